@@ -0,0 +1,347 @@
+/*
+Package audit provides a Hord database driver that records every change made to the underlying database. To use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/audit"
+	)
+
+Each successful Set and Delete emits an append-only Record describing the operation, the key, the caller, the time of
+the change, and SHA-256 hashes of the previous and new values. Records are written to a pluggable Sink; this package
+provides sinks for files, Hord databases, and channels.
+
+The caller identity is taken from the context bound to the driver with WithContext(). Use WithCaller() to attach an
+identity to a context.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the audit driver.
+
+	// Handle database connection
+	var database hord.Database
+	...
+
+	// Open an audit log file
+	sink, err := audit.OpenFileSink("/var/log/hord-audit.log")
+	if err != nil {
+	    // Handle error
+	}
+
+	db, err := audit.Dial(audit.Config{
+		Database: database,
+		Sink:     sink,
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the audit driver, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Identify the caller for this request
+	ctx := audit.WithCaller(r.Context(), "user@example.com")
+
+	// Set a value, recording the change
+	err = db.WithContext(ctx).Set("key", []byte("value"))
+	if err != nil {
+	    // Handle error
+	}
+*/
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// Config provides the configuration options for the Audit driver.
+type Config struct {
+	// Database is the underlying database being audited.
+	Database hord.Database
+
+	// Sink receives a Record for every change made to the Database.
+	Sink Sink
+}
+
+// Audit is used to record changes made to a database. It also satisfies the Hord database interface.
+type Audit struct {
+	data hord.Database
+	sink Sink
+	ctx  context.Context
+
+	// locks serializes changes to each key so that recorded value hashes reflect the order of operations on the key.
+	// It is shared between drivers created with WithContext.
+	locks *keyLocks
+}
+
+// keyLocks holds a lock for each key being changed.
+type keyLocks struct {
+	sync.Mutex
+	keys map[string]*keyLock
+}
+
+// keyLock is the lock of a single key, removed once no changes to the key are waiting.
+type keyLock struct {
+	sync.Mutex
+	waiting int
+}
+
+// Operation identifies the type of change recorded.
+type Operation string
+
+const (
+	// OperationSet is recorded when a value is stored.
+	OperationSet Operation = "set"
+
+	// OperationDelete is recorded when a value is deleted.
+	OperationDelete Operation = "delete"
+)
+
+// Record describes a single change made to the database.
+type Record struct {
+	// Operation is the type of change.
+	Operation Operation `json:"operation"`
+
+	// Key is the key that was changed.
+	Key string `json:"key"`
+
+	// Caller is the identity of the caller that made the change, taken from the context. Empty if no caller was set.
+	Caller string `json:"caller,omitempty"`
+
+	// Timestamp is the time the change was made.
+	Timestamp time.Time `json:"timestamp"`
+
+	// OldHash is the hex encoded SHA-256 hash of the previous value. Empty if the key did not exist.
+	OldHash string `json:"old_hash,omitempty"`
+
+	// NewHash is the hex encoded SHA-256 hash of the new value. Empty if the key was deleted.
+	NewHash string `json:"new_hash,omitempty"`
+}
+
+var (
+	// ErrNoSink is returned by Dial when no Sink is provided.
+	ErrNoSink = errors.New("audit sink must be provided")
+
+	// ErrSinkFailed is returned when a change was made but the audit record could not be written to the Sink.
+	ErrSinkFailed = errors.New("unable to write audit record")
+
+	// ErrSinkTimeout is returned by a TimeoutChannelSink when a record is not received before the timeout.
+	ErrSinkTimeout = errors.New("audit record was not received before the timeout")
+)
+
+// callerKey is the context key used to store the caller identity.
+type callerKey struct{}
+
+// WithCaller returns a copy of ctx carrying the caller identity recorded with each change.
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller identity carried by ctx, or an empty string if none is set.
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
+
+// Dial will create a new Audit driver using the provided Config. It will return an error if the Database or Sink is
+// nil.
+func Dial(cfg Config) (*Audit, error) {
+	if cfg.Database == nil {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	if cfg.Sink == nil {
+		return nil, ErrNoSink
+	}
+
+	return &Audit{
+		data:  cfg.Database,
+		sink:  cfg.Sink,
+		ctx:   context.Background(),
+		locks: &keyLocks{keys: make(map[string]*keyLock)},
+	}, nil
+}
+
+// WithContext returns a copy of the driver that records the caller identity carried by ctx. The copy shares the
+// underlying database and sink with the original.
+func (db *Audit) WithContext(ctx context.Context) *Audit {
+	if db == nil {
+		return nil
+	}
+
+	c := *db
+	c.ctx = ctx
+	return &c
+}
+
+// Setup will run the Setup function for the underlying database.
+func (db *Audit) Setup() error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.Setup()
+}
+
+// HealthCheck will run the HealthCheck function for the underlying database.
+func (db *Audit) HealthCheck() error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.HealthCheck()
+}
+
+// Get will fetch the value for the specified key from the underlying database. Reads are not recorded.
+func (db *Audit) Get(key string) ([]byte, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.data.Get(key)
+}
+
+// Set will store the value for the specified key and record the change. If the value is stored but the record
+// cannot be written, an error wrapping ErrSinkFailed is returned.
+func (db *Audit) Set(key string, data []byte) error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	defer db.locks.lock(key)()
+
+	old, err := db.hash(key)
+	if err != nil {
+		return err
+	}
+
+	err = db.data.Set(key, data)
+	if err != nil {
+		return err
+	}
+
+	return db.record(OperationSet, key, old, hashValue(data))
+}
+
+// Delete will delete the value for the specified key and record the change. If the value is deleted but the record
+// cannot be written, an error wrapping ErrSinkFailed is returned.
+func (db *Audit) Delete(key string) error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	defer db.locks.lock(key)()
+
+	old, err := db.hash(key)
+	if err != nil {
+		return err
+	}
+
+	err = db.data.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	return db.record(OperationDelete, key, old, "")
+}
+
+// Keys will return the keys from the underlying database.
+func (db *Audit) Keys() ([]string, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.data.Keys()
+}
+
+// Close will close the underlying database.
+func (db *Audit) Close() {
+	if db != nil && db.data != nil {
+		db.data.Close()
+	}
+}
+
+// GetDatabase will return the underlying database.
+func (db *Audit) GetDatabase() hord.Database {
+	return db.data
+}
+
+// lock will lock the key, returning the function to unlock it. Changes to different keys do not wait for each other.
+func (l *keyLocks) lock(key string) func() {
+	l.Lock()
+	k, ok := l.keys[key]
+	if !ok {
+		k = &keyLock{}
+		l.keys[key] = k
+	}
+	k.waiting++
+	l.Unlock()
+
+	k.Lock()
+
+	return func() {
+		k.Unlock()
+
+		l.Lock()
+		k.waiting--
+		if k.waiting == 0 {
+			delete(l.keys, key)
+		}
+		l.Unlock()
+	}
+}
+
+// hash will return the hash of the current value of the specified key, or an empty string if the key does not exist.
+func (db *Audit) hash(key string) (string, error) {
+	data, err := db.data.Get(key)
+	if errors.Is(err, hord.ErrNil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return hashValue(data), nil
+}
+
+// record will write a Record of the change to the sink.
+func (db *Audit) record(op Operation, key, oldHash, newHash string) error {
+	r := Record{
+		Operation: op,
+		Key:       key,
+		Caller:    CallerFromContext(db.ctx),
+		Timestamp: time.Now(),
+		OldHash:   oldHash,
+		NewHash:   newHash,
+	}
+
+	err := db.sink.Write(r)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSinkFailed, err)
+	}
+
+	return nil
+}
+
+// hashValue returns the hex encoded SHA-256 hash of data.
+func hashValue(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,357 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// ErrDatabaseTest is used for testing purposes
+var ErrDatabaseTest = errors.New("database error")
+
+// failingSink is a Sink that always returns an error.
+type failingSink struct{}
+
+func (failingSink) Write(_ Record) error {
+	return ErrDatabaseTest
+}
+
+// sinkFunc is a Sink calling the function for every record.
+type sinkFunc func(Record) error
+
+func (f sinkFunc) Write(r Record) error {
+	return f(r)
+}
+
+// setupAudit is a helper function to create a new Audit driver backed by a hashmap database.
+func setupAudit(t *testing.T, sink Sink) *Audit {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	db, err := Dial(Config{Database: database, Sink: sink})
+	if err != nil {
+		t.Fatalf("Failed to create audit driver - %s", err)
+	}
+
+	return db
+}
+
+// storedRecords returns the records stored within the database sink.
+func storedRecords(t *testing.T, database hord.Database) []Record {
+	keys, err := database.Keys()
+	if err != nil {
+		t.Fatalf("Unexpected error fetching keys - %s", err)
+	}
+
+	var records []Record
+	for _, k := range keys {
+		data, err := database.Get(k)
+		if err != nil {
+			t.Fatalf("Unexpected error fetching record %s - %s", k, err)
+		}
+
+		var r Record
+		if err := json.Unmarshal(data, &r); err != nil {
+			t.Fatalf("Unexpected error decoding record %s - %s", k, err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestCaller(t *testing.T) {
+	if c := CallerFromContext(context.Background()); c != "" {
+		t.Errorf("Expected empty caller, got %s", c)
+	}
+
+	ctx := WithCaller(context.Background(), "alice")
+	if c := CallerFromContext(ctx); c != "alice" {
+		t.Errorf("Unexpected caller - got %s, expected alice", c)
+	}
+}
+
+func TestDatabaseSink(t *testing.T) {
+	sink, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to sink database - %s", err)
+	}
+
+	db := setupAudit(t, &DatabaseSink{Database: sink})
+	ctx := WithCaller(context.Background(), "alice")
+
+	t.Run("Record Set", func(t *testing.T) {
+		for _, v := range []string{"v1", "v2"} {
+			if err := db.WithContext(ctx).Set("key", []byte(v)); err != nil {
+				t.Fatalf("Set() returned error: %s", err)
+			}
+		}
+
+		records := storedRecords(t, sink)
+		if len(records) != 2 {
+			t.Fatalf("Unexpected number of records - got %d, expected 2", len(records))
+		}
+
+		for _, r := range records {
+			if r.Operation != OperationSet || r.Key != "key" || r.Caller != "alice" || r.Timestamp.IsZero() {
+				t.Errorf("Unexpected record - %+v", r)
+			}
+			switch r.NewHash {
+			case hashValue([]byte("v1")):
+				if r.OldHash != "" {
+					t.Errorf("Expected empty old hash for new key - %+v", r)
+				}
+			case hashValue([]byte("v2")):
+				if r.OldHash != hashValue([]byte("v1")) {
+					t.Errorf("Expected old hash to match previous value - %+v", r)
+				}
+			default:
+				t.Errorf("Unexpected new hash - %+v", r)
+			}
+		}
+	})
+
+	t.Run("Record Delete", func(t *testing.T) {
+		if err := db.Delete("key"); err != nil {
+			t.Fatalf("Delete() returned error: %s", err)
+		}
+
+		records := storedRecords(t, sink)
+		if len(records) != 3 {
+			t.Fatalf("Unexpected number of records - got %d, expected 3", len(records))
+		}
+
+		found := false
+		for _, r := range records {
+			if r.Operation == OperationDelete {
+				found = true
+				if r.OldHash != hashValue([]byte("v2")) || r.NewHash != "" || r.Caller != "" {
+					t.Errorf("Unexpected delete record - %+v", r)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Delete was not recorded - %+v", records)
+		}
+	})
+
+	t.Run("Reads Are Not Recorded", func(t *testing.T) {
+		_, _ = db.Get("key")
+		_, _ = db.Keys()
+
+		if n := len(storedRecords(t, sink)); n != 3 {
+			t.Errorf("Unexpected number of records - got %d, expected 3", n)
+		}
+	})
+}
+
+func TestChannelSink(t *testing.T) {
+	ch := make(chan Record, 1)
+	db := setupAudit(t, ChannelSink(ch))
+
+	err := db.WithContext(WithCaller(context.Background(), "bob")).Set("key", []byte("value"))
+	if err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+
+	r := <-ch
+	if r.Operation != OperationSet || r.Key != "key" || r.Caller != "bob" || r.NewHash != hashValue([]byte("value")) {
+		t.Errorf("Unexpected record - %+v", r)
+	}
+}
+
+func TestTimeoutChannelSink(t *testing.T) {
+	t.Run("Received", func(t *testing.T) {
+		ch := make(chan Record, 1)
+		db := setupAudit(t, TimeoutChannelSink{Channel: ch})
+
+		if err := db.Set("key", []byte("value")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		if r := <-ch; r.Operation != OperationSet || r.Key != "key" {
+			t.Errorf("Unexpected record - %+v", r)
+		}
+	})
+
+	t.Run("No Receiver", func(t *testing.T) {
+		db := setupAudit(t, TimeoutChannelSink{Channel: make(chan Record)})
+
+		err := db.Set("key", []byte("value"))
+		if !errors.Is(err, ErrSinkFailed) || !errors.Is(err, ErrSinkTimeout) {
+			t.Errorf("Set() returned error: %v, expected %s", err, ErrSinkTimeout)
+		}
+
+		// The change is still made
+		if data, err := db.Get("key"); err != nil || string(data) != "value" {
+			t.Errorf("Get() returned %s - %v, expected value", data, err)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		db := setupAudit(t, TimeoutChannelSink{Channel: make(chan Record), Timeout: 10 * time.Millisecond})
+
+		err := db.Delete("key")
+		if !errors.Is(err, ErrSinkTimeout) {
+			t.Errorf("Delete() returned error: %v, expected %s", err, ErrSinkTimeout)
+		}
+	})
+}
+
+func TestKeyLocks(t *testing.T) {
+	release := make(chan struct{})
+	db := setupAudit(t, sinkFunc(func(r Record) error {
+		if r.Key == "blocked" {
+			<-release
+		}
+		return nil
+	}))
+
+	// A change waiting for its record to be written does not block changes to other keys
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- db.Set("blocked", []byte("value"))
+	}()
+
+	for {
+		db.locks.Lock()
+		_, ok := db.locks.keys["blocked"]
+		db.locks.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- db.WithContext(context.Background()).Set("other", []byte("value"))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Set() returned error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Changes to different keys were serialized")
+	}
+
+	close(release)
+	if err := <-blocked; err != nil {
+		t.Errorf("Set() returned error: %s", err)
+	}
+
+	db.locks.Lock()
+	defer db.locks.Unlock()
+	if len(db.locks.keys) != 0 {
+		t.Errorf("Expected key locks to be released - %d remain", len(db.locks.keys))
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := t.TempDir() + "/audit.log"
+
+	sink, err := OpenFileSink(path)
+	if err != nil {
+		t.Fatalf("OpenFileSink() returned error: %s", err)
+	}
+
+	db := setupAudit(t, sink)
+	if err := db.Set("key", []byte("value")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+	if err := db.Delete("key"); err != nil {
+		t.Fatalf("Delete() returned error: %s", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() returned error: %s", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unable to open audit log - %s", err)
+	}
+	defer f.Close() // nolint:errcheck
+
+	var ops []Operation
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("Unable to decode audit record - %s", err)
+		}
+		ops = append(ops, r.Operation)
+	}
+
+	if len(ops) != 2 || ops[0] != OperationSet || ops[1] != OperationDelete {
+		t.Errorf("Unexpected audit log contents - %v", ops)
+	}
+
+	t.Run("Invalid Path", func(t *testing.T) {
+		_, err := OpenFileSink(t.TempDir() + "/missing/audit.log")
+		if err == nil {
+			t.Errorf("Expected error opening audit log within missing directory")
+		}
+	})
+}
+
+func TestAuditFailures(t *testing.T) {
+	tt := map[string]struct {
+		cfg  mock.Config
+		sink Sink
+		err  error
+	}{
+		"Sink Fails": {
+			cfg: mock.Config{
+				GetFunc: func(_ string) ([]byte, error) { return nil, hord.ErrNil },
+				SetFunc: func(_ string, _ []byte) error { return nil },
+			},
+			sink: failingSink{},
+			err:  ErrSinkFailed,
+		},
+		"Set Fails": {
+			cfg: mock.Config{
+				GetFunc: func(_ string) ([]byte, error) { return nil, hord.ErrNil },
+				SetFunc: func(_ string, _ []byte) error { return ErrDatabaseTest },
+			},
+			sink: ChannelSink(make(chan Record)),
+			err:  ErrDatabaseTest,
+		},
+		"Get Fails": {
+			cfg: mock.Config{
+				GetFunc: func(_ string) ([]byte, error) { return nil, ErrDatabaseTest },
+			},
+			sink: ChannelSink(make(chan Record)),
+			err:  ErrDatabaseTest,
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			database, err := mock.Dial(tc.cfg)
+			if err != nil {
+				t.Fatalf("Failed to create mock database - %s", err)
+			}
+
+			db, err := Dial(Config{Database: database, Sink: tc.sink})
+			if err != nil {
+				t.Fatalf("Failed to create audit driver - %s", err)
+			}
+
+			err = db.Set("key", []byte("data"))
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Set() returned error: %v, expected %s", err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+module github.com/tarmac-project/hord/audit
+
+go 1.23.0
+
+require (
+	github.com/tarmac-project/hord v0.9.0
+	github.com/tarmac-project/hord/drivers/hashmap v0.8.1
+	github.com/tarmac-project/hord/drivers/mock v0.6.4
+)
+
+require gopkg.in/yaml.v3 v3.0.1 // indirect
+
+// Build against the hord module within this repository until v0.9.0 is released.
+replace github.com/tarmac-project/hord => ../
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// Sink receives audit records. Implementations must be safe for concurrent use.
+type Sink interface {
+	// Write appends the record to the audit log.
+	Write(Record) error
+}
+
+// FileSink appends records to a file as newline delimited JSON.
+type FileSink struct {
+	sync.Mutex
+	file *os.File
+}
+
+// OpenFileSink opens, or creates, the file at path for appending audit records.
+func OpenFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open audit log: %w", err)
+	}
+
+	return &FileSink{file: f}, nil
+}
+
+// Write appends the record to the file.
+func (s *FileSink) Write(r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	_, err = s.file.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the underlying file.
+func (s *FileSink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.file.Close()
+}
+
+// DatabaseSink stores each record as a JSON value within a Hord database. Records are stored under unique keys made
+// of the Prefix, the record timestamp, and a sequence number, so existing records are never overwritten.
+type DatabaseSink struct {
+	// Database is the database records are stored in.
+	Database hord.Database
+
+	// Prefix is the key prefix for stored records. Default is "audit.".
+	Prefix string
+
+	lock sync.Mutex
+	seq  uint64
+}
+
+// Write stores the record within the database.
+func (s *DatabaseSink) Write(r Record) error {
+	if s.Database == nil {
+		return hord.ErrNoDial
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.seq++
+	seq := s.seq
+	s.lock.Unlock()
+
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "audit."
+	}
+
+	return s.Database.Set(prefix+strconv.FormatInt(r.Timestamp.UnixNano(), 10)+"."+strconv.FormatUint(seq, 10), b)
+}
+
+// ChannelSink sends each record to a channel. Writes block until the record is received, so changes to a key wait for
+// their record to be received. Use TimeoutChannelSink to bound the wait.
+type ChannelSink chan<- Record
+
+// Write sends the record to the channel.
+func (s ChannelSink) Write(r Record) error {
+	s <- r
+	return nil
+}
+
+// TimeoutChannelSink sends each record to a channel, returning ErrSinkTimeout if the record is not received within
+// Timeout. Records that are not received are dropped.
+type TimeoutChannelSink struct {
+	// Channel is the channel records are sent to.
+	Channel chan<- Record
+
+	// Timeout is how long Write waits for the record to be received. Default is 0, which never waits and only sends
+	// records the channel is ready to receive.
+	Timeout time.Duration
+}
+
+// Write sends the record to the channel, waiting up to Timeout for it to be received.
+func (s TimeoutChannelSink) Write(r Record) error {
+	if s.Timeout <= 0 {
+		select {
+		case s.Channel <- r:
+			return nil
+		default:
+			return ErrSinkTimeout
+		}
+	}
+
+	timer := time.NewTimer(s.Timeout)
+	defer timer.Stop()
+
+	select {
+	case s.Channel <- r:
+		return nil
+	case <-timer.C:
+		return ErrSinkTimeout
+	}
+}
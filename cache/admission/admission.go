@@ -0,0 +1,206 @@
+/*
+Package admission provides cache admission policies, deciding which values are worth storing within a cache. Admission
+policies protect caches from being polluted by values that are unlikely to be read again, such as the keys touched by
+a one-off scanning job. To use admission policies, import this package as follows:
+
+	import (
+	    "github.com/tarmac-project/hord/cache/admission"
+	)
+
+The following policies are provided:
+
+  - Always() admits every value.
+  - NewTinyLFU() admits keys once they have been requested frequently, estimating request frequency with a
+    count-min sketch that is periodically aged so past popularity fades.
+  - MaxSize() admits values up to a maximum size.
+  - Predicate() admits keys matching a function.
+
+Policies can be combined with All(), which admits values admitted by every policy.
+
+	policy := admission.All(
+		admission.MaxSize(64 << 10),
+		admission.Predicate(func(key string) bool { return !strings.HasPrefix(key, "lock:") }),
+		admission.NewTinyLFU(admission.TinyLFUConfig{}),
+	)
+*/
+package admission
+
+import (
+	"sync"
+
+	"github.com/tarmac-project/hord/cache/internal/doublehash"
+)
+
+// Policy decides whether values are stored within a cache.
+type Policy interface {
+	// Admit returns true if the value for the key should be stored within the cache. It is called every time a value
+	// is about to be stored, which frequency based policies count as a request for the key.
+	Admit(key string, data []byte) bool
+}
+
+// PolicyFunc is a function satisfying the Policy interface.
+type PolicyFunc func(key string, data []byte) bool
+
+// Admit calls f(key, data).
+func (f PolicyFunc) Admit(key string, data []byte) bool {
+	return f(key, data)
+}
+
+// Always returns a Policy admitting every value.
+func Always() Policy {
+	return PolicyFunc(func(_ string, _ []byte) bool {
+		return true
+	})
+}
+
+// MaxSize returns a Policy admitting values of at most size bytes.
+func MaxSize(size int) Policy {
+	return PolicyFunc(func(_ string, data []byte) bool {
+		return len(data) <= size
+	})
+}
+
+// Predicate returns a Policy admitting the keys for which fn returns true.
+func Predicate(fn func(key string) bool) Policy {
+	return PolicyFunc(func(key string, _ []byte) bool {
+		return fn(key)
+	})
+}
+
+// All returns a Policy admitting values admitted by every policy. Policies are evaluated in order and evaluation stops
+// at the first rejection, so later frequency based policies only count requests admitted by earlier policies.
+func All(policies ...Policy) Policy {
+	return PolicyFunc(func(key string, data []byte) bool {
+		for _, p := range policies {
+			if !p.Admit(key, data) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+const (
+	// DefaultThreshold is the default number of requests before TinyLFU admits a key.
+	DefaultThreshold = 2
+
+	// DefaultSampleSize is the default number of requests TinyLFU counts before ageing its counters.
+	DefaultSampleSize = 100000
+
+	// sketchDepth is the number of counter rows within the count-min sketch.
+	sketchDepth = 4
+
+	// maxCount is the largest value held by a sketch counter.
+	maxCount = 15
+)
+
+// TinyLFUConfig provides the configuration options for the TinyLFU policy.
+type TinyLFUConfig struct {
+	// Threshold is the estimated number of requests for a key, including the current request, before it is admitted.
+	// Default is 2, which admits keys on their second request. The maximum is 15.
+	Threshold int
+
+	// SampleSize is the number of requests counted before every counter is halved, so the frequency of keys that are
+	// no longer requested decays. It also sizes the sketch. Default is 100000.
+	SampleSize int
+}
+
+// TinyLFU is a Policy admitting keys once their estimated request frequency reaches a threshold. Frequencies are
+// estimated using a count-min sketch of byte counters saturating at 15, which is aged every SampleSize requests. It is
+// safe for concurrent use.
+type TinyLFU struct {
+	sync.Mutex
+
+	threshold  uint8
+	sampleSize int
+	requests   int
+
+	mask uint64
+	rows [sketchDepth][]uint8
+}
+
+// NewTinyLFU returns a new TinyLFU policy using the provided TinyLFUConfig.
+func NewTinyLFU(cfg TinyLFUConfig) *TinyLFU {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = DefaultThreshold
+	}
+	if cfg.Threshold > maxCount {
+		cfg.Threshold = maxCount
+	}
+
+	if cfg.SampleSize <= 0 {
+		cfg.SampleSize = DefaultSampleSize
+	}
+
+	// Size each row to the next power of two above the sample size
+	width := uint64(1)
+	for width < uint64(cfg.SampleSize) {
+		width <<= 1
+	}
+
+	p := &TinyLFU{
+		threshold:  uint8(cfg.Threshold),
+		sampleSize: cfg.SampleSize,
+		mask:       width - 1,
+	}
+	for i := range p.rows {
+		p.rows[i] = make([]uint8, width)
+	}
+
+	return p
+}
+
+// Admit counts a request for the key, returning true once the estimated frequency of the key reaches the threshold.
+func (p *TinyLFU) Admit(key string, _ []byte) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	p.increment(key)
+	return p.estimate(key) >= p.threshold
+}
+
+// Estimate returns the estimated number of requests for the key.
+func (p *TinyLFU) Estimate(key string) int {
+	p.Lock()
+	defer p.Unlock()
+
+	return int(p.estimate(key))
+}
+
+// increment will count a request for the key, ageing the sketch once the sample size is reached.
+func (p *TinyLFU) increment(key string) {
+	h1, h2 := doublehash.Sum(key)
+	for i := range p.rows {
+		n := (h1 + uint64(i)*h2) & p.mask
+		if p.rows[i][n] < maxCount {
+			p.rows[i][n]++
+		}
+	}
+
+	p.requests++
+	if p.requests >= p.sampleSize {
+		p.age()
+	}
+}
+
+// estimate returns the smallest counter for the key, which bounds the number of requests for it.
+func (p *TinyLFU) estimate(key string) uint8 {
+	h1, h2 := doublehash.Sum(key)
+	var count uint8 = maxCount
+	for i := range p.rows {
+		if c := p.rows[i][(h1+uint64(i)*h2)&p.mask]; c < count {
+			count = c
+		}
+	}
+	return count
+}
+
+// age will halve every counter.
+func (p *TinyLFU) age() {
+	for i := range p.rows {
+		for j := range p.rows[i] {
+			p.rows[i][j] >>= 1
+		}
+	}
+	p.requests /= 2
+}
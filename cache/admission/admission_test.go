@@ -0,0 +1,122 @@
+package admission
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPolicies(t *testing.T) {
+	unitTests := map[string]struct {
+		policy   Policy
+		key      string
+		data     []byte
+		expected bool
+	}{
+		"Always": {
+			policy:   Always(),
+			key:      "key",
+			data:     []byte("data"),
+			expected: true,
+		},
+		"Max Size Within Limit": {
+			policy:   MaxSize(4),
+			key:      "key",
+			data:     []byte("data"),
+			expected: true,
+		},
+		"Max Size Exceeded": {
+			policy: MaxSize(3),
+			key:    "key",
+			data:   []byte("data"),
+		},
+		"Predicate Match": {
+			policy:   Predicate(func(key string) bool { return strings.HasPrefix(key, "user:") }),
+			key:      "user:1",
+			expected: true,
+		},
+		"Predicate Mismatch": {
+			policy: Predicate(func(key string) bool { return strings.HasPrefix(key, "user:") }),
+			key:    "lock:1",
+		},
+		"All Admitted": {
+			policy:   All(Always(), MaxSize(4)),
+			key:      "key",
+			data:     []byte("data"),
+			expected: true,
+		},
+		"All Rejected": {
+			policy: All(Always(), MaxSize(3)),
+			key:    "key",
+			data:   []byte("data"),
+		},
+		"All Empty": {
+			policy:   All(),
+			key:      "key",
+			expected: true,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			if got := test.policy.Admit(test.key, test.data); got != test.expected {
+				t.Errorf("Admit(%s) returned %t, expected %t", test.key, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestTinyLFU(t *testing.T) {
+	t.Run("Threshold", func(t *testing.T) {
+		p := NewTinyLFU(TinyLFUConfig{Threshold: 3})
+
+		for i, expected := range []bool{false, false, true, true} {
+			if got := p.Admit("key", nil); got != expected {
+				t.Errorf("Admit() request %d returned %t, expected %t", i+1, got, expected)
+			}
+		}
+
+		if n := p.Estimate("key"); n != 4 {
+			t.Errorf("Unexpected estimate - got %d, expected 4", n)
+		}
+	})
+
+	t.Run("One-off Keys Rejected", func(t *testing.T) {
+		p := NewTinyLFU(TinyLFUConfig{})
+
+		var admitted int
+		for i := 0; i < 10000; i++ {
+			if p.Admit(fmt.Sprintf("scan-%d", i), nil) {
+				admitted++
+			}
+		}
+
+		// Collisions may admit a small number of one-off keys
+		if admitted > 100 {
+			t.Errorf("Unexpected number of one-off keys admitted - %d", admitted)
+		}
+	})
+
+	t.Run("Ageing", func(t *testing.T) {
+		p := NewTinyLFU(TinyLFUConfig{SampleSize: 64})
+
+		for i := 0; i < 8; i++ {
+			p.Admit("hot", nil)
+		}
+
+		for i := 0; i < 200; i++ {
+			p.Admit(fmt.Sprintf("other-%d", i), nil)
+		}
+
+		if n := p.Estimate("hot"); n >= 8 {
+			t.Errorf("Expected estimate to decay - got %d", n)
+		}
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		p := NewTinyLFU(TinyLFUConfig{Threshold: 100})
+		if p.threshold != maxCount || p.sampleSize != DefaultSampleSize {
+			t.Errorf("Unexpected defaults - threshold %d, sample size %d", p.threshold, p.sampleSize)
+		}
+	})
+}
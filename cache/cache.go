@@ -81,22 +81,56 @@ Hord provides a simple abstraction for working with the cache, with easy-to-use
 	if err != nil {
 	    // Handle error
 	}
+
+# Per-call Options
+
+GetWithOptions() changes the behavior of a single lookup, for example reading a value directly from the database.
+Options are supported by the Lookaside type, while other types return ErrOptionsUnsupported unless no option is set.
+
+	value, err := cache.GetWithOptions(db, "key", cache.GetOptions{Refresh: true})
+	if err != nil {
+	    // Handle error
+	}
+
+# Driver Methods
+
+Dial() may wrap the cache driver, for example to warm it up or to broadcast invalidations. Use As() to reach methods of
+the cache driver beneath any wrapping.
+
+	if l, ok := cache.As[*lookaside.Lookaside](db); ok {
+	    stats := l.Stats()
+	}
 */
 package cache
 
 import (
 	"errors"
+	"time"
 
 	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/cache/admission"
+	"github.com/tarmac-project/hord/cache/invalidation"
+	"github.com/tarmac-project/hord/cache/keymap"
 	"github.com/tarmac-project/hord/cache/lookaside"
+	"github.com/tarmac-project/hord/cache/readthrough"
+	"github.com/tarmac-project/hord/cache/refreshahead"
+	"github.com/tarmac-project/hord/cache/tiered"
+	"github.com/tarmac-project/hord/cache/warmup"
+	"github.com/tarmac-project/hord/cache/writebehind"
+	"github.com/tarmac-project/hord/cache/writethrough"
 )
 
 // CacheType is the type of cache to use.
 type Type string
 
 const (
-	Lookaside Type = "lookaside"
-	None      Type = "none"
+	Lookaside    Type = "lookaside"
+	ReadThrough  Type = "readthrough"
+	WriteThrough Type = "writethrough"
+	WriteBehind  Type = "writebehind"
+	RefreshAhead Type = "refreshahead"
+	Tiered       Type = "tiered"
+	None         Type = "none"
 )
 
 // Config provides the configuration options for the Cache driver.
@@ -104,6 +138,110 @@ type Config struct {
 	Type     Type
 	Database hord.Database
 	Cache    hord.Database
+
+	// Loader fetches values on a cache miss for the ReadThrough type. Default is the Get function of Database, in which
+	// case Set and Delete also write to Database. When a Loader is provided, Database is optional and Set and Delete
+	// only update Cache, so a deleted key is loaded again by the next Get.
+	Loader readthrough.Loader
+
+	// FailurePolicy defines how cache write failures are handled for the WriteThrough type. Default is
+	// writethrough.Invalidate.
+	FailurePolicy writethrough.FailurePolicy
+
+	// DeleteBeforeWrite removes keys from the cache before writing to the database for the WriteThrough type.
+	DeleteBeforeWrite bool
+
+	// Retries is the number of times a failed cache write is retried with the writethrough.Retry policy for the
+	// WriteThrough type. Default is writethrough.DefaultRetries.
+	Retries int
+
+	// RetryInterval is the time between retries with the writethrough.Retry policy for the WriteThrough type. Default
+	// is writethrough.DefaultRetryInterval.
+	RetryInterval time.Duration
+
+	// FlushInterval is the time between flushes of queued changes for the WriteBehind type. Default is
+	// writebehind.DefaultFlushInterval.
+	FlushInterval time.Duration
+
+	// BatchSize is the number of queued keys that triggers a flush before the FlushInterval elapses for the
+	// WriteBehind type. Default is writebehind.DefaultBatchSize.
+	BatchSize int
+
+	// FlushRetries is the number of flushes that retry a failed change before it is dropped for the WriteBehind type.
+	// Default is writebehind.DefaultMaxRetries.
+	FlushRetries int
+
+	// OnFlushError is called once for each queued change dropped after failing to be written to the database for the
+	// WriteBehind type.
+	OnFlushError func(key string, err error)
+
+	// TTL is the time a cached value is considered fresh for the RefreshAhead type. Default is
+	// refreshahead.DefaultTTL.
+	TTL time.Duration
+
+	// RefreshWindow is the period before the TTL elapses in which reading a key triggers a background refresh for the
+	// RefreshAhead type. Default is one fifth of the TTL.
+	RefreshWindow time.Duration
+
+	// Workers is the number of background refresh workers for the RefreshAhead type. Default is
+	// refreshahead.DefaultWorkers.
+	Workers int
+
+	// SoftTTL is the time after which cached values are considered stale for the Lookaside type.
+	SoftTTL time.Duration
+
+	// StaleWhileRevalidate serves stale values while refreshing them in the background for the Lookaside type.
+	StaleWhileRevalidate bool
+
+	// ServeStaleOnError serves stale values when the database returns an error for the Lookaside type.
+	ServeStaleOnError bool
+
+	// Coalesce shares a single database fetch between concurrent cache misses for the same key for the Lookaside type.
+	Coalesce bool
+
+	// NegativeTTL is the time keys not found within the database are recorded as missing for the Lookaside type.
+	NegativeTTL time.Duration
+
+	// Filter rejects keys known to be missing from the database using a Bloom filter for the Lookaside type. Keys
+	// invalidated by other instances through Invalidation are added to the filter as they arrive.
+	Filter bool
+
+	// FilterFalsePositiveRate is the target false positive rate of the membership filter for the Lookaside type.
+	FilterFalsePositiveRate float64
+
+	// FilterRebuildInterval is the time between rebuilds of the membership filter for the Lookaside type.
+	FilterRebuildInterval time.Duration
+
+	// Admission decides which values are stored within the cache for the Lookaside type. Default is nil, which admits
+	// every value.
+	Admission admission.Policy
+
+	// Policies override the SoftTTL, Admission and write behavior for keys matching a pattern for the Lookaside type.
+	// Dial returns ErrPoliciesUnsupported when Policies are provided for any other type.
+	Policies []Policy
+
+	// Degrade serves lookups directly from the database while the cache is failing for the Lookaside type, resuming use
+	// of the cache once it recovers.
+	Degrade bool
+
+	// RecoveryInterval is the time between cache health checks while degraded for the Lookaside type.
+	RecoveryInterval time.Duration
+
+	// Tiers is the list of cache tiers, ordered from fastest to slowest, placed in front of Database for the Tiered
+	// type. When Tiers are provided, Cache is optional and unused. Default is Cache as the only cache tier.
+	Tiers []tiered.Tier
+
+	// Invalidation broadcasts the keys of every Set and Delete to other instances, which remove them from their Cache,
+	// or from the first of the Tiers for the Tiered type. Default is nil, which disables invalidation.
+	Invalidation invalidation.Bus
+
+	// KeyMapper maps keys before they reach Cache, or every one of the Tiers for the Tiered type, for example hashing
+	// them with keymap.SHA256(). Database keeps the original keys. Default is nil, which stores keys as-is.
+	KeyMapper keymap.Mapper
+
+	// WarmUp warms the cache during Setup() by reading the configured keys through the cache. The Database and Source
+	// are set to the cache driver and Database unless Source is provided. Default is nil, which disables warm-up.
+	WarmUp *warmup.Config
 }
 
 // NilCache is a nil cache driver that returns dial errors. It fixes the issue when the Dial function returns a nil hord.Database this prevents nil pointer errors.
@@ -112,19 +250,184 @@ type NilCache struct{}
 var (
 	// ErrNoType is returned when the CacheType is invalid.
 	ErrNoType = errors.New("invalid CacheType")
+
+	// ErrOptionsUnsupported is returned by GetWithOptions when the cache type does not support the options set.
+	ErrOptionsUnsupported = errors.New("get options are not supported by the cache type")
+
+	// ErrPoliciesUnsupported is returned by Dial when Policies are provided for a cache type that does not apply them.
+	ErrPoliciesUnsupported = errors.New("key policies are not supported by the cache type")
+)
+
+// WritePolicy defines how Set updates the cache for keys matching a Policy.
+type WritePolicy = lookaside.WritePolicy
+
+const (
+	// PolicyWrite stores the new value within the cache. This is the default.
+	PolicyWrite = lookaside.Write
+
+	// PolicyInvalidate removes the key from the cache, so the next Get fills it from the database.
+	PolicyInvalidate = lookaside.Invalidate
 )
 
+// Forever is a Policy TTL for values that never become stale.
+const Forever = lookaside.Forever
+
+// Policy overrides the caching behavior for keys matching a pattern. Policies are applied by the Lookaside type.
+type Policy = lookaside.Policy
+
+// GetOptions changes the behavior of a single call to GetWithOptions.
+type GetOptions = lookaside.GetOptions
+
 // Dial will create a new Cache driver using the provided Config. It will return an error if either the Database or Cache values in Config are nil or if a CacheType is not specified.
 func Dial(cfg Config) (hord.Database, error) {
-	if (cfg.Database == nil) || (cfg.Cache == nil) {
+	if (cfg.Database == nil && (cfg.Type != ReadThrough || cfg.Loader == nil)) || (cfg.Cache == nil && (cfg.Type != Tiered || len(cfg.Tiers) == 0)) {
 		return &NilCache{}, hord.ErrInvalidDatabase
 	}
 
+	if len(cfg.Policies) > 0 && cfg.Type != Lookaside {
+		return &NilCache{}, ErrPoliciesUnsupported
+	}
+
+	if cfg.KeyMapper != nil {
+		var err error
+		cfg, err = mapKeys(cfg)
+		if err != nil {
+			return &NilCache{}, err
+		}
+	}
+
+	db, err := dial(cfg)
+	if err != nil {
+		return db, err
+	}
+
+	if cfg.WarmUp != nil {
+		w := *cfg.WarmUp
+		w.Database = db
+		if w.Source == nil {
+			w.Source = cfg.Database
+		}
+
+		db, err = warmup.Dial(w)
+		if err != nil {
+			return &NilCache{}, err
+		}
+	}
+
+	if cfg.Invalidation == nil {
+		return db, nil
+	}
+
+	// Remove keys invalidated by other instances from the fastest cache tier
+	local := cfg.Cache
+	if cfg.Type == Tiered && len(cfg.Tiers) > 0 {
+		local = cfg.Tiers[0].Database
+	}
+
+	// Keys written by other instances may now exist, so they must not be rejected by the membership filter
+	var onInvalidate func(string)
+	if l, ok := As[*lookaside.Lookaside](db); ok {
+		onInvalidate = l.Invalidated
+	}
+
+	inv, err := invalidation.Dial(invalidation.Config{
+		Database:     db,
+		Cache:        local,
+		Bus:          cfg.Invalidation,
+		OnInvalidate: onInvalidate,
+	})
+	if err != nil {
+		db.Close()
+		return &NilCache{}, err
+	}
+
+	return inv, nil
+}
+
+// mapKeys will wrap Cache and every one of the Tiers within Config with the KeyMapper.
+func mapKeys(cfg Config) (Config, error) {
+	var err error
+	if cfg.Cache != nil {
+		cfg.Cache, err = keymap.Dial(keymap.Config{Database: cfg.Cache, Mapper: cfg.KeyMapper})
+		if err != nil {
+			return cfg, err
+		}
+	}
+
+	tiers := make([]tiered.Tier, len(cfg.Tiers))
+	for i, t := range cfg.Tiers {
+		if t.Database != nil {
+			t.Database, err = keymap.Dial(keymap.Config{Database: t.Database, Mapper: cfg.KeyMapper})
+			if err != nil {
+				return cfg, err
+			}
+		}
+		tiers[i] = t
+	}
+	cfg.Tiers = tiers
+
+	return cfg, nil
+}
+
+// dial will create the cache driver for the Type within Config.
+func dial(cfg Config) (hord.Database, error) {
 	switch cfg.Type {
-	case Lookaside:
-		return lookaside.Dial(lookaside.Config{
+	case ReadThrough:
+		return readthrough.Dial(readthrough.Config{
 			Database: cfg.Database,
 			Cache:    cfg.Cache,
+			Loader:   cfg.Loader,
+		})
+	case WriteThrough:
+		return writethrough.Dial(writethrough.Config{
+			Database:          cfg.Database,
+			Cache:             cfg.Cache,
+			FailurePolicy:     cfg.FailurePolicy,
+			Retries:           cfg.Retries,
+			RetryInterval:     cfg.RetryInterval,
+			DeleteBeforeWrite: cfg.DeleteBeforeWrite,
+		})
+	case WriteBehind:
+		return writebehind.Dial(writebehind.Config{
+			Database:      cfg.Database,
+			Cache:         cfg.Cache,
+			FlushInterval: cfg.FlushInterval,
+			BatchSize:     cfg.BatchSize,
+			MaxRetries:    cfg.FlushRetries,
+			OnError:       cfg.OnFlushError,
+		})
+	case RefreshAhead:
+		return refreshahead.Dial(refreshahead.Config{
+			Database:      cfg.Database,
+			Cache:         cfg.Cache,
+			TTL:           cfg.TTL,
+			RefreshWindow: cfg.RefreshWindow,
+			Workers:       cfg.Workers,
+		})
+	case Tiered:
+		tiers := cfg.Tiers
+		if len(tiers) == 0 {
+			tiers = []tiered.Tier{{Database: cfg.Cache}}
+		}
+		return tiered.Dial(tiered.Config{
+			Tiers: append(append([]tiered.Tier{}, tiers...), tiered.Tier{Database: cfg.Database}),
+		})
+	case Lookaside:
+		return lookaside.Dial(lookaside.Config{
+			Database:                cfg.Database,
+			Cache:                   cfg.Cache,
+			SoftTTL:                 cfg.SoftTTL,
+			StaleWhileRevalidate:    cfg.StaleWhileRevalidate,
+			ServeStaleOnError:       cfg.ServeStaleOnError,
+			Coalesce:                cfg.Coalesce,
+			NegativeTTL:             cfg.NegativeTTL,
+			Filter:                  cfg.Filter,
+			FilterFalsePositiveRate: cfg.FilterFalsePositiveRate,
+			FilterRebuildInterval:   cfg.FilterRebuildInterval,
+			Admission:               cfg.Admission,
+			Policies:                cfg.Policies,
+			Degrade:                 cfg.Degrade,
+			RecoveryInterval:        cfg.RecoveryInterval,
 		})
 	case None:
 		return cfg.Database, nil
@@ -133,6 +436,49 @@ func Dial(cfg Config) (hord.Database, error) {
 	}
 }
 
+// GetWithOptions will get the data for the key from a driver returned by Dial(), applying the options to this call
+// only. Options are supported by the Lookaside type, including when it is wrapped for WarmUp or Invalidation. Other
+// types return ErrOptionsUnsupported when any option is set, and otherwise behave as Get().
+func GetWithOptions(db hord.Database, key string, opts GetOptions) ([]byte, error) {
+	if db == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if l, ok := As[*lookaside.Lookaside](db); ok {
+		return l.GetWithOptions(key, opts)
+	}
+
+	if opts != (GetOptions{}) {
+		return nil, ErrOptionsUnsupported
+	}
+
+	return db.Get(key)
+}
+
+// unwrapper is implemented by drivers wrapping another driver.
+type unwrapper interface {
+	Unwrap() hord.Database
+}
+
+// As returns the first driver of type T found by unwrapping db, starting with db itself. Drivers returned by Dial()
+// wrap the cache driver when WarmUp or Invalidation is configured, hiding its methods from type assertions.
+func As[T any](db hord.Database) (T, bool) {
+	for db != nil {
+		if t, ok := db.(T); ok {
+			return t, true
+		}
+
+		u, ok := db.(unwrapper)
+		if !ok {
+			break
+		}
+		db = u.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
+
 func (nc *NilCache) Setup() error {
 	return hord.ErrNoDial
 }
@@ -3,8 +3,19 @@ package cache
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/cache/admission"
+	"github.com/tarmac-project/hord/cache/invalidation"
+	"github.com/tarmac-project/hord/cache/keymap"
+	"github.com/tarmac-project/hord/cache/lookaside"
+	"github.com/tarmac-project/hord/cache/refreshahead"
+	"github.com/tarmac-project/hord/cache/tiered"
+	"github.com/tarmac-project/hord/cache/warmup"
+	"github.com/tarmac-project/hord/cache/writebehind"
+	"github.com/tarmac-project/hord/cache/writethrough"
+	"github.com/tarmac-project/hord/drivers/hashmap"
 	"github.com/tarmac-project/hord/drivers/mock"
 )
 
@@ -45,6 +56,131 @@ func TestDial(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		"Type: ReadThrough": {
+			config: Config{
+				Type:     ReadThrough,
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+			},
+			expectedError: nil,
+		},
+		"Type: ReadThrough with Loader": {
+			config: Config{
+				Type:   ReadThrough,
+				Cache:  &mock.Database{},
+				Loader: func(_ string) ([]byte, error) { return nil, hord.ErrNil },
+			},
+			expectedError: nil,
+		},
+		"Type: WriteThrough": {
+			config: Config{
+				Type:     WriteThrough,
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+			},
+			expectedError: nil,
+		},
+		"Type: WriteBehind": {
+			config: Config{
+				Type:     WriteBehind,
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+			},
+			expectedError: nil,
+		},
+		"Type: RefreshAhead": {
+			config: Config{
+				Type:     RefreshAhead,
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+			},
+			expectedError: nil,
+		},
+		"Type: RefreshAhead with Invalid Window": {
+			config: Config{
+				Type:          RefreshAhead,
+				Database:      &mock.Database{},
+				Cache:         &mock.Database{},
+				TTL:           time.Second,
+				RefreshWindow: 2 * time.Second,
+			},
+			expectedError: refreshahead.ErrInvalidRefreshWindow,
+		},
+		"Type: Tiered": {
+			config: Config{
+				Type:     Tiered,
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+			},
+			expectedError: nil,
+		},
+		"Type: Tiered with Tiers": {
+			config: Config{
+				Type:     Tiered,
+				Database: &mock.Database{},
+				Tiers:    []tiered.Tier{{Database: &mock.Database{}}, {Database: &mock.Database{}}},
+			},
+			expectedError: nil,
+		},
+		"Type: Lookaside with Admission": {
+			config: Config{
+				Type:      Lookaside,
+				Database:  &mock.Database{},
+				Cache:     &mock.Database{},
+				Admission: admission.MaxSize(1024),
+			},
+			expectedError: nil,
+		},
+		"Type: Lookaside with Policies": {
+			config: Config{
+				Type:     Lookaside,
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+				Policies: []Policy{
+					{Pattern: "user:*", TTL: time.Hour},
+					{Pattern: "lock:*", Bypass: true},
+					{Pattern: "config:*", TTL: Forever, WritePolicy: PolicyInvalidate},
+				},
+			},
+			expectedError: nil,
+		},
+		"Type: Lookaside with Invalid Policy": {
+			config: Config{
+				Type:     Lookaside,
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+				Policies: []Policy{{Pattern: "["}},
+			},
+			expectedError: lookaside.ErrInvalidPolicy,
+		},
+		"Type: WriteThrough with Policies": {
+			config: Config{
+				Type:     WriteThrough,
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+				Policies: []Policy{{Pattern: "user:*", TTL: time.Hour}},
+			},
+			expectedError: ErrPoliciesUnsupported,
+		},
+		"Type: Lookaside with Degrade": {
+			config: Config{
+				Type:             Lookaside,
+				Database:         &mock.Database{},
+				Cache:            &mock.Database{},
+				Degrade:          true,
+				RecoveryInterval: time.Second,
+			},
+			expectedError: nil,
+		},
+		"Type: Lookaside with Invalidation": {
+			config: Config{
+				Type:         Lookaside,
+				Database:     &mock.Database{},
+				Cache:        &mock.Database{},
+				Invalidation: invalidation.NewHub().Bus(),
+			},
+			expectedError: nil,
+		},
 		"Type: None": {
 			config: Config{
 				Type:     None,
@@ -87,3 +223,421 @@ func TestNilCache(t *testing.T) {
 	}
 	nc.Close()
 }
+
+func TestWarmUp(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+	_ = database.Set("user:1", []byte("data"))
+	_ = database.Set("config:a", []byte("data"))
+
+	var progress warmup.Progress
+	db, err := Dial(Config{
+		Type:     Lookaside,
+		Database: database,
+		Cache:    cache,
+		WarmUp: &warmup.Config{
+			Keys:       warmup.Prefix("user:"),
+			OnProgress: func(p warmup.Progress) { progress = p },
+		},
+	})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	defer db.Close()
+
+	if err := db.Setup(); err != nil {
+		t.Fatalf("Setup() returned error: %s", err)
+	}
+
+	if _, err := cache.Get("user:1"); err != nil {
+		t.Errorf("Expected user:1 to be warmed - %s", err)
+	}
+	if _, err := cache.Get("config:a"); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("Expected config:a to not be warmed - %v", err)
+	}
+
+	if progress.Total != 1 || progress.Warmed != 1 {
+		t.Errorf("Unexpected progress - %+v", progress)
+	}
+}
+
+func TestKeyMapper(t *testing.T) {
+	unitTests := map[string]Type{
+		"Lookaside": Lookaside,
+		"Tiered":    Tiered,
+	}
+
+	for name, typ := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			cache, err := hashmap.Dial(hashmap.Config{})
+			if err != nil {
+				t.Fatalf("Failed to connect to cache - %s", err)
+			}
+
+			database, err := hashmap.Dial(hashmap.Config{})
+			if err != nil {
+				t.Fatalf("Failed to connect to database - %s", err)
+			}
+
+			db, err := Dial(Config{
+				Type:      typ,
+				Database:  database,
+				Cache:     cache,
+				KeyMapper: keymap.SHA256("app"),
+			})
+			if err != nil {
+				t.Fatalf("Dial() returned error: %s", err)
+			}
+			defer db.Close()
+
+			if err := db.Set("user:1", []byte("data")); err != nil {
+				t.Fatalf("Set() returned error: %s", err)
+			}
+
+			// Only the cache stores mapped keys
+			if _, err := database.Get("user:1"); err != nil {
+				t.Errorf("Expected the database to store the original key - %s", err)
+			}
+			if _, err := cache.Get(keymap.SHA256("app").Map("user:1")); err != nil {
+				t.Errorf("Expected the cache to store the mapped key - %s", err)
+			}
+
+			data, err := db.Get("user:1")
+			if err != nil || string(data) != "data" {
+				t.Errorf("Get() returned %s - %v, expected data", data, err)
+			}
+
+			if l, ok := db.(*lookaside.Lookaside); ok {
+				keys, err := l.CacheKeys()
+				if err != nil || len(keys) != 1 || keys[0] != "user:1" {
+					t.Errorf("CacheKeys() returned %v - %v, expected [user:1]", keys, err)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteThroughOptions(t *testing.T) {
+	cache, err := mock.Dial(mock.Config{
+		SetFunc:    func(_ string, _ []byte) error { return errors.New("cache error") },
+		DeleteFunc: func(_ string) error { return nil },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock cache - %s", err)
+	}
+
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	db, err := Dial(Config{
+		Type:          WriteThrough,
+		Database:      database,
+		Cache:         cache,
+		FailurePolicy: writethrough.Retry,
+		Retries:       2,
+		RetryInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	defer db.Close()
+
+	if err := db.Set("key", []byte("data")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+
+	wt, ok := As[*writethrough.WriteThrough](db)
+	if !ok {
+		t.Fatalf("As() did not find the write-through driver")
+	}
+	if s := wt.Stats(); s.Retries != 2 || s.Invalidations != 1 {
+		t.Errorf("Unexpected stats - %+v", s)
+	}
+}
+
+func TestWriteBehindOptions(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	database, err := mock.Dial(mock.Config{
+		SetFunc: func(_ string, _ []byte) error { return errors.New("database error") },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock database - %s", err)
+	}
+
+	failed := make(chan string, 1)
+	db, err := Dial(Config{
+		Type:          WriteBehind,
+		Database:      database,
+		Cache:         cache,
+		FlushInterval: time.Hour,
+		BatchSize:     1,
+		FlushRetries:  1,
+		OnFlushError: func(key string, _ error) {
+			select {
+			case failed <- key:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	defer db.Close()
+
+	wb, ok := As[*writebehind.WriteBehind](db)
+	if !ok {
+		t.Fatalf("As() did not find the write-behind driver")
+	}
+
+	// A single queued key reaches the BatchSize and is flushed before the FlushInterval
+	if err := db.Set("key", []byte("data")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for wb.Stats().FlushErrors == 0 && time.Now().Before(deadline) {
+		<-time.After(5 * time.Millisecond)
+	}
+
+	// The next flush retries the failed key for the last time before it is dropped
+	if err := db.Set("other", []byte("data")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+
+	select {
+	case key := <-failed:
+		if key != "key" {
+			t.Errorf("OnFlushError called with %s, expected key", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Timed out waiting for OnFlushError")
+	}
+}
+
+func TestFilterInvalidation(t *testing.T) {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	hub := invalidation.NewHub()
+	instance := func() hord.Database {
+		cache, err := hashmap.Dial(hashmap.Config{})
+		if err != nil {
+			t.Fatalf("Failed to connect to cache - %s", err)
+		}
+
+		db, err := Dial(Config{
+			Type:                  Lookaside,
+			Database:              database,
+			Cache:                 cache,
+			Filter:                true,
+			FilterRebuildInterval: time.Hour,
+			Invalidation:          hub.Bus(),
+		})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+
+		if err := db.Setup(); err != nil {
+			t.Fatalf("Setup() returned error: %s", err)
+		}
+		return db
+	}
+
+	a := instance()
+	defer a.Close()
+	b := instance()
+	defer b.Close()
+
+	// Keys written by another instance are found before the filter is rebuilt
+	if err := a.Set("key", []byte("data")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+
+	data, err := b.Get("key")
+	if err != nil || string(data) != "data" {
+		t.Errorf("Get() returned %s - %v, expected data", data, err)
+	}
+}
+
+func TestAs(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	db, err := Dial(Config{
+		Type:         Lookaside,
+		Database:     database,
+		Cache:        cache,
+		WarmUp:       &warmup.Config{Keys: warmup.List()},
+		Invalidation: invalidation.NewHub().Bus(),
+	})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	defer db.Close()
+
+	if _, ok := db.(*lookaside.Lookaside); ok {
+		t.Fatalf("Expected the lookaside driver to be wrapped")
+	}
+
+	// Methods of every driver beneath the wrapping are reachable
+	l, ok := As[*lookaside.Lookaside](db)
+	if !ok {
+		t.Fatalf("As() did not find the lookaside driver")
+	}
+	_ = db.Set("key", []byte("data"))
+	_, _ = db.Get("key")
+	if s := l.Stats(); s.Hits != 1 {
+		t.Errorf("Unexpected stats - %+v", s)
+	}
+
+	if _, ok := As[*warmup.WarmUp](db); !ok {
+		t.Errorf("As() did not find the warm-up driver")
+	}
+	if _, ok := As[*invalidation.Invalidator](db); !ok {
+		t.Errorf("As() did not find the invalidation driver")
+	}
+
+	if _, ok := As[*tiered.Tiered](db); ok {
+		t.Errorf("As() found a driver that is not used")
+	}
+	if _, ok := As[*lookaside.Lookaside](nil); ok {
+		t.Errorf("As() found a driver within nil")
+	}
+}
+
+func TestGetWithOptions(t *testing.T) {
+	unitTests := map[string]struct {
+		typ           Type
+		opts          GetOptions
+		expected      string
+		expectedError error
+	}{
+		"Lookaside Bypass": {
+			typ:      Lookaside,
+			opts:     GetOptions{Bypass: true},
+			expected: "database",
+		},
+		"Lookaside Refresh": {
+			typ:      Lookaside,
+			opts:     GetOptions{Refresh: true},
+			expected: "database",
+		},
+		"Lookaside Defaults": {
+			typ:      Lookaside,
+			expected: "cached",
+		},
+		"WriteThrough Bypass": {
+			typ:           WriteThrough,
+			opts:          GetOptions{Bypass: true},
+			expectedError: ErrOptionsUnsupported,
+		},
+		"WriteThrough Defaults": {
+			typ:      WriteThrough,
+			expected: "cached",
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			cache, err := hashmap.Dial(hashmap.Config{})
+			if err != nil {
+				t.Fatalf("Failed to connect to cache - %s", err)
+			}
+
+			database, err := hashmap.Dial(hashmap.Config{})
+			if err != nil {
+				t.Fatalf("Failed to connect to database - %s", err)
+			}
+
+			// Options reach the lookaside driver beneath the invalidation wrapping
+			db, err := Dial(Config{
+				Type:         test.typ,
+				Database:     database,
+				Cache:        cache,
+				Invalidation: invalidation.NewHub().Bus(),
+			})
+			if err != nil {
+				t.Fatalf("Dial() returned error: %s", err)
+			}
+			defer db.Close()
+
+			_ = database.Set("key", []byte("database"))
+			_ = cache.Set("key", []byte("cached"))
+
+			data, err := GetWithOptions(db, "key", test.opts)
+			if !errors.Is(err, test.expectedError) {
+				t.Fatalf("GetWithOptions() returned error: %v, expected %v", err, test.expectedError)
+			}
+			if string(data) != test.expected {
+				t.Errorf("GetWithOptions() returned %s, expected %s", data, test.expected)
+			}
+		})
+	}
+
+	if _, err := GetWithOptions(nil, "key", GetOptions{}); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("GetWithOptions() returned error: %v, expected %s", err, hord.ErrNoDial)
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	db, err := Dial(Config{
+		Type:     Lookaside,
+		Database: database,
+		Cache:    cache,
+		Policies: []Policy{
+			{Pattern: "lock:*", Bypass: true},
+			{Pattern: "user:*", WritePolicy: PolicyInvalidate},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	defer db.Close()
+
+	for _, k := range []string{"lock:1", "user:1", "other"} {
+		if err := db.Set(k, []byte("data")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+		if _, err := database.Get(k); err != nil {
+			t.Errorf("Expected %s to be stored within the database - %s", k, err)
+		}
+	}
+
+	// Only keys matching no Policy are written to the cache
+	keys, err := cache.Keys()
+	if err != nil || len(keys) != 1 || keys[0] != "other" {
+		t.Errorf("Unexpected cached keys - %v, %v", keys, err)
+	}
+}
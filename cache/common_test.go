@@ -18,6 +18,21 @@ func TestInterfaceHappyPath(t *testing.T) {
 		"Lookaside Caching": {
 			cacheMethod: Lookaside,
 		},
+		"Read-Through Caching": {
+			cacheMethod: ReadThrough,
+		},
+		"Write-Through Caching": {
+			cacheMethod: WriteThrough,
+		},
+		"Write-Behind Caching": {
+			cacheMethod: WriteBehind,
+		},
+		"Refresh-Ahead Caching": {
+			cacheMethod: RefreshAhead,
+		},
+		"Tiered Caching": {
+			cacheMethod: Tiered,
+		},
 	}
 
 	// Loop through valid Configs and validate the driver adheres to the Hord interface
@@ -0,0 +1,19 @@
+/*
+Package doublehash provides the pair of key hashes used by the cache drivers for double hashing, where the i-th probe
+for a key is h1 + i*h2.
+*/
+package doublehash
+
+import (
+	"hash/maphash"
+)
+
+// seeds are the independent seeds of the two hashes. They are chosen once per process, so hashes must not be persisted
+// or shared between processes.
+var seeds = [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()}
+
+// Sum returns two independent hashes of the key, so the probe sequences of keys sharing h1 still differ. The second
+// hash is always odd, so when the number of slots is a power of two every slot can be selected.
+func Sum(key string) (uint64, uint64) {
+	return maphash.String(seeds[0], key), maphash.String(seeds[1], key) | 1
+}
@@ -0,0 +1,30 @@
+package doublehash
+
+import (
+	"fmt"
+	"math/bits"
+	"testing"
+)
+
+func TestSum(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+
+		h1, h2 := Sum(key)
+		if h2&1 == 0 {
+			t.Errorf("Sum(%s) returned an even second hash %d", key, h2)
+		}
+
+		a, b := Sum(key)
+		if a != h1 || b != h2 {
+			t.Errorf("Sum(%s) is not deterministic", key)
+		}
+
+		// The second hash should not be derived from the first
+		for r := 0; r < 64; r++ {
+			if bits.RotateLeft64(h1, r)|1 == h2 {
+				t.Fatalf("Sum(%s) returned a second hash that is a rotation of the first", key)
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package invalidation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// Bus broadcasts key invalidations between service instances.
+type Bus interface {
+	// Publish broadcasts an invalidation of the key to the subscribers of every other Bus.
+	Publish(key string) error
+
+	// Subscribe registers fn to be called with every key invalidated by another Bus. Invalidations published by this
+	// Bus are not delivered to it.
+	Subscribe(fn func(key string)) error
+
+	// Close stops delivering invalidations and releases any resources held by the Bus.
+	Close()
+}
+
+var (
+	// ErrNoConnection is returned when neither a connection nor a server address is provided.
+	ErrNoConnection = errors.New("no connection or server address provided")
+
+	// ErrClosed is returned when subscribing to a closed bus.
+	ErrClosed = errors.New("bus is closed")
+)
+
+// message is an invalidation sent over a Bus.
+type message struct {
+	// Origin identifies the Bus that published the invalidation.
+	Origin string `json:"origin"`
+
+	// Key is the invalidated key.
+	Key string `json:"key"`
+}
+
+// newOrigin returns a random identifier for a Bus.
+func newOrigin() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// encode returns the wire format of an invalidation of key from origin.
+func encode(origin, key string) ([]byte, error) {
+	return json.Marshal(message{Origin: origin, Key: key})
+}
+
+// decode returns the key from the wire format of an invalidation, returning false if the invalidation is malformed or
+// was published by origin.
+func decode(origin string, data []byte) (string, bool) {
+	var m message
+	if err := json.Unmarshal(data, &m); err != nil || m.Key == "" || m.Origin == origin {
+		return "", false
+	}
+	return m.Key, true
+}
+
+// Hub connects in-memory buses within a single process. It is intended for tests and for composing multiple cache
+// instances within one process.
+type Hub struct {
+	sync.RWMutex
+
+	buses map[*MemoryBus]struct{}
+}
+
+// MemoryBus is an in-memory Bus connected to the other buses of its Hub. Invalidations are delivered synchronously
+// before Publish returns.
+type MemoryBus struct {
+	sync.RWMutex
+
+	hub *Hub
+	fns []func(key string)
+}
+
+// NewHub returns a new Hub without any connected buses.
+func NewHub() *Hub {
+	return &Hub{buses: make(map[*MemoryBus]struct{})}
+}
+
+// Bus returns a new MemoryBus connected to the Hub.
+func (h *Hub) Bus() *MemoryBus {
+	b := &MemoryBus{hub: h}
+
+	h.Lock()
+	defer h.Unlock()
+	h.buses[b] = struct{}{}
+
+	return b
+}
+
+// Publish delivers an invalidation of the key to the subscribers of every other bus connected to the Hub.
+func (b *MemoryBus) Publish(key string) error {
+	b.hub.RLock()
+	buses := make([]*MemoryBus, 0, len(b.hub.buses))
+	for other := range b.hub.buses {
+		if other != b {
+			buses = append(buses, other)
+		}
+	}
+	b.hub.RUnlock()
+
+	for _, other := range buses {
+		other.deliver(key)
+	}
+
+	return nil
+}
+
+// Subscribe registers fn to be called with every key invalidated by another bus connected to the Hub.
+func (b *MemoryBus) Subscribe(fn func(key string)) error {
+	b.Lock()
+	defer b.Unlock()
+	b.fns = append(b.fns, fn)
+
+	return nil
+}
+
+// Close disconnects the bus from the Hub.
+func (b *MemoryBus) Close() {
+	b.hub.Lock()
+	defer b.hub.Unlock()
+	delete(b.hub.buses, b)
+}
+
+// deliver will call every subscriber of the bus with the key.
+func (b *MemoryBus) deliver(key string) {
+	b.RLock()
+	fns := b.fns
+	b.RUnlock()
+
+	for _, fn := range fns {
+		fn(key)
+	}
+}
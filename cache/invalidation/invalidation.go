@@ -0,0 +1,282 @@
+/*
+Package invalidation provides a Hord database driver that keeps in-process caches coherent across service instances. To
+use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/cache/invalidation"
+	)
+
+The Invalidator wraps a cache implementation, such as a look-aside cache with an in-process Cache. Every successful Set
+and Delete publishes the key on a Bus, and every other instance subscribed to the Bus removes the key from its Cache, so
+the next Get fetches the new value from the database.
+
+Three Bus implementations are provided:
+
+  - NATSBus, created with DialNATS(), uses NATS publish and subscribe.
+  - RedisBus, created with DialRedis(), uses Redis publish and subscribe.
+  - MemoryBus, created from a Hub, connects buses within a single process and is intended for tests.
+
+Invalidations are best-effort. An error wrapping ErrPublishFailed is returned when the write succeeded but the
+invalidation could not be published, and invalidations missed while disconnected are not redelivered, so Cache entries
+should still expire.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the cache.
+
+	// Handle cache connection
+	var local hord.Database
+	...
+
+	// Handle look-aside cache connection using the local cache
+	var cache hord.Database
+	...
+
+	bus, err := invalidation.DialNATS(invalidation.NATSConfig{URL: "nats://localhost:4222"})
+	if err != nil {
+	    // Handle connection error
+	}
+
+	var db hord.Database
+	db, err := invalidation.Dial(invalidation.Config{
+		Database: cache,
+		Cache:    local,
+		Bus:      bus,
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the cache, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Set a value
+	err = db.Set("key", []byte("value"))
+	if err != nil {
+	    // Handle error
+	}
+
+	// Retrieve a value
+	value, err := db.Get("key")
+	if err != nil {
+	    // Handle error
+	}
+*/
+package invalidation
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/tarmac-project/hord"
+)
+
+// Config provides the configuration options for the Invalidator driver.
+type Config struct {
+	// Database is the database or cache implementation used for all operations.
+	Database hord.Database
+
+	// Cache is the cache keys are removed from when invalidated by another instance. It is typically the in-process
+	// Cache used by Database.
+	Cache hord.Database
+
+	// Bus broadcasts invalidations between instances. The Bus is closed with the Invalidator.
+	Bus Bus
+
+	// OnInvalidate is called with every key invalidated by another instance, once removed from Cache. For example,
+	// lookaside.Lookaside.Invalidated adds the key to its membership filter.
+	OnInvalidate func(key string)
+}
+
+// Invalidator broadcasts key invalidations for every write and removes keys invalidated by other instances from its
+// cache. It also satisfies the Hord database interface.
+type Invalidator struct {
+	data         hord.Database
+	cache        hord.Database
+	bus          Bus
+	onInvalidate func(key string)
+
+	published     atomic.Uint64
+	publishErrors atomic.Uint64
+	received      atomic.Uint64
+	evictErrors   atomic.Uint64
+}
+
+// Stats provides counters describing invalidation activity.
+type Stats struct {
+	// Published is the number of invalidations published.
+	Published uint64
+
+	// PublishErrors is the number of invalidations that failed to publish.
+	PublishErrors uint64
+
+	// Received is the number of invalidations received from other instances.
+	Received uint64
+
+	// EvictErrors is the number of received invalidations that failed to remove the key from the cache.
+	EvictErrors uint64
+}
+
+var (
+	// ErrNoBus is returned by Dial when no Bus is provided.
+	ErrNoBus = errors.New("no invalidation bus provided")
+
+	// ErrPublishFailed is returned when a write succeeded but the invalidation could not be published.
+	ErrPublishFailed = errors.New("unable to publish invalidation")
+)
+
+// Dial will create a new Invalidator driver using the provided Config and subscribe to invalidations from other
+// instances. It will return an error if the Database, Cache or Bus values in Config are nil.
+func Dial(cfg Config) (*Invalidator, error) {
+	if cfg.Database == nil || cfg.Cache == nil {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	if cfg.Bus == nil {
+		return nil, ErrNoBus
+	}
+
+	db := &Invalidator{
+		data:         cfg.Database,
+		cache:        cfg.Cache,
+		bus:          cfg.Bus,
+		onInvalidate: cfg.OnInvalidate,
+	}
+
+	if err := db.bus.Subscribe(db.evict); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Setup will run the Setup function for the database.
+func (db *Invalidator) Setup() error {
+	if db == nil || db.data == nil || db.bus == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.Setup()
+}
+
+// HealthCheck will run the HealthCheck function for the database.
+func (db *Invalidator) HealthCheck() error {
+	if db == nil || db.data == nil || db.bus == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.data.HealthCheck(); err != nil {
+		return errors.Join(hord.ErrHealthCheckFailure, err)
+	}
+
+	return nil
+}
+
+// Get will get the data from the database.
+func (db *Invalidator) Get(key string) ([]byte, error) {
+	if db == nil || db.data == nil || db.bus == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.data.Get(key)
+}
+
+// Set will set the data in the database and publish an invalidation of the key.
+func (db *Invalidator) Set(key string, data []byte) error {
+	if db == nil || db.data == nil || db.bus == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.data.Set(key, data); err != nil {
+		return err
+	}
+
+	return db.publish(key)
+}
+
+// Delete will delete the data from the database and publish an invalidation of the key.
+func (db *Invalidator) Delete(key string) error {
+	if db == nil || db.data == nil || db.bus == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.data.Delete(key); err != nil {
+		return err
+	}
+
+	return db.publish(key)
+}
+
+// Keys will return the keys from the database.
+func (db *Invalidator) Keys() ([]string, error) {
+	if db == nil || db.data == nil || db.bus == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.data.Keys()
+}
+
+// Stats returns a snapshot of the invalidation counters.
+func (db *Invalidator) Stats() Stats {
+	return Stats{
+		Published:     db.published.Load(),
+		PublishErrors: db.publishErrors.Load(),
+		Received:      db.received.Load(),
+		EvictErrors:   db.evictErrors.Load(),
+	}
+}
+
+// GetDatabase will return the wrapped database.
+func (db *Invalidator) GetDatabase() hord.Database {
+	return db.data
+}
+
+// Unwrap will return the wrapped database, so its own methods can be reached with a type assertion.
+func (db *Invalidator) Unwrap() hord.Database {
+	return db.data
+}
+
+// Close will close the bus and the database.
+func (db *Invalidator) Close() {
+	if db != nil && db.data != nil && db.bus != nil {
+		db.bus.Close()
+		db.data.Close()
+	}
+}
+
+// publish will broadcast an invalidation of the key.
+func (db *Invalidator) publish(key string) error {
+	if err := db.bus.Publish(key); err != nil {
+		db.publishErrors.Add(1)
+		return fmt.Errorf("%w: %w", ErrPublishFailed, err)
+	}
+
+	db.published.Add(1)
+	return nil
+}
+
+// evict will remove a key invalidated by another instance from the cache.
+func (db *Invalidator) evict(key string) {
+	db.received.Add(1)
+
+	err := db.cache.Delete(key)
+	if err != nil && !errors.Is(err, hord.ErrNil) {
+		db.evictErrors.Add(1)
+	}
+
+	if db.onInvalidate != nil {
+		db.onInvalidate(key)
+	}
+}
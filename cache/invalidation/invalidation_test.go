@@ -0,0 +1,233 @@
+package invalidation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// Test Errors used for testing purposes
+var (
+	ErrDatabaseTest = errors.New("database error")
+	ErrBusTest      = errors.New("bus error")
+)
+
+// failingBus is a Bus that fails to publish or subscribe.
+type failingBus struct {
+	publishErr   error
+	subscribeErr error
+}
+
+func (b *failingBus) Publish(_ string) error             { return b.publishErr }
+func (b *failingBus) Subscribe(_ func(key string)) error { return b.subscribeErr }
+func (b *failingBus) Close()                             {}
+
+// instance is a helper function creating an Invalidator connected to the hub, wrapping its own local cache.
+func instance(t *testing.T, hub *Hub) (*Invalidator, hord.Database) {
+	local, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	db, err := Dial(Config{Database: local, Cache: local, Bus: hub.Bus()})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+
+	return db, local
+}
+
+func TestDial(t *testing.T) {
+	unitTests := map[string]struct {
+		config        Config
+		expectedError error
+	}{
+		"No Config": {
+			config:        Config{},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"No Cache": {
+			config:        Config{Database: &mock.Database{}, Bus: NewHub().Bus()},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"No Bus": {
+			config:        Config{Database: &mock.Database{}, Cache: &mock.Database{}},
+			expectedError: ErrNoBus,
+		},
+		"Subscribe Failure": {
+			config:        Config{Database: &mock.Database{}, Cache: &mock.Database{}, Bus: &failingBus{subscribeErr: ErrBusTest}},
+			expectedError: ErrBusTest,
+		},
+		"Happy Path": {
+			config: Config{Database: &mock.Database{}, Cache: &mock.Database{}, Bus: NewHub().Bus()},
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Dial(test.config)
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Dial(%v) returned error: %s, expected %s", test.config, err, test.expectedError)
+			}
+		})
+	}
+}
+
+func TestInvalidation(t *testing.T) {
+	hub := NewHub()
+	a, aLocal := instance(t, hub)
+	defer a.Close()
+	b, bLocal := instance(t, hub)
+	defer b.Close()
+
+	_ = bLocal.Set("key", []byte("old"))
+
+	t.Run("Set Invalidates Other Instances", func(t *testing.T) {
+		if err := a.Set("key", []byte("new")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		if _, err := bLocal.Get("key"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected key to be removed from other instance - %v", err)
+		}
+
+		// The publishing instance keeps its own value
+		if v, err := aLocal.Get("key"); err != nil || string(v) != "new" {
+			t.Errorf("Expected publishing instance to keep its value - %s %v", v, err)
+		}
+	})
+
+	t.Run("Delete Invalidates Other Instances", func(t *testing.T) {
+		_ = aLocal.Set("other", []byte("data"))
+
+		if err := b.Delete("other"); err != nil {
+			t.Fatalf("Delete() returned error: %s", err)
+		}
+
+		if _, err := aLocal.Get("other"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected key to be removed from other instance - %v", err)
+		}
+	})
+
+	t.Run("Closed Bus", func(t *testing.T) {
+		c, cLocal := instance(t, hub)
+		c.Close()
+		defer cLocal.Close()
+
+		if err := a.Set("closed", []byte("data")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		if n := c.Stats().Received; n != 0 {
+			t.Errorf("Unexpected invalidations received after close - %d", n)
+		}
+	})
+
+	if s := a.Stats(); s.Published != 2 || s.Received != 1 {
+		t.Errorf("Unexpected stats - %+v", s)
+	}
+}
+
+func TestInvalidationFailures(t *testing.T) {
+	t.Run("Write Failure Does Not Publish", func(t *testing.T) {
+		database, err := mock.Dial(mock.Config{
+			SetFunc:    func(_ string, _ []byte) error { return ErrDatabaseTest },
+			DeleteFunc: func(_ string) error { return ErrDatabaseTest },
+		})
+		if err != nil {
+			t.Fatalf("Failed to create mock database - %s", err)
+		}
+
+		db, err := Dial(Config{Database: database, Cache: database, Bus: NewHub().Bus()})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+
+		if err := db.Set("key", []byte("data")); !errors.Is(err, ErrDatabaseTest) {
+			t.Errorf("Set() returned error: %v, expected %s", err, ErrDatabaseTest)
+		}
+		if err := db.Delete("key"); !errors.Is(err, ErrDatabaseTest) {
+			t.Errorf("Delete() returned error: %v, expected %s", err, ErrDatabaseTest)
+		}
+		if n := db.Stats().Published; n != 0 {
+			t.Errorf("Unexpected invalidations published - %d", n)
+		}
+	})
+
+	t.Run("Publish Failure", func(t *testing.T) {
+		db, err := Dial(Config{Database: &mock.Database{}, Cache: &mock.Database{}, Bus: &failingBus{publishErr: ErrBusTest}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+
+		if err := db.Set("key", []byte("data")); !errors.Is(err, ErrPublishFailed) || !errors.Is(err, ErrBusTest) {
+			t.Errorf("Set() returned error: %v, expected %s", err, ErrPublishFailed)
+		}
+		if n := db.Stats().PublishErrors; n != 1 {
+			t.Errorf("Unexpected publish errors - %d", n)
+		}
+	})
+
+	t.Run("Not Dialed", func(t *testing.T) {
+		var db *Invalidator
+		if _, err := db.Get("key"); !errors.Is(err, hord.ErrNoDial) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNoDial)
+		}
+		if err := db.Set("key", []byte("data")); !errors.Is(err, hord.ErrNoDial) {
+			t.Errorf("Set() returned error: %v, expected %s", err, hord.ErrNoDial)
+		}
+	})
+}
+
+func TestMessage(t *testing.T) {
+	data, err := encode("origin", "key")
+	if err != nil {
+		t.Fatalf("encode() returned error: %s", err)
+	}
+
+	if key, ok := decode("other", data); !ok || key != "key" {
+		t.Errorf("decode() returned %s %t, expected key", key, ok)
+	}
+
+	if _, ok := decode("origin", data); ok {
+		t.Errorf("Expected invalidations from the same origin to be ignored")
+	}
+
+	if _, ok := decode("other", []byte("invalid")); ok {
+		t.Errorf("Expected malformed invalidations to be ignored")
+	}
+}
+
+func TestOnInvalidate(t *testing.T) {
+	hub := NewHub()
+	a, _ := instance(t, hub)
+	defer a.Close()
+
+	local, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	var invalidated []string
+	b, err := Dial(Config{
+		Database:     local,
+		Cache:        local,
+		Bus:          hub.Bus(),
+		OnInvalidate: func(key string) { invalidated = append(invalidated, key) },
+	})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	defer b.Close()
+
+	_ = a.Set("key", []byte("data"))
+	_ = a.Delete("other")
+	_ = b.Set("own", []byte("data"))
+
+	if len(invalidated) != 2 || invalidated[0] != "key" || invalidated[1] != "other" {
+		t.Errorf("Unexpected keys passed to OnInvalidate - %v", invalidated)
+	}
+}
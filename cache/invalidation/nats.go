@@ -0,0 +1,116 @@
+package invalidation
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// DefaultSubject is the default NATS subject and Redis channel invalidations are published to.
+	DefaultSubject = "hord.invalidate"
+)
+
+// NATSConfig provides the configuration options for the NATSBus.
+type NATSConfig struct {
+	// Conn is an established NATS connection. When nil, a connection to URL is established and closed with the bus.
+	Conn *nats.Conn
+
+	// URL is the NATS server URL used when Conn is nil.
+	URL string
+
+	// Options are the NATS connection options used when Conn is nil.
+	Options []nats.Option
+
+	// Subject is the subject invalidations are published to. Default is "hord.invalidate".
+	Subject string
+}
+
+// NATSBus is a Bus using NATS publish and subscribe.
+type NATSBus struct {
+	sync.Mutex
+
+	conn    *nats.Conn
+	owned   bool
+	subject string
+	origin  string
+	subs    []*nats.Subscription
+}
+
+// DialNATS will create a new NATSBus using the provided NATSConfig.
+func DialNATS(cfg NATSConfig) (*NATSBus, error) {
+	if cfg.Subject == "" {
+		cfg.Subject = DefaultSubject
+	}
+
+	b := &NATSBus{
+		conn:    cfg.Conn,
+		subject: cfg.Subject,
+		origin:  newOrigin(),
+	}
+
+	if b.conn == nil {
+		if cfg.URL == "" {
+			return nil, ErrNoConnection
+		}
+
+		conn, err := nats.Connect(cfg.URL, cfg.Options...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to NATS: %w", err)
+		}
+		b.conn = conn
+		b.owned = true
+	}
+
+	return b, nil
+}
+
+// Publish broadcasts an invalidation of the key to the subscribers of every other bus.
+func (b *NATSBus) Publish(key string) error {
+	data, err := encode(b.origin, key)
+	if err != nil {
+		return err
+	}
+
+	return b.conn.Publish(b.subject, data)
+}
+
+// Subscribe registers fn to be called with every key invalidated by another bus.
+func (b *NATSBus) Subscribe(fn func(key string)) error {
+	sub, err := b.conn.Subscribe(b.subject, func(msg *nats.Msg) {
+		if key, ok := decode(b.origin, msg.Data); ok {
+			fn(key)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("unable to subscribe to %s: %w", b.subject, err)
+	}
+
+	// Ensure the subscription is registered with the server before returning
+	if err := b.conn.Flush(); err != nil {
+		_ = sub.Unsubscribe()
+		return fmt.Errorf("unable to subscribe to %s: %w", b.subject, err)
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	b.subs = append(b.subs, sub)
+
+	return nil
+}
+
+// Close stops all subscriptions, closing the NATS connection if it was established by the bus.
+func (b *NATSBus) Close() {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, sub := range b.subs {
+		_ = sub.Unsubscribe()
+	}
+	b.subs = nil
+
+	if b.owned {
+		b.conn.Close()
+	}
+}
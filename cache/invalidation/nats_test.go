@@ -0,0 +1,71 @@
+package invalidation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitForKey is a helper function returning the next key received on the channel.
+func waitForKey(t *testing.T, keys <-chan string) string {
+	select {
+	case key := <-keys:
+		return key
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for invalidation")
+	}
+	return ""
+}
+
+func TestNATSBus(t *testing.T) {
+	if _, err := DialNATS(NATSConfig{}); !errors.Is(err, ErrNoConnection) {
+		t.Errorf("DialNATS() returned error: %v, expected %s", err, ErrNoConnection)
+	}
+
+	if _, err := DialNATS(NATSConfig{URL: "notnats"}); err == nil {
+		t.Errorf("Expected error when connecting to an invalid URL")
+	}
+
+	subject := "hord.test.invalidate"
+	a, err := DialNATS(NATSConfig{URL: "nats", Subject: subject})
+	if err != nil {
+		t.Fatalf("DialNATS() returned error: %s", err)
+	}
+	defer a.Close()
+
+	b, err := DialNATS(NATSConfig{URL: "nats", Subject: subject})
+	if err != nil {
+		t.Fatalf("DialNATS() returned error: %s", err)
+	}
+	defer b.Close()
+
+	aKeys := make(chan string, 10)
+	bKeys := make(chan string, 10)
+	if err := a.Subscribe(func(key string) { aKeys <- key }); err != nil {
+		t.Fatalf("Subscribe() returned error: %s", err)
+	}
+	if err := b.Subscribe(func(key string) { bKeys <- key }); err != nil {
+		t.Fatalf("Subscribe() returned error: %s", err)
+	}
+
+	if err := a.Publish("from-a"); err != nil {
+		t.Fatalf("Publish() returned error: %s", err)
+	}
+	if err := b.Publish("from-b"); err != nil {
+		t.Fatalf("Publish() returned error: %s", err)
+	}
+
+	if key := waitForKey(t, bKeys); key != "from-a" {
+		t.Errorf("Unexpected invalidation received - got %s, expected from-a", key)
+	}
+	if key := waitForKey(t, aKeys); key != "from-b" {
+		t.Errorf("Unexpected invalidation received - got %s, expected from-b", key)
+	}
+
+	// Buses never receive their own invalidations
+	select {
+	case key := <-aKeys:
+		t.Errorf("Unexpected invalidation received - %s", key)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,192 @@
+package invalidation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// DefaultReconnectInterval is the default time between attempts to re-establish a failed Redis subscription.
+	DefaultReconnectInterval = time.Second
+)
+
+// RedisConfig provides the configuration options for the RedisBus.
+type RedisConfig struct {
+	// Pool is an established Redis connection pool. When nil, a pool connecting to Server is created and closed with
+	// the bus.
+	Pool *redis.Pool
+
+	// Server is the Redis server address used when Pool is nil.
+	Server string
+
+	// Options are the Redis dial options used when Pool is nil.
+	Options []redis.DialOption
+
+	// Channel is the channel invalidations are published to. Default is "hord.invalidate".
+	Channel string
+
+	// ReconnectInterval is the time between attempts to re-establish a failed subscription. Default is 1 second.
+	ReconnectInterval time.Duration
+}
+
+// RedisBus is a Bus using Redis publish and subscribe.
+type RedisBus struct {
+	sync.Mutex
+
+	pool              *redis.Pool
+	owned             bool
+	channel           string
+	origin            string
+	reconnectInterval time.Duration
+
+	// conns holds the subscribed connections, closed to stop their receive loops.
+	conns  map[redis.Conn]struct{}
+	done   chan struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// DialRedis will create a new RedisBus using the provided RedisConfig.
+func DialRedis(cfg RedisConfig) (*RedisBus, error) {
+	if cfg.Channel == "" {
+		cfg.Channel = DefaultSubject
+	}
+
+	if cfg.ReconnectInterval <= 0 {
+		cfg.ReconnectInterval = DefaultReconnectInterval
+	}
+
+	b := &RedisBus{
+		pool:              cfg.Pool,
+		channel:           cfg.Channel,
+		origin:            newOrigin(),
+		reconnectInterval: cfg.ReconnectInterval,
+		conns:             make(map[redis.Conn]struct{}),
+		done:              make(chan struct{}),
+	}
+
+	if b.pool == nil {
+		if cfg.Server == "" {
+			return nil, ErrNoConnection
+		}
+
+		b.pool = &redis.Pool{
+			MaxIdle:     2,
+			IdleTimeout: 5 * time.Minute,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", cfg.Server, cfg.Options...)
+			},
+		}
+		b.owned = true
+	}
+
+	return b, nil
+}
+
+// Publish broadcasts an invalidation of the key to the subscribers of every other bus.
+func (b *RedisBus) Publish(key string) error {
+	data, err := encode(b.origin, key)
+	if err != nil {
+		return err
+	}
+
+	conn := b.pool.Get()
+	defer conn.Close() // nolint:errcheck
+
+	_, err = conn.Do("PUBLISH", b.channel, data)
+	return err
+}
+
+// Subscribe registers fn to be called with every key invalidated by another bus. The subscription is re-established
+// every ReconnectInterval after a failure until the bus is closed.
+func (b *RedisBus) Subscribe(fn func(key string)) error {
+	psc, err := b.subscribe()
+	if err != nil {
+		return err
+	}
+
+	b.wg.Add(1)
+	go b.receive(psc, fn)
+
+	return nil
+}
+
+// subscribe will establish a subscription to the channel.
+func (b *RedisBus) subscribe() (redis.PubSubConn, error) {
+	psc := redis.PubSubConn{Conn: b.pool.Get()}
+	if err := psc.Subscribe(b.channel); err != nil {
+		_ = psc.Close()
+		return psc, err
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	if b.closed {
+		_ = psc.Close()
+		return psc, ErrClosed
+	}
+	b.conns[psc.Conn] = struct{}{}
+
+	return psc, nil
+}
+
+// receive will deliver invalidations to fn until the bus is closed, re-establishing the subscription on failure.
+func (b *RedisBus) receive(psc redis.PubSubConn, fn func(key string)) {
+	defer b.wg.Done()
+
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			if key, ok := decode(b.origin, v.Data); ok {
+				fn(key)
+			}
+			continue
+		case error:
+		default:
+			continue
+		}
+
+		// The subscription failed, retry until re-established or closed
+		b.Lock()
+		delete(b.conns, psc.Conn)
+		b.Unlock()
+		_ = psc.Close()
+		for {
+			select {
+			case <-b.done:
+				return
+			case <-time.After(b.reconnectInterval):
+			}
+
+			var err error
+			psc, err = b.subscribe()
+			if err == nil {
+				break
+			}
+		}
+	}
+}
+
+// Close stops all subscriptions, closing the Redis connection pool if it was created by the bus.
+func (b *RedisBus) Close() {
+	b.Lock()
+	if b.closed {
+		b.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.done)
+	for conn := range b.conns {
+		_ = conn.Close()
+	}
+	clear(b.conns)
+	b.Unlock()
+
+	b.wg.Wait()
+
+	if b.owned {
+		_ = b.pool.Close()
+	}
+}
@@ -0,0 +1,48 @@
+package invalidation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRedisBus(t *testing.T) {
+	if _, err := DialRedis(RedisConfig{}); !errors.Is(err, ErrNoConnection) {
+		t.Errorf("DialRedis() returned error: %v, expected %s", err, ErrNoConnection)
+	}
+
+	channel := "hord.test.invalidate"
+	a, err := DialRedis(RedisConfig{Server: "redis:6379", Channel: channel, ReconnectInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("DialRedis() returned error: %s", err)
+	}
+	defer a.Close()
+
+	if err := a.Publish("ping"); err != nil {
+		t.Skipf("Redis is unavailable - %s", err)
+	}
+
+	b, err := DialRedis(RedisConfig{Server: "redis:6379", Channel: channel})
+	if err != nil {
+		t.Fatalf("DialRedis() returned error: %s", err)
+	}
+	defer b.Close()
+
+	bKeys := make(chan string, 10)
+	if err := b.Subscribe(func(key string) { bKeys <- key }); err != nil {
+		t.Fatalf("Subscribe() returned error: %s", err)
+	}
+
+	if err := a.Publish("from-a"); err != nil {
+		t.Fatalf("Publish() returned error: %s", err)
+	}
+
+	if key := waitForKey(t, bKeys); key != "from-a" {
+		t.Errorf("Unexpected invalidation received - got %s, expected from-a", key)
+	}
+
+	b.Close()
+	if err := b.Subscribe(func(_ string) {}); err == nil {
+		t.Errorf("Expected error when subscribing to a closed bus")
+	}
+}
@@ -0,0 +1,333 @@
+/*
+Package keymap provides a Hord database driver that maps keys before they reach a cache, for example hashing them with
+SHA-256 to keep them short and to avoid exposing them, or placing them within a namespace shared with other
+applications. Keys are only mapped within the wrapped cache, while the database keeps the original keys. To use this
+driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/cache/keymap"
+	)
+
+The following Mappers are provided:
+
+  - Prefix() places keys within a namespace, and can always recover the original keys.
+  - SHA256() replaces keys with their SHA-256 hash within a namespace. Hashes cannot be reversed, so the original keys
+    are recovered from an in-process index of recently used keys.
+
+Keys() returns the original keys of every cache key that can be recovered, while MappedKeys() also reports the cache
+keys that cannot. Keys outside the namespace of the Mapper are ignored.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client wrapping the cache.
+
+	// Handle cache connection
+	var cache hord.Database
+	...
+
+	var db hord.Database
+	db, err := keymap.Dial(keymap.Config{
+		Database: cache,
+		Mapper:   keymap.SHA256("app"),
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+*/
+package keymap
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/tarmac-project/hord"
+)
+
+const (
+	// DefaultIndexSize is the default number of keys remembered by the index of a KeyMap.
+	DefaultIndexSize = 10000
+
+	// Separator separates the namespace from the key within cache keys. It is accepted by
+	// hord.PortableValidationPolicy, so mapped keys are usable with every driver.
+	Separator = "."
+)
+
+// Mapper maps original keys to cache keys.
+type Mapper interface {
+	// Map returns the cache key for the key.
+	Map(key string) string
+
+	// Unmap returns the original key for the cache key. It returns false if the cache key was not produced by Map or
+	// the original key cannot be recovered from it.
+	Unmap(cacheKey string) (string, bool)
+
+	// Owns returns true if the cache key may have been produced by Map.
+	Owns(cacheKey string) bool
+}
+
+// prefix is a Mapper placing keys within a namespace.
+type prefix struct {
+	namespace string
+}
+
+// Prefix returns a Mapper placing keys within the namespace, as namespace.key. Keys valid under
+// hord.PortableValidationPolicy remain valid once mapped, as long as the namespace is.
+func Prefix(namespace string) Mapper {
+	return prefix{namespace: namespace + Separator}
+}
+
+// Map returns namespace.key.
+func (p prefix) Map(key string) string {
+	return p.namespace + key
+}
+
+// Unmap returns the key without the namespace.
+func (p prefix) Unmap(cacheKey string) (string, bool) {
+	if !p.Owns(cacheKey) {
+		return "", false
+	}
+	return strings.TrimPrefix(cacheKey, p.namespace), true
+}
+
+// Owns returns true if the cache key is within the namespace.
+func (p prefix) Owns(cacheKey string) bool {
+	return strings.HasPrefix(cacheKey, p.namespace)
+}
+
+// hash is a Mapper replacing keys with their SHA-256 hash within a namespace.
+type hash struct {
+	namespace string
+}
+
+// SHA256 returns a Mapper replacing keys with the hex encoded SHA-256 hash of the key, as namespace.hash. The namespace
+// is also included within the hash, so the same key hashes differently in every namespace. Mapped keys are valid under
+// hord.PortableValidationPolicy whatever the original key, as long as the namespace is.
+func SHA256(namespace string) Mapper {
+	return hash{namespace: namespace + Separator}
+}
+
+// Map returns namespace.sha256(namespace.key).
+func (h hash) Map(key string) string {
+	sum := sha256.Sum256([]byte(h.namespace + key))
+	return h.namespace + hex.EncodeToString(sum[:])
+}
+
+// Unmap always returns false, as hashes cannot be reversed.
+func (h hash) Unmap(_ string) (string, bool) {
+	return "", false
+}
+
+// Owns returns true if the cache key is a hash within the namespace.
+func (h hash) Owns(cacheKey string) bool {
+	return strings.HasPrefix(cacheKey, h.namespace) && len(cacheKey) == len(h.namespace)+sha256.Size*2
+}
+
+// Config provides the configuration options for the KeyMap driver.
+type Config struct {
+	// Database is the cache implementation storing values under the mapped keys.
+	Database hord.Database
+
+	// Mapper maps keys before they reach Database.
+	Mapper Mapper
+
+	// IndexSize is the number of recently used keys remembered to recover original keys the Mapper cannot. The least
+	// recently used keys are forgotten first. Default is 10000.
+	IndexSize int
+}
+
+// MappedKey describes a cache key and the original key it was mapped from.
+type MappedKey struct {
+	// CacheKey is the key stored within the cache.
+	CacheKey string
+
+	// Key is the original key, empty if it could not be recovered.
+	Key string
+}
+
+// KeyMap wraps a cache implementation, mapping keys before they reach it. It also satisfies the Hord database interface.
+type KeyMap struct {
+	data   hord.Database
+	mapper Mapper
+
+	// index remembers the original keys of recently used cache keys, forgetting the least recently used first.
+	lock  sync.Mutex
+	index map[string]*list.Element
+	order *list.List
+	size  int
+}
+
+// indexEntry is an entry of the index.
+type indexEntry struct {
+	cacheKey string
+	key      string
+}
+
+// Dial will create a new KeyMap driver using the provided Config. It will return an error if the Database or Mapper
+// values in Config are nil.
+func Dial(cfg Config) (*KeyMap, error) {
+	if cfg.Database == nil || cfg.Mapper == nil {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	if cfg.IndexSize <= 0 {
+		cfg.IndexSize = DefaultIndexSize
+	}
+
+	return &KeyMap{
+		data:   cfg.Database,
+		mapper: cfg.Mapper,
+		index:  make(map[string]*list.Element),
+		order:  list.New(),
+		size:   cfg.IndexSize,
+	}, nil
+}
+
+// key will map the key, remembering the original key if the Mapper cannot recover it.
+func (db *KeyMap) key(key string) string {
+	cacheKey := db.mapper.Map(key)
+	if _, ok := db.mapper.Unmap(cacheKey); ok {
+		return cacheKey
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if e, ok := db.index[cacheKey]; ok {
+		db.order.MoveToFront(e)
+		return cacheKey
+	}
+
+	if db.order.Len() >= db.size {
+		oldest := db.order.Back()
+		db.order.Remove(oldest)
+		delete(db.index, oldest.Value.(*indexEntry).cacheKey)
+	}
+	db.index[cacheKey] = db.order.PushFront(&indexEntry{cacheKey: cacheKey, key: key})
+
+	return cacheKey
+}
+
+// unmap returns the original key of the cache key, if it can be recovered.
+func (db *KeyMap) unmap(cacheKey string) (string, bool) {
+	if key, ok := db.mapper.Unmap(cacheKey); ok {
+		return key, true
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	e, ok := db.index[cacheKey]
+	if !ok {
+		return "", false
+	}
+	return e.Value.(*indexEntry).key, true
+}
+
+// Setup will run the Setup function for the cache implementation.
+func (db *KeyMap) Setup() error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.Setup()
+}
+
+// HealthCheck will run the HealthCheck function for the cache implementation.
+func (db *KeyMap) HealthCheck() error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.HealthCheck()
+}
+
+// Get will get the data of the mapped key from the cache implementation.
+func (db *KeyMap) Get(key string) ([]byte, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.data.Get(db.key(key))
+}
+
+// Set will set the data of the mapped key using the cache implementation.
+func (db *KeyMap) Set(key string, data []byte) error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.Set(db.key(key), data)
+}
+
+// Delete will delete the mapped key using the cache implementation.
+func (db *KeyMap) Delete(key string) error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.Delete(db.key(key))
+}
+
+// Keys will return the original keys of the cache keys that can be recovered. Use MappedKeys to also report the cache
+// keys that cannot.
+func (db *KeyMap) Keys() ([]string, error) {
+	mapped, err := db.MappedKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(mapped))
+	for _, m := range mapped {
+		if m.Key != "" {
+			keys = append(keys, m.Key)
+		}
+	}
+
+	return keys, nil
+}
+
+// MappedKeys will return every cache key within the namespace of the Mapper, along with the original key when it can
+// be recovered.
+func (db *KeyMap) MappedKeys() ([]MappedKey, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	cacheKeys, err := db.data.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	var mapped []MappedKey
+	for _, k := range cacheKeys {
+		if !db.mapper.Owns(k) {
+			continue
+		}
+
+		key, _ := db.unmap(k)
+		mapped = append(mapped, MappedKey{CacheKey: k, Key: key})
+	}
+
+	return mapped, nil
+}
+
+// Close will close the cache implementation.
+func (db *KeyMap) Close() {
+	if db != nil && db.data != nil {
+		db.data.Close()
+	}
+}
+
+// GetDatabase will return the wrapped cache implementation.
+func (db *KeyMap) GetDatabase() hord.Database {
+	return db.data
+}
+
+// Unwrap will return the wrapped cache implementation, so its own methods can be reached with a type assertion.
+func (db *KeyMap) Unwrap() hord.Database {
+	return db.data
+}
@@ -0,0 +1,230 @@
+package keymap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+)
+
+func TestMappers(t *testing.T) {
+	unitTests := map[string]struct {
+		mapper     Mapper
+		expected   string
+		reversible bool
+	}{
+		"Prefix": {
+			mapper:     Prefix("app"),
+			expected:   "app.user:1",
+			reversible: true,
+		},
+		"SHA256": {
+			mapper:   SHA256("app"),
+			expected: "app.b061bf690143b6cb9cc29cdb888d1829c5897b1fba8f60aa75dd935fd7bf1d3c",
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			cacheKey := test.mapper.Map("user:1")
+			if cacheKey != test.expected {
+				t.Errorf("Map() returned %s, expected %s", cacheKey, test.expected)
+			}
+
+			if !test.mapper.Owns(cacheKey) {
+				t.Errorf("Expected mapper to own %s", cacheKey)
+			}
+
+			if test.mapper.Owns("other:key") {
+				t.Errorf("Expected mapper to not own keys outside the namespace")
+			}
+
+			key, ok := test.mapper.Unmap(cacheKey)
+			if ok != test.reversible || (ok && key != "user:1") {
+				t.Errorf("Unmap() returned %s - %t, expected reversible %t", key, ok, test.reversible)
+			}
+		})
+	}
+
+	t.Run("Portable Keys", func(t *testing.T) {
+		for _, m := range []Mapper{Prefix("app"), SHA256("app")} {
+			cacheKey := m.Map("user/1")
+			if err := hord.PortableValidationPolicy.ValidKey(cacheKey); err != nil {
+				t.Errorf("Expected %s to be a portable key - %s", cacheKey, err)
+			}
+		}
+
+		// Hashing also makes keys with unsupported characters portable
+		cacheKey := SHA256("app").Map("user:1 with spaces")
+		if err := hord.PortableValidationPolicy.ValidKey(cacheKey); err != nil {
+			t.Errorf("Expected %s to be a portable key - %s", cacheKey, err)
+		}
+	})
+
+	t.Run("SHA256 Namespaces", func(t *testing.T) {
+		a, b := SHA256("a").Map("key"), SHA256("b").Map("key")
+		if strings.TrimPrefix(a, "a.") == strings.TrimPrefix(b, "b.") {
+			t.Errorf("Expected keys to hash differently in every namespace")
+		}
+
+		if a != SHA256("a").Map("key") {
+			t.Errorf("Expected keys to hash consistently")
+		}
+	})
+}
+
+func TestKeyMap(t *testing.T) {
+	if _, err := Dial(Config{}); !errors.Is(err, hord.ErrInvalidDatabase) {
+		t.Errorf("Dial() returned error: %v, expected %s", err, hord.ErrInvalidDatabase)
+	}
+
+	unitTests := map[string]struct {
+		mapper Mapper
+	}{
+		"Prefix": {mapper: Prefix("app")},
+		"SHA256": {mapper: SHA256("app")},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			cache, err := hashmap.Dial(hashmap.Config{})
+			if err != nil {
+				t.Fatalf("Failed to connect to cache - %s", err)
+			}
+
+			db, err := Dial(Config{Database: cache, Mapper: test.mapper})
+			if err != nil {
+				t.Fatalf("Dial() returned error: %s", err)
+			}
+			defer db.Close()
+
+			if err := db.Setup(); err != nil {
+				t.Errorf("Setup() returned error: %s", err)
+			}
+			if err := db.HealthCheck(); err != nil {
+				t.Errorf("HealthCheck() returned error: %s", err)
+			}
+
+			for _, k := range []string{"user:1", "user:2"} {
+				if err := db.Set(k, []byte("data")); err != nil {
+					t.Fatalf("Set() returned error: %s", err)
+				}
+			}
+
+			// Keys written outside the namespace are ignored
+			_ = cache.Set("other", []byte("data"))
+
+			if _, err := cache.Get("user:1"); !errors.Is(err, hord.ErrNil) {
+				t.Errorf("Expected keys to be mapped within the cache")
+			}
+
+			data, err := db.Get("user:1")
+			if err != nil || string(data) != "data" {
+				t.Errorf("Get() returned %s - %v, expected data", data, err)
+			}
+
+			keys, err := db.Keys()
+			sort.Strings(keys)
+			if err != nil || fmt.Sprint(keys) != "[user:1 user:2]" {
+				t.Errorf("Keys() returned %v - %v, expected [user:1 user:2]", keys, err)
+			}
+
+			if err := db.Delete("user:1"); err != nil {
+				t.Fatalf("Delete() returned error: %s", err)
+			}
+			if _, err := db.Get("user:1"); !errors.Is(err, hord.ErrNil) {
+				t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+			}
+		})
+	}
+}
+
+func TestKeyMapIndex(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	db, err := Dial(Config{Database: cache, Mapper: SHA256("app"), IndexSize: 2})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+
+	for _, k := range []string{"a", "b", "c"} {
+		_ = db.Set(k, []byte("data"))
+	}
+
+	// The oldest key is forgotten once the index is full
+	mapped, err := db.MappedKeys()
+	if err != nil {
+		t.Fatalf("MappedKeys() returned error: %s", err)
+	}
+
+	var unknown []string
+	var known []string
+	for _, m := range mapped {
+		if m.Key == "" {
+			unknown = append(unknown, m.CacheKey)
+			continue
+		}
+		known = append(known, m.Key)
+	}
+	sort.Strings(known)
+
+	if fmt.Sprint(known) != "[b c]" || len(unknown) != 1 || unknown[0] != SHA256("app").Map("a") {
+		t.Errorf("Unexpected mapped keys - known %v, unknown %v", known, unknown)
+	}
+
+	keys, _ := db.Keys()
+	if len(keys) != 2 {
+		t.Errorf("Expected unrecoverable keys to be excluded - %v", keys)
+	}
+
+	// Using a key again remembers it
+	_, _ = db.Get("a")
+	keys, _ = db.Keys()
+	sort.Strings(keys)
+	if fmt.Sprint(keys) != "[a c]" {
+		t.Errorf("Unexpected keys - %v", keys)
+	}
+
+	// The least recently used key is forgotten, rather than the oldest
+	_, _ = db.Get("c")
+	_ = db.Set("d", []byte("data"))
+	keys, _ = db.Keys()
+	sort.Strings(keys)
+	if fmt.Sprint(keys) != "[c d]" {
+		t.Errorf("Unexpected keys - %v", keys)
+	}
+}
+
+func TestKeyMapUnwrap(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	db, err := Dial(Config{Database: cache, Mapper: Prefix("app")})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+
+	if db.Unwrap() != cache || db.GetDatabase() != cache {
+		t.Errorf("Expected the wrapped cache to be returned")
+	}
+}
+
+func TestKeyMapNotDialed(t *testing.T) {
+	var db *KeyMap
+	if _, err := db.Get("key"); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNoDial)
+	}
+	if _, err := db.Keys(); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("Keys() returned error: %v, expected %s", err, hord.ErrNoDial)
+	}
+	db.Close()
+}
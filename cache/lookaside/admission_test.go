@@ -0,0 +1,143 @@
+package lookaside
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/cache/admission"
+)
+
+func TestAdmission(t *testing.T) {
+	unitTests := map[string]struct {
+		policy   admission.Policy
+		key      string
+		data     []byte
+		gets     int
+		expected bool
+		rejects  uint64
+	}{
+		"No Policy": {
+			key:      "key",
+			data:     []byte("data"),
+			gets:     1,
+			expected: true,
+		},
+		"Always": {
+			policy:   admission.Always(),
+			key:      "key",
+			data:     []byte("data"),
+			gets:     1,
+			expected: true,
+		},
+		"Max Size Exceeded": {
+			policy:  admission.MaxSize(3),
+			key:     "key",
+			data:    []byte("data"),
+			gets:    3,
+			rejects: 3,
+		},
+		"Predicate Mismatch": {
+			policy:  admission.Predicate(func(key string) bool { return !strings.HasPrefix(key, "lock:") }),
+			key:     "lock:1",
+			data:    []byte("data"),
+			gets:    3,
+			rejects: 3,
+		},
+		"TinyLFU One-off": {
+			policy:  admission.NewTinyLFU(admission.TinyLFUConfig{}),
+			key:     "key",
+			data:    []byte("data"),
+			gets:    1,
+			rejects: 1,
+		},
+		"TinyLFU Repeated": {
+			policy:   admission.NewTinyLFU(admission.TinyLFUConfig{}),
+			key:      "key",
+			data:     []byte("data"),
+			gets:     2,
+			expected: true,
+			rejects:  1,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, backing, _ := setupStale(t, Config{Admission: test.policy})
+			_ = backing.Set(test.key, test.data)
+
+			for i := 0; i < test.gets; i++ {
+				data, err := db.Get(test.key)
+				if err != nil || string(data) != string(test.data) {
+					t.Fatalf("Get() returned %s - %v, expected %s", data, err, test.data)
+				}
+			}
+
+			_, err := db.GetCache().Get(test.key)
+			if cached := err == nil; cached != test.expected {
+				t.Errorf("Unexpected cache state - cached %t, expected %t", cached, test.expected)
+			}
+
+			if n := db.Stats().AdmissionRejects; n != test.rejects {
+				t.Errorf("Unexpected number of admission rejects - got %d, expected %d", n, test.rejects)
+			}
+		})
+	}
+}
+
+func TestAdmissionSet(t *testing.T) {
+	db, backing, _ := setupStale(t, Config{Admission: admission.MaxSize(4)})
+
+	if err := db.Set("key", []byte("data")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+
+	// Rejected values remove the previously cached value
+	if err := db.Set("key", []byte("too large")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+
+	if _, err := db.GetCache().Get("key"); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("Expected rejected value to be removed from the cache, got %v", err)
+	}
+
+	data, err := backing.Get("key")
+	if err != nil || string(data) != "too large" {
+		t.Errorf("Database returned %s - %v, expected too large", data, err)
+	}
+}
+
+func TestAdmissionReplacesEntries(t *testing.T) {
+	unitTests := map[string]Config{
+		"Stale":    {SoftTTL: 20 * time.Millisecond},
+		"Negative": {NegativeTTL: 20 * time.Millisecond},
+	}
+
+	for name, cfg := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			rejecting := false
+			cfg.Admission = admission.PolicyFunc(func(_ string, _ []byte) bool { return !rejecting })
+			db, backing, _ := setupStale(t, cfg)
+
+			if cfg.NegativeTTL == 0 {
+				_ = backing.Set("key", []byte("old"))
+			}
+			_, _ = db.Get("key")
+
+			<-time.After(30 * time.Millisecond)
+			_ = backing.Set("key", []byte("new"))
+			rejecting = true
+
+			data, err := db.Get("key")
+			if err != nil || string(data) != "new" {
+				t.Errorf("Get() returned %s - %v, expected new", data, err)
+			}
+
+			if _, err := db.GetCache().Get("key"); !errors.Is(err, hord.ErrNil) {
+				t.Errorf("Expected the replaced entry to be removed from the cache, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package lookaside
+
+import (
+	"sync"
+)
+
+// call is an in-flight database fetch shared by concurrent callers requesting the same key.
+type call struct {
+	wg   sync.WaitGroup
+	data []byte
+	err  error
+}
+
+// load will fetch the data from the data database and store it in the cache. When request coalescing is enabled,
+// concurrent callers loading the same key share a single database fetch and cache fill.
+func (db *Lookaside) load(key string) ([]byte, error) {
+	if !db.coalesce {
+		return db.fill(key)
+	}
+
+	db.Lock()
+	if c, ok := db.calls[key]; ok {
+		db.Unlock()
+		db.stats.coalesced.Add(1)
+		c.wg.Wait()
+
+		// Callers receive their own copy so the shared result cannot be modified
+		if c.data == nil {
+			return nil, c.err
+		}
+		return append([]byte(nil), c.data...), c.err
+	}
+
+	c := &call{}
+	c.wg.Add(1)
+	db.calls[key] = c
+	db.Unlock()
+
+	c.data, c.err = db.fill(key)
+
+	db.Lock()
+	delete(db.calls, key)
+	db.Unlock()
+	c.wg.Done()
+
+	return c.data, c.err
+}
@@ -0,0 +1,99 @@
+package lookaside
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+func TestCoalesce(t *testing.T) {
+	unitTests := map[string]struct {
+		coalesce          bool
+		expectedFetches   int64
+		expectedCoalesced uint64
+	}{
+		"Enabled": {
+			coalesce:          true,
+			expectedFetches:   1,
+			expectedCoalesced: 49,
+		},
+		"Disabled": {
+			coalesce:          false,
+			expectedFetches:   50,
+			expectedCoalesced: 0,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			var fetches, fills atomic.Int64
+			release := make(chan struct{})
+
+			database, err := mock.Dial(mock.Config{
+				GetFunc: func(_ string) ([]byte, error) {
+					fetches.Add(1)
+					<-release
+					return []byte("database-data"), nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create mock database - %s", err)
+			}
+
+			cache, err := mock.Dial(mock.Config{
+				GetFunc: func(_ string) ([]byte, error) {
+					return nil, hord.ErrNil
+				},
+				SetFunc: func(_ string, _ []byte) error {
+					fills.Add(1)
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create mock cache - %s", err)
+			}
+
+			db, err := Dial(Config{Database: database, Cache: cache, Coalesce: test.coalesce})
+			if err != nil {
+				t.Fatalf("Failed to connect to database - %s", err)
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					data, err := db.Get("key")
+					if err != nil || string(data) != "database-data" {
+						t.Errorf("Get() returned %s - %v, expected database-data", data, err)
+					}
+				}()
+			}
+
+			// Wait for every caller to either fetch or join an in-flight fetch
+			deadline := time.Now().Add(5 * time.Second)
+			for uint64(fetches.Load())+db.Stats().Coalesced < 50 {
+				if time.Now().After(deadline) {
+					t.Fatalf("Timed out waiting for callers")
+				}
+				<-time.After(time.Millisecond)
+			}
+			close(release)
+			wg.Wait()
+
+			if n := fetches.Load(); n != test.expectedFetches {
+				t.Errorf("Unexpected number of database fetches - got %d, expected %d", n, test.expectedFetches)
+			}
+			if n := fills.Load(); n != test.expectedFetches {
+				t.Errorf("Unexpected number of cache fills - got %d, expected %d", n, test.expectedFetches)
+			}
+			if n := db.Stats().Coalesced; n != test.expectedCoalesced {
+				t.Errorf("Unexpected number of coalesced calls - got %d, expected %d", n, test.expectedCoalesced)
+			}
+		})
+	}
+}
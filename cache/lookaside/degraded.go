@@ -0,0 +1,167 @@
+package lookaside
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// DefaultRecoveryInterval is the default time between cache health checks while degraded.
+const DefaultRecoveryInterval = 5 * time.Second
+
+// Health describes the health of the database and the cache.
+type Health struct {
+	// Database is the error returned by the database health check, nil if healthy.
+	Database error
+
+	// Cache is the error returned by the cache health check, nil if healthy.
+	Cache error
+
+	// Degraded is true while lookups are served directly from the database because the cache is failing.
+	Degraded bool
+
+	// DegradedSince is the time the cache started failing, zero when not degraded.
+	DegradedSince time.Time
+
+	// PendingInvalidations is the number of keys written while degraded, removed from the cache before it is used
+	// again.
+	PendingInvalidations int
+}
+
+// Health will run the HealthCheck function for both the database and the cache, returning the health of each along
+// with the degraded state. A failing cache health check degrades the cache when Degrade is enabled.
+func (db *Lookaside) Health() Health {
+	if db == nil || db.data == nil || db.cache == nil {
+		return Health{Database: hord.ErrNoDial, Cache: hord.ErrNoDial}
+	}
+
+	h := Health{
+		Database: db.data.HealthCheck(),
+		Cache:    db.cache.HealthCheck(),
+	}
+
+	if h.Cache != nil {
+		db.cacheFailed(h.Cache)
+	}
+
+	db.degradeLock.Lock()
+	defer db.degradeLock.Unlock()
+	h.Degraded = db.degraded.Load()
+	h.DegradedSince = db.degradedSince
+	h.PendingInvalidations = len(db.dirty)
+
+	return h
+}
+
+// getDegraded will get the data directly from the data database while the cache is degraded.
+func (db *Lookaside) getDegraded(key string) ([]byte, error) {
+	db.stats.degradedReads.Add(1)
+	return db.miss(db.data.Get(key))
+}
+
+// cacheFailed will degrade the cache after a cache operation fails with err, returning true if the cache is degraded.
+// Errors describing missing keys or invalid keys and values do not degrade the cache.
+func (db *Lookaside) cacheFailed(err error) bool {
+	if !db.degrade || errors.Is(err, hord.ErrNil) || errors.Is(err, hord.ErrInvalidKey) ||
+		errors.Is(err, hord.ErrInvalidData) {
+		return false
+	}
+
+	db.degradeLock.Lock()
+	defer db.degradeLock.Unlock()
+
+	if db.degraded.Load() {
+		return true
+	}
+
+	db.degraded.Store(true)
+	db.degradedSince = time.Now()
+	db.stats.degradations.Add(1)
+
+	// Check the cache in the background until it recovers
+	select {
+	case <-db.done:
+	default:
+		db.wg.Add(1)
+		go db.recoverCache()
+	}
+
+	return true
+}
+
+// absorb returns err, unless it degraded the cache, in which case the key is recorded for removal from the cache once
+// it recovers and nil is returned.
+func (db *Lookaside) absorb(key string, err error) error {
+	if err == nil || !db.cacheFailed(err) {
+		return err
+	}
+
+	db.markDirty(key)
+	return nil
+}
+
+// markDirty will record a key written to the database while the cache is degraded, so any cached value is removed
+// before the cache is used again. If the cache has since recovered, the key is removed immediately.
+func (db *Lookaside) markDirty(key string) {
+	db.degradeLock.Lock()
+	if db.degraded.Load() {
+		db.dirty[key] = struct{}{}
+		db.degradeLock.Unlock()
+		return
+	}
+	db.degradeLock.Unlock()
+
+	if err := db.invalidate(key); err != nil && db.cacheFailed(err) {
+		db.markDirty(key)
+	}
+}
+
+// recoverCache will check the health of the cache every RecoveryInterval, removing the keys written while degraded once
+// it is healthy and resuming use of the cache once every key is removed.
+func (db *Lookaside) recoverCache() {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(db.recoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.done:
+			return
+		case <-ticker.C:
+		}
+
+		if db.cache.HealthCheck() != nil {
+			continue
+		}
+
+		db.degradeLock.Lock()
+		keys := make([]string, 0, len(db.dirty))
+		for k := range db.dirty {
+			keys = append(keys, k)
+		}
+		db.degradeLock.Unlock()
+
+		// Keys failing to be removed are retried on the next check
+		var removed []string
+		for _, k := range keys {
+			if err := db.invalidate(k); err == nil {
+				removed = append(removed, k)
+			}
+		}
+
+		db.degradeLock.Lock()
+		for _, k := range removed {
+			delete(db.dirty, k)
+		}
+		if len(db.dirty) == 0 {
+			db.degraded.Store(false)
+			db.degradedSince = time.Time{}
+			db.stats.recoveries.Add(1)
+			db.degradeLock.Unlock()
+			return
+		}
+		db.degradeLock.Unlock()
+	}
+}
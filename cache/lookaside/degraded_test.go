@@ -0,0 +1,215 @@
+package lookaside
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// setupDegraded is a helper function creating a lookaside cache with a cache that fails while failing is set.
+func setupDegraded(t *testing.T, degrade bool) (*Lookaside, hord.Database, hord.Database, *atomic.Bool) {
+	backing, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	failing := &atomic.Bool{}
+	cache, err := mock.Dial(mock.Config{
+		GetFunc: func(key string) ([]byte, error) {
+			if failing.Load() {
+				return nil, ErrCacheTest
+			}
+			return backing.Get(key)
+		},
+		SetFunc: func(key string, data []byte) error {
+			if failing.Load() {
+				return ErrCacheTest
+			}
+			return backing.Set(key, data)
+		},
+		DeleteFunc: func(key string) error {
+			if failing.Load() {
+				return ErrCacheTest
+			}
+			return backing.Delete(key)
+		},
+		HealthCheckFunc: func() error {
+			if failing.Load() {
+				return ErrCacheTest
+			}
+			return nil
+		},
+		KeysFunc: backing.Keys,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock cache - %s", err)
+	}
+
+	db, err := Dial(Config{
+		Database:         database,
+		Cache:            cache,
+		Degrade:          degrade,
+		RecoveryInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	t.Cleanup(db.Close)
+
+	return db, database, backing, failing
+}
+
+func TestDegraded(t *testing.T) {
+	db, database, backing, failing := setupDegraded(t, true)
+
+	_ = db.Set("key", []byte("old"))
+	_ = db.Set("other", []byte("data"))
+	failing.Store(true)
+
+	t.Run("Health", func(t *testing.T) {
+		if err := db.HealthCheck(); err != nil {
+			t.Errorf("HealthCheck() returned error: %s", err)
+		}
+
+		h := db.Health()
+		if h.Database != nil || !errors.Is(h.Cache, ErrCacheTest) || !h.Degraded || h.DegradedSince.IsZero() {
+			t.Errorf("Unexpected health - %+v", h)
+		}
+	})
+
+	t.Run("Served From Database", func(t *testing.T) {
+		data, err := db.Get("key")
+		if err != nil || string(data) != "old" {
+			t.Errorf("Get() returned %s - %v, expected old", data, err)
+		}
+
+		if err := db.Set("key", []byte("new")); err != nil {
+			t.Errorf("Set() returned error: %s", err)
+		}
+
+		data, err = db.Get("key")
+		if err != nil || string(data) != "new" {
+			t.Errorf("Get() returned %s - %v, expected new", data, err)
+		}
+
+		if err := db.Delete("other"); err != nil {
+			t.Errorf("Delete() returned error: %s", err)
+		}
+
+		if h := db.Health(); h.PendingInvalidations != 2 {
+			t.Errorf("Unexpected pending invalidations - %d", h.PendingInvalidations)
+		}
+
+		if s := db.Stats(); s.Degradations != 1 || s.DegradedReads != 2 {
+			t.Errorf("Unexpected stats - %+v", s)
+		}
+	})
+
+	t.Run("Recovery", func(t *testing.T) {
+		failing.Store(false)
+
+		deadline := time.Now().Add(5 * time.Second)
+		for db.Health().Degraded {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for the cache to recover")
+			}
+			<-time.After(10 * time.Millisecond)
+		}
+
+		// Keys written while degraded are removed from the cache before it is used again
+		if _, err := backing.Get("key"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected the outdated value to be removed from the cache, got %v", err)
+		}
+		if _, err := backing.Get("other"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected the deleted value to be removed from the cache, got %v", err)
+		}
+
+		data, err := db.Get("key")
+		if err != nil || string(data) != "new" {
+			t.Errorf("Get() returned %s - %v, expected new", data, err)
+		}
+
+		if _, err := backing.Get("key"); err != nil {
+			t.Errorf("Expected the cache to be filled again, got %v", err)
+		}
+
+		if _, err := database.Get("other"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected other to be deleted from the database, got %v", err)
+		}
+
+		if s := db.Stats(); s.Recoveries != 1 {
+			t.Errorf("Unexpected stats - %+v", s)
+		}
+	})
+}
+
+func TestDegradedFill(t *testing.T) {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+	_ = database.Set("key", []byte("data"))
+
+	var lookups atomic.Int32
+	cache, err := mock.Dial(mock.Config{
+		GetFunc: func(_ string) ([]byte, error) {
+			lookups.Add(1)
+			return nil, hord.ErrNil
+		},
+		SetFunc: func(_ string, _ []byte) error {
+			return ErrCacheTest
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock cache - %s", err)
+	}
+
+	db, err := Dial(Config{Database: database, Cache: cache, Degrade: true, RecoveryInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	defer db.Close()
+
+	// Fill failures degrade the cache rather than returning an error
+	data, err := db.Get("key")
+	if err != nil || string(data) != "data" {
+		t.Errorf("Get() returned %s - %v, expected data", data, err)
+	}
+
+	if h := db.Health(); !h.Degraded || h.PendingInvalidations != 1 {
+		t.Errorf("Unexpected health - %+v", h)
+	}
+
+	// Degraded lookups skip the cache
+	_, _ = db.Get("key")
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("Unexpected number of cache lookups - %d", n)
+	}
+}
+
+func TestDegradeDisabled(t *testing.T) {
+	db, _, _, failing := setupDegraded(t, false)
+	failing.Store(true)
+
+	if err := db.HealthCheck(); !errors.Is(err, hord.ErrHealthCheckFailure) {
+		t.Errorf("HealthCheck() returned error: %v, expected %s", err, hord.ErrHealthCheckFailure)
+	}
+
+	if _, err := db.Get("key"); !errors.Is(err, ErrCacheTest) {
+		t.Errorf("Get() returned error: %v, expected %s", err, ErrCacheTest)
+	}
+
+	if h := db.Health(); h.Degraded || h.Cache == nil {
+		t.Errorf("Unexpected health - %+v", h)
+	}
+}
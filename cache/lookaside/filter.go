@@ -0,0 +1,162 @@
+package lookaside
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/tarmac-project/hord/cache/internal/doublehash"
+)
+
+const (
+	// DefaultFilterFalsePositiveRate is the default target false positive rate of the membership filter.
+	DefaultFilterFalsePositiveRate = 0.01
+
+	// DefaultFilterRebuildInterval is the default time between membership filter rebuilds.
+	DefaultFilterRebuildInterval = 10 * time.Minute
+
+	// minFilterCapacity is the minimum number of keys the membership filter is sized for.
+	minFilterCapacity = 1024
+)
+
+// bloom is a Bloom filter used to test whether a key may exist within the database. It is not safe for concurrent
+// use.
+type bloom struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// newBloom returns a Bloom filter sized to hold n keys with a false positive rate of p.
+func newBloom(n int, p float64) *bloom {
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+
+	return &bloom{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// add will add the key to the filter.
+func (b *bloom) add(key string) {
+	h1, h2 := doublehash.Sum(key)
+	for i := uint64(0); i < b.k; i++ {
+		n := (h1 + i*h2) % b.m
+		b.bits[n/64] |= 1 << (n % 64)
+	}
+}
+
+// test returns false if the key is definitely not within the filter.
+func (b *bloom) test(key string) bool {
+	h1, h2 := doublehash.Sum(key)
+	for i := uint64(0); i < b.k; i++ {
+		n := (h1 + i*h2) % b.m
+		if b.bits[n/64]&(1<<(n%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// buildFilter will build a new membership filter from the keys within the data database and replace the current
+// filter. Keys set while the filter is being built are included.
+func (db *Lookaside) buildFilter() error {
+	db.filterLock.Lock()
+	db.filterBuilding = true
+	db.filterPending = nil
+	db.filterLock.Unlock()
+
+	keys, err := db.data.Keys()
+	if err != nil {
+		db.filterLock.Lock()
+		db.filterBuilding = false
+		db.filterPending = nil
+		db.filterLock.Unlock()
+		return fmt.Errorf("unable to build membership filter: %w", err)
+	}
+
+	db.filterLock.Lock()
+	defer db.filterLock.Unlock()
+
+	// Size the filter with headroom for keys added before the next rebuild
+	n := (len(keys) + len(db.filterPending)) * 2
+	if n < minFilterCapacity {
+		n = minFilterCapacity
+	}
+
+	f := newBloom(n, db.filterRate)
+	for _, k := range keys {
+		f.add(k)
+	}
+	for _, k := range db.filterPending {
+		f.add(k)
+	}
+
+	db.filter = f
+	db.filterBuilding = false
+	db.filterPending = nil
+	db.stats.filterRebuilds.Add(1)
+
+	return nil
+}
+
+// filterAdd will add the key to the membership filter.
+func (db *Lookaside) filterAdd(key string) {
+	if !db.filterEnabled {
+		return
+	}
+
+	db.filterLock.Lock()
+	defer db.filterLock.Unlock()
+
+	if db.filter != nil {
+		db.filter.add(key)
+	}
+
+	if db.filterBuilding {
+		db.filterPending = append(db.filterPending, key)
+	}
+}
+
+// Invalidated records a key written by another client, adding it to the membership filter so lookups for it are no
+// longer rejected before the next rebuild. It does nothing when the filter is disabled.
+func (db *Lookaside) Invalidated(key string) {
+	if db == nil {
+		return
+	}
+
+	db.filterAdd(key)
+}
+
+// filterRejects returns true if the membership filter reports the key definitely does not exist. Keys are never
+// rejected before the filter has been built.
+func (db *Lookaside) filterRejects(key string) bool {
+	if !db.filterEnabled {
+		return false
+	}
+
+	db.filterLock.RLock()
+	defer db.filterLock.RUnlock()
+
+	return db.filter != nil && !db.filter.test(key)
+}
+
+// rebuildFilter will periodically rebuild the membership filter until the driver is closed.
+func (db *Lookaside) rebuildFilter() {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(db.filterInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.done:
+			return
+		case <-ticker.C:
+			// Failed rebuilds keep the current filter, which remains valid as keys are added on Set
+			_ = db.buildFilter()
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package lookaside
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+func TestBloom(t *testing.T) {
+	f := newBloom(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		f.add(fmt.Sprintf("key-%d", i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		if !f.test(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Expected key-%d to be within the filter", i)
+		}
+	}
+
+	var falsePositives int
+	for i := 0; i < 10000; i++ {
+		if f.test(fmt.Sprintf("missing-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	if falsePositives > 300 {
+		t.Errorf("Unexpected false positive count - got %d of 10000, expected around 100", falsePositives)
+	}
+}
+
+func TestFilterDial(t *testing.T) {
+	unitTests := map[string]struct {
+		rate float64
+		err  error
+	}{
+		"Default":  {rate: 0},
+		"Custom":   {rate: 0.001},
+		"Negative": {rate: -0.1, err: ErrInvalidFalsePositiveRate},
+		"Too High": {rate: 1, err: ErrInvalidFalsePositiveRate},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, _, _ := setupStale(t, Config{})
+			_, err := Dial(Config{
+				Database:                db.data,
+				Cache:                   db.cache,
+				Filter:                  true,
+				FilterFalsePositiveRate: test.rate,
+			})
+			if !errors.Is(err, test.err) {
+				t.Errorf("Dial() returned error: %v, expected %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	db, backing, failing := setupStale(t, Config{Filter: true, FilterRebuildInterval: 20 * time.Millisecond})
+	defer db.Close()
+
+	_ = backing.Set("existing", []byte("data"))
+
+	t.Run("Not Rejected Before Setup", func(t *testing.T) {
+		if _, err := db.Get("missing"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+
+		if n := db.Stats().FilterRejects; n != 0 {
+			t.Errorf("Unexpected number of filter rejects - got %d, expected 0", n)
+		}
+	})
+
+	if err := db.Setup(); err != nil {
+		t.Fatalf("Setup() returned error: %s", err)
+	}
+
+	t.Run("Missing Key Rejected", func(t *testing.T) {
+		// A failing database proves the lookup never reached it
+		*failing = true
+		defer func() { *failing = false }()
+
+		if _, err := db.Get("another-missing"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+
+		if n := db.Stats().FilterRejects; n != 1 {
+			t.Errorf("Unexpected number of filter rejects - got %d, expected 1", n)
+		}
+	})
+
+	t.Run("Existing Key", func(t *testing.T) {
+		data, err := db.Get("existing")
+		if err != nil || string(data) != "data" {
+			t.Errorf("Get() returned %s - %v, expected data", data, err)
+		}
+	})
+
+	t.Run("Set Adds Key", func(t *testing.T) {
+		if err := db.Set("new", []byte("value")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		data, err := db.Get("new")
+		if err != nil || string(data) != "value" {
+			t.Errorf("Get() returned %s - %v, expected value", data, err)
+		}
+	})
+
+	t.Run("Rebuild", func(t *testing.T) {
+		// Keys written by other clients are found once the filter is rebuilt
+		_ = backing.Set("external", []byte("external-data"))
+
+		rebuilds := db.Stats().FilterRebuilds
+		deadline := time.Now().Add(5 * time.Second)
+		for db.Stats().FilterRebuilds <= rebuilds {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for filter rebuild")
+			}
+			<-time.After(time.Millisecond)
+		}
+
+		data, err := db.Get("external")
+		if err != nil || string(data) != "external-data" {
+			t.Errorf("Get() returned %s - %v, expected external-data", data, err)
+		}
+	})
+
+	t.Run("Setup Is Repeatable", func(t *testing.T) {
+		if err := db.Setup(); err != nil {
+			t.Errorf("Setup() returned error: %s", err)
+		}
+	})
+}
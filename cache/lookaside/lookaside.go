@@ -6,6 +6,89 @@ Package lookaside provides a Hord database driver for a look-aside cache. To use
 	    "github.com/tarmac-project/hord/cache/lookaside"
 	)
 
+When a SoftTTL is configured, cached values are considered stale once the SoftTTL elapses. Stale values are kept within
+the cache and refreshed on the next Get. With StaleWhileRevalidate, stale values are returned immediately while being
+refreshed in the background, and with ServeStaleOnError, stale values are returned when the database cannot be reached.
+Stale values are always returned along with an error wrapping ErrStale so callers know the data may be out of date.
+
+	value, err := db.Get("key")
+	if err != nil && !errors.Is(err, lookaside.ErrStale) {
+	    // Handle error
+	}
+
+When Coalesce is enabled, concurrent cache misses for the same key share a single database fetch and cache fill. The
+number of calls served by a shared fetch is reported by Stats().
+
+When a NegativeTTL is configured, keys not found within the database are recorded within the cache, so repeated
+lookups for missing keys return hord.ErrNil without checking the database until the NegativeTTL elapses. Set replaces
+the negative entry with the new value.
+
+When Filter is enabled, Setup() builds a Bloom filter from the database keys. Lookups for keys the filter reports as
+definitely missing return hord.ErrNil without checking either the cache or the database. Keys are added to the filter
+on Set, while deleted keys remain until the filter is rebuilt every FilterRebuildInterval. Keys written to the database
+by other clients are not found until the next rebuild, unless they are passed to Invalidated(), for example by an
+invalidation bus. Before Setup() builds the filter, no keys are rejected.
+
+When an Admission policy is configured, values are only stored within the cache when admitted by the policy, so one-off
+lookups such as those made by scanning jobs do not evict values worth keeping. Values that are not admitted are still
+returned, and are removed from the cache on Set so an older cached value is not served in their place. Policies are
+provided by the admission package.
+
+	db, err := lookaside.Dial(lookaside.Config{
+		Database:  database,
+		Cache:     cache,
+		Admission: admission.NewTinyLFU(admission.TinyLFUConfig{}),
+	})
+
+Cache activity is reported by Stats(), including hits, misses, fills and the bytes served from the cache and the
+database. Counters are safe to read while the cache is in use and are reset with ResetStats().
+
+	stats := db.Stats()
+	log.Printf("hit ratio %.2f, %d fill errors", stats.HitRatio(), stats.FillErrors)
+
+Verify() walks the cache keys and compares every cached entry with the database, reporting values that differ, values
+no longer found within the database, and negative entries hiding values that now exist. With Repair, inconsistent
+entries are removed from the cache so they are filled from the database on the next Get. A Rate limits the number of
+keys checked per second. Cache keys mapped by a keymap.KeyMap whose original key cannot be recovered are reported as
+Unchecked.
+
+	report, err := db.Verify(lookaside.VerifyConfig{Repair: true, Rate: 500})
+	if err != nil {
+	    // Handle error
+	}
+
+Policies override the caching behavior for keys matching a pattern, using the syntax of path.Match, where * and ? do not
+match /. The first matching Policy is used, and keys matching no Policy use the SoftTTL and Admission policy of Config.
+
+	db, err := lookaside.Dial(lookaside.Config{
+		Database: database,
+		Cache:    cache,
+		Policies: []lookaside.Policy{
+			{Pattern: "user:*", TTL: time.Hour},
+			{Pattern: "lock:*", Bypass: true},
+			{Pattern: "config:*", TTL: lookaside.Forever},
+		},
+	})
+
+GetWithOptions() changes the behavior of a single lookup. Bypass reads directly from the database, Refresh replaces the
+cached value with the value within the database, and NoFill reads from the database on a miss without storing the
+value within the cache.
+
+	value, err := db.GetWithOptions("report:2024", lookaside.GetOptions{NoFill: true})
+	if err != nil {
+	    // Handle error
+	}
+
+When Degrade is enabled, a failing cache no longer fails lookups or health checks. Lookups are served directly from
+the database, and writes only update the database while recording the keys written. The cache is health checked every
+RecoveryInterval, and once healthy the recorded keys are removed from it before it is used again. Health() reports the
+health of the database and the cache along with the degraded state.
+
+	h := db.Health()
+	if h.Degraded {
+	    log.Printf("cache degraded since %s: %s", h.DegradedSince, h.Cache)
+	}
+
 # Connecting to the Database
 
 Use the Dial() function to create a new client for interacting with the cache.
@@ -85,30 +168,178 @@ package lookaside
 import (
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/cache/admission"
 )
 
 // Config provides the configuration options for the Lookaside driver.
 type Config struct {
 	Database hord.Database
 	Cache    hord.Database
+
+	// SoftTTL is the time after which cached values are considered stale. Stale values are kept within the cache and
+	// refreshed from the database on the next Get. Default is 0, which disables staleness tracking.
+	SoftTTL time.Duration
+
+	// StaleWhileRevalidate returns stale values immediately, along with ErrStale, while refreshing them from the
+	// database in the background. Requires SoftTTL.
+	StaleWhileRevalidate bool
+
+	// ServeStaleOnError returns stale values, along with ErrStale, when refreshing them from the database fails.
+	// Requires SoftTTL.
+	ServeStaleOnError bool
+
+	// Coalesce enables request coalescing, so concurrent cache misses for the same key share a single database fetch
+	// and cache fill.
+	Coalesce bool
+
+	// NegativeTTL enables negative caching. Keys not found within the database are recorded within the cache and
+	// lookups return hord.ErrNil without checking the database until the NegativeTTL elapses. Default is 0, which
+	// disables negative caching.
+	NegativeTTL time.Duration
+
+	// Filter enables a Bloom filter membership guard, built from the database keys during Setup(). Lookups for keys
+	// the filter reports as definitely missing return hord.ErrNil without checking the cache or the database.
+	Filter bool
+
+	// FilterFalsePositiveRate is the target false positive rate of the membership filter. Default is 0.01.
+	FilterFalsePositiveRate float64
+
+	// FilterRebuildInterval is the time between rebuilds of the membership filter, which drop deleted keys and pick up
+	// keys written by other clients. Default is 10 minutes.
+	FilterRebuildInterval time.Duration
+
+	// Admission decides which values are stored within the cache. Default is nil, which admits every value.
+	Admission admission.Policy
+
+	// Policies override the SoftTTL, Admission and write behavior for keys matching a pattern. The first Policy
+	// matching a key is used.
+	Policies []Policy
+
+	// Degrade serves lookups directly from the database while the cache is failing, rather than returning cache
+	// errors. The cache is used again once healthy, after removing the keys written while degraded.
+	Degrade bool
+
+	// RecoveryInterval is the time between cache health checks while degraded. Default is 5 seconds.
+	RecoveryInterval time.Duration
 }
 
 // Lookaside is used to store data in a look-aside caching pattern. It also satisfies the Hord database interface.
 type Lookaside struct {
+	sync.Mutex
+
 	data  hord.Database
 	cache hord.Database
+
+	softTTL              time.Duration
+	envelope             bool
+	staleWhileRevalidate bool
+	serveStaleOnError    bool
+
+	// revalidating holds the keys being refreshed in the background.
+	revalidating map[string]struct{}
+	wg           sync.WaitGroup
+
+	coalesce bool
+
+	// calls holds the in-flight database fetches when request coalescing is enabled.
+	calls map[string]*call
+
+	negativeTTL time.Duration
+
+	filterEnabled  bool
+	filterRate     float64
+	filterInterval time.Duration
+
+	// filter is the membership filter, nil until built by Setup(). filterPending holds keys set while a rebuild is
+	// running.
+	filter         *bloom
+	filterBuilding bool
+	filterPending  []string
+	filterLock     sync.RWMutex
+	filterStarted  bool
+
+	admission admission.Policy
+	policies  []Policy
+
+	degrade          bool
+	recoveryInterval time.Duration
+
+	// degraded is set while the cache is failing. dirty holds the keys written while degraded.
+	degraded      atomic.Bool
+	degradedSince time.Time
+	dirty         map[string]struct{}
+	degradeLock   sync.Mutex
+
+	// done is closed to stop background filter rebuilds.
+	done      chan struct{}
+	closeOnce sync.Once
+
+	stats counters
 }
 
+var (
+	// ErrStale is returned along with data when the data was served from the cache after its SoftTTL elapsed. It is
+	// the same error as hord.ErrStale.
+	ErrStale = hord.ErrStale
+
+	// ErrInvalidFalsePositiveRate is returned when the configured filter false positive rate is not between 0 and 1.
+	ErrInvalidFalsePositiveRate = errors.New("filter false positive rate must be between 0 and 1")
+)
+
 func Dial(cfg Config) (*Lookaside, error) {
 	if (cfg.Database == nil) || (cfg.Cache == nil) {
 		return nil, hord.ErrInvalidDatabase
 	}
 
+	if cfg.FilterFalsePositiveRate == 0 {
+		cfg.FilterFalsePositiveRate = DefaultFilterFalsePositiveRate
+	}
+	if cfg.FilterFalsePositiveRate <= 0 || cfg.FilterFalsePositiveRate >= 1 {
+		return nil, ErrInvalidFalsePositiveRate
+	}
+
+	if cfg.FilterRebuildInterval <= 0 {
+		cfg.FilterRebuildInterval = DefaultFilterRebuildInterval
+	}
+
+	if cfg.RecoveryInterval <= 0 {
+		cfg.RecoveryInterval = DefaultRecoveryInterval
+	}
+
+	// Cached values carry the time they were cached when any key may become stale
+	envelope := cfg.SoftTTL > 0
+	for _, p := range cfg.Policies {
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		envelope = envelope || p.TTL > 0
+	}
+
 	return &Lookaside{
-		data:  cfg.Database,
-		cache: cfg.Cache,
+		data:                 cfg.Database,
+		cache:                cfg.Cache,
+		softTTL:              cfg.SoftTTL,
+		envelope:             envelope,
+		staleWhileRevalidate: cfg.StaleWhileRevalidate,
+		serveStaleOnError:    cfg.ServeStaleOnError,
+		revalidating:         make(map[string]struct{}),
+		coalesce:             cfg.Coalesce,
+		calls:                make(map[string]*call),
+		negativeTTL:          cfg.NegativeTTL,
+		filterEnabled:        cfg.Filter,
+		filterRate:           cfg.FilterFalsePositiveRate,
+		filterInterval:       cfg.FilterRebuildInterval,
+		admission:            cfg.Admission,
+		policies:             cfg.Policies,
+		degrade:              cfg.Degrade,
+		recoveryInterval:     cfg.RecoveryInterval,
+		dirty:                make(map[string]struct{}),
+		done:                 make(chan struct{}),
 	}, nil
 }
 
@@ -126,10 +357,25 @@ func (db *Lookaside) Setup() error {
 		return err
 	}
 
+	if db.filterEnabled {
+		if err := db.buildFilter(); err != nil {
+			return err
+		}
+
+		db.Lock()
+		defer db.Unlock()
+		if !db.filterStarted {
+			db.filterStarted = true
+			db.wg.Add(1)
+			go db.rebuildFilter()
+		}
+	}
+
 	return nil
 }
 
-// HealthCheck will run the HealthCheck function for both the database and the cache.
+// HealthCheck will run the HealthCheck function for both the database and the cache. When Degrade is enabled, a
+// failing cache degrades the cache rather than failing the health check. Use Health() for the health of each.
 func (db *Lookaside) HealthCheck() error {
 	if db == nil || db.data == nil || db.cache == nil {
 		return hord.ErrNoDial
@@ -140,7 +386,7 @@ func (db *Lookaside) HealthCheck() error {
 
 	if dataErr != nil {
 		return errors.Join(hord.ErrHealthCheckFailure, dataErr)
-	} else if cacheErr != nil {
+	} else if cacheErr != nil && !db.cacheFailed(cacheErr) {
 		return errors.Join(hord.ErrHealthCheckFailure, cacheErr)
 	}
 
@@ -148,34 +394,111 @@ func (db *Lookaside) HealthCheck() error {
 }
 
 // Get will get the data from the cache database. If not found, it uses a look-aside pattern to fetch from the data database and store the data in the cache.
+//
+// When a SoftTTL is configured, stale data may be returned along with an error wrapping ErrStale.
 func (db *Lookaside) Get(key string) ([]byte, error) {
+	return db.GetWithOptions(key, GetOptions{})
+}
+
+// GetWithOptions will get the data in the same way as Get, with the behavior of this call changed by GetOptions.
+func (db *Lookaside) GetWithOptions(key string, opts GetOptions) ([]byte, error) {
 	if db == nil || db.data == nil || db.cache == nil {
 		return nil, hord.ErrNoDial
 	}
 
+	p := db.policy(key)
+	if opts.Bypass || p.Bypass || (opts.Refresh && opts.NoFill) {
+		db.stats.bypassed.Add(1)
+		return db.data.Get(key)
+	}
+
+	// Skip both tiers for keys known to be missing
+	if !opts.Refresh && db.filterRejects(key) {
+		db.stats.filterRejected.Add(1)
+		return nil, hord.ErrNil
+	}
+
+	if db.degraded.Load() {
+		return db.getDegraded(key)
+	}
+
+	if opts.Refresh {
+		return db.miss(db.reload(key))
+	}
+
 	// Check the cache first
 	data, err := db.cache.Get(key)
 	if (err != nil) && !errors.Is(err, hord.ErrNil) {
+		if db.cacheFailed(err) {
+			return db.getDegraded(key)
+		}
 		return nil, err
 	} else if !errors.Is(err, hord.ErrNil) {
-		return data, nil
+		if storedAt, ok := isNegative(data); ok {
+			if time.Since(storedAt) < db.negativeTTL {
+				db.stats.negativeHits.Add(1)
+				return nil, hord.ErrNil
+			}
+			return db.miss(db.fetch(key, opts.NoFill))
+		}
+
+		value, storedAt := db.decode(data)
+		if !db.isStale(p.TTL, storedAt) {
+			db.hit(value)
+			return value, nil
+		}
+		return db.getStale(key, value, opts.NoFill)
 	}
 
+	return db.miss(db.fetch(key, opts.NoFill))
+}
+
+// fill will fetch the data from the data database and store it in the cache.
+func (db *Lookaside) fill(key string) ([]byte, error) {
 	// Check the data database
-	data, err = db.data.Get(key)
+	data, err := db.data.Get(key)
+	if errors.Is(err, hord.ErrNil) && db.negativeTTL > 0 {
+		// Record the missing key, failing to do so only means the next lookup checks the database again
+		_ = db.cache.Set(key, negativeEntry())
+		return nil, err
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if !db.admit(key, data) {
+		// Remove any stale or negative cache entry the rejected value would have replaced
+		if db.envelope || db.negativeTTL > 0 {
+			_ = db.invalidate(key)
+		}
+		return data, nil
+	}
+
 	// Update the cache
-	err = db.cache.Set(key, data)
+	err = db.cache.Set(key, db.encode(data))
 	if err != nil {
+		db.stats.fillErrors.Add(1)
+		if db.absorb(key, err) == nil {
+			return data, nil
+		}
 		return data, fmt.Errorf("%w: %w", hord.ErrCacheError, err)
 	}
+	db.stats.fills.Add(1)
 
 	return data, nil
 }
 
+// admit returns true if the data should be stored within the cache.
+func (db *Lookaside) admit(key string, data []byte) bool {
+	p := db.policy(key)
+	if p.Admission == nil || p.Admission.Admit(key, data) {
+		return true
+	}
+
+	db.stats.admitRejected.Add(1)
+	return false
+}
+
 // Set will set the data in both the data and cache databases.
 func (db *Lookaside) Set(key string, data []byte) error {
 	if db == nil || db.data == nil || db.cache == nil {
@@ -186,11 +509,31 @@ func (db *Lookaside) Set(key string, data []byte) error {
 	if err != nil {
 		return err
 	}
+	db.filterAdd(key)
+
+	p := db.policy(key)
+	if p.Bypass {
+		return nil
+	}
+
+	if db.degraded.Load() {
+		db.markDirty(key)
+		return nil
+	}
+
+	// Remove values not worth caching so an older cached value is not served
+	if p.WritePolicy == Invalidate || !db.admit(key, data) {
+		return db.absorb(key, db.invalidate(key))
+	}
 
 	// Update cache only if database Set was successful
-	err = db.cache.Set(key, data)
+	err = db.cache.Set(key, db.encode(data))
 	if err != nil {
-		return err
+		// Remove any negative cache entry so the new value is not hidden
+		if db.negativeTTL > 0 {
+			_ = db.invalidate(key)
+		}
+		return db.absorb(key, err)
 	}
 
 	return nil
@@ -203,7 +546,11 @@ func (db *Lookaside) Delete(key string) error {
 	}
 
 	dataErr := db.data.Delete(key)
-	cacheErr := db.cache.Delete(key)
+	if db.degraded.Load() {
+		db.markDirty(key)
+		return dataErr
+	}
+	cacheErr := db.absorb(key, db.invalidate(key))
 
 	if dataErr != nil {
 		return dataErr
@@ -242,9 +589,12 @@ func (db *Lookaside) GetDatabase() hord.Database {
 	return db.data
 }
 
-// Close will close the connections to both the database and the cache.
+// Close will stop background filter rebuilds, wait for background refreshes to complete and close the connections to
+// both the database and the cache.
 func (db *Lookaside) Close() {
 	if db != nil && db.data != nil && db.cache != nil {
+		db.closeOnce.Do(func() { close(db.done) })
+		db.wg.Wait()
 		db.data.Close()
 		db.cache.Close()
 	}
@@ -0,0 +1,30 @@
+package lookaside
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+)
+
+// negativeHeader identifies cache entries recording that a key does not exist within the database.
+var negativeHeader = []byte("\x00hord-lookaside-nil\x00")
+
+// negativeSize is the size of a negative cache entry.
+var negativeSize = len(negativeHeader) + 8
+
+// negativeEntry returns a negative cache entry recording that a key was found missing now.
+func negativeEntry() []byte {
+	b := make([]byte, negativeSize)
+	copy(b, negativeHeader)
+	binary.BigEndian.PutUint64(b[len(negativeHeader):], uint64(time.Now().UnixNano()))
+	return b
+}
+
+// isNegative returns true and the time the entry was created if data is a negative cache entry.
+func isNegative(data []byte) (time.Time, bool) {
+	if len(data) != negativeSize || !bytes.HasPrefix(data, negativeHeader) {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data[len(negativeHeader):]))), true
+}
@@ -0,0 +1,97 @@
+package lookaside
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+func TestNegativeCaching(t *testing.T) {
+	db, backing, _ := setupStale(t, Config{NegativeTTL: 50 * time.Millisecond})
+
+	t.Run("Miss Is Cached", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			_, err := db.Get("missing")
+			if !errors.Is(err, hord.ErrNil) {
+				t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+			}
+		}
+
+		if n := db.Stats().NegativeHits; n != 2 {
+			t.Errorf("Unexpected number of negative hits - got %d, expected 2", n)
+		}
+
+		// Values added directly to the database are hidden until the negative entry expires
+		_ = backing.Set("missing", []byte("data"))
+		if _, err := db.Get("missing"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+	})
+
+	t.Run("Negative Entry Expires", func(t *testing.T) {
+		<-time.After(60 * time.Millisecond)
+
+		data, err := db.Get("missing")
+		if err != nil || string(data) != "data" {
+			t.Errorf("Get() returned %s - %v, expected data", data, err)
+		}
+	})
+
+	t.Run("Set Clears Negative Entry", func(t *testing.T) {
+		_, _ = db.Get("new")
+
+		if err := db.Set("new", []byte("value")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		data, err := db.Get("new")
+		if err != nil || string(data) != "value" {
+			t.Errorf("Get() returned %s - %v, expected value", data, err)
+		}
+	})
+}
+
+func TestNegativeCachingSetFailure(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	failing, err := mock.Dial(mock.Config{
+		GetFunc:    cache.Get,
+		DeleteFunc: cache.Delete,
+		SetFunc: func(key string, data []byte) error {
+			if _, ok := isNegative(data); ok {
+				return cache.Set(key, data)
+			}
+			return ErrCacheTest
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock cache - %s", err)
+	}
+
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	db, err := Dial(Config{Database: database, Cache: failing, NegativeTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	_, _ = db.Get("key")
+
+	if err := db.Set("key", []byte("value")); !errors.Is(err, ErrCacheTest) {
+		t.Errorf("Set() returned error: %v, expected %s", err, ErrCacheTest)
+	}
+
+	if _, err := cache.Get("key"); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("Expected negative entry to be removed after failed cache write, got %v", err)
+	}
+}
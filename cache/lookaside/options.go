@@ -0,0 +1,45 @@
+package lookaside
+
+import (
+	"errors"
+
+	"github.com/tarmac-project/hord"
+)
+
+// GetOptions changes the behavior of a single call to GetWithOptions.
+type GetOptions struct {
+	// Bypass reads the value directly from the database, without checking or updating the cache.
+	Bypass bool
+
+	// Refresh skips the cached value, reading the value from the database and storing it within the cache. Keys no
+	// longer found within the database are removed from the cache. Combined with NoFill, Refresh behaves as Bypass.
+	Refresh bool
+
+	// NoFill checks the cache as usual, but values read from the database on a miss are not stored within the cache.
+	// Stale values are also read from the database without being refreshed.
+	NoFill bool
+}
+
+// fetch will fetch the data from the data database on a cache miss, storing it in the cache unless noFill is set.
+func (db *Lookaside) fetch(key string, noFill bool) ([]byte, error) {
+	if noFill {
+		return db.data.Get(key)
+	}
+
+	return db.load(key)
+}
+
+// reload will fetch the data from the data database and store it in the cache without sharing an in-flight fetch, so
+// the value is read after the call started. Keys found are added to the membership filter, while keys no longer found
+// within the data database are removed from the cache, unless replaced by a negative cache entry.
+func (db *Lookaside) reload(key string) ([]byte, error) {
+	data, err := db.fill(key)
+	if err == nil || errors.Is(err, hord.ErrCacheError) {
+		db.filterAdd(key)
+	}
+	if errors.Is(err, hord.ErrNil) && db.negativeTTL <= 0 {
+		_ = db.invalidate(key)
+	}
+
+	return data, err
+}
@@ -0,0 +1,132 @@
+package lookaside
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+func TestGetWithOptions(t *testing.T) {
+	unitTests := map[string]struct {
+		opts           GetOptions
+		cached         bool
+		expected       string
+		expectedCached string
+	}{
+		"Default Hit": {
+			cached:         true,
+			expected:       "cached",
+			expectedCached: "cached",
+		},
+		"Default Miss": {
+			expected:       "database",
+			expectedCached: "database",
+		},
+		"Bypass Hit": {
+			opts:           GetOptions{Bypass: true},
+			cached:         true,
+			expected:       "database",
+			expectedCached: "cached",
+		},
+		"Bypass Miss": {
+			opts:     GetOptions{Bypass: true},
+			expected: "database",
+		},
+		"Refresh Hit": {
+			opts:           GetOptions{Refresh: true},
+			cached:         true,
+			expected:       "database",
+			expectedCached: "database",
+		},
+		"Refresh Miss": {
+			opts:           GetOptions{Refresh: true},
+			expected:       "database",
+			expectedCached: "database",
+		},
+		"No Fill Hit": {
+			opts:           GetOptions{NoFill: true},
+			cached:         true,
+			expected:       "cached",
+			expectedCached: "cached",
+		},
+		"No Fill Miss": {
+			opts:     GetOptions{NoFill: true},
+			expected: "database",
+		},
+		"Refresh without Fill": {
+			opts:           GetOptions{Refresh: true, NoFill: true},
+			cached:         true,
+			expected:       "database",
+			expectedCached: "cached",
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, backing, _ := setupStale(t, Config{})
+			_ = backing.Set("key", []byte("database"))
+			if test.cached {
+				_ = db.cache.Set("key", []byte("cached"))
+			}
+
+			data, err := db.GetWithOptions("key", test.opts)
+			if err != nil || string(data) != test.expected {
+				t.Errorf("GetWithOptions() returned %s - %v, expected %s", data, err, test.expected)
+			}
+
+			cached, err := db.cache.Get("key")
+			if test.expectedCached == "" {
+				if !errors.Is(err, hord.ErrNil) {
+					t.Errorf("Expected the cache to not be filled, got %s - %v", cached, err)
+				}
+				return
+			}
+			if string(cached) != test.expectedCached {
+				t.Errorf("Unexpected cached value - got %s - %v, expected %s", cached, err, test.expectedCached)
+			}
+		})
+	}
+}
+
+func TestGetWithOptionsRefreshRemovesMissing(t *testing.T) {
+	db, _, _ := setupStale(t, Config{})
+	_ = db.cache.Set("key", []byte("cached"))
+
+	if _, err := db.GetWithOptions("key", GetOptions{Refresh: true}); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("GetWithOptions() returned error: %v, expected %s", err, hord.ErrNil)
+	}
+
+	if _, err := db.cache.Get("key"); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("Expected missing key to be removed from the cache, got %v", err)
+	}
+}
+
+func TestGetWithOptionsNoFillStale(t *testing.T) {
+	db, backing, _ := setupStale(t, Config{SoftTTL: 20 * time.Millisecond, StaleWhileRevalidate: true})
+	_ = backing.Set("key", []byte("old"))
+	_, _ = db.Get("key")
+
+	<-time.After(30 * time.Millisecond)
+	_ = backing.Set("key", []byte("new"))
+
+	// Stale values are read from the database rather than served and revalidated
+	data, err := db.GetWithOptions("key", GetOptions{NoFill: true})
+	if err != nil || string(data) != "new" {
+		t.Errorf("GetWithOptions() returned %s - %v, expected new", data, err)
+	}
+
+	db.wg.Wait()
+	cached, _ := db.cache.Get("key")
+	if value, _ := db.decode(cached); string(value) != "old" {
+		t.Errorf("Expected the stale value to remain cached, got %s", value)
+	}
+}
+
+func TestGetWithOptionsNotDialed(t *testing.T) {
+	var db *Lookaside
+	if _, err := db.GetWithOptions("key", GetOptions{Bypass: true}); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("GetWithOptions() returned error: %v, expected %s", err, hord.ErrNoDial)
+	}
+}
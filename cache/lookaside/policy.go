@@ -0,0 +1,81 @@
+package lookaside
+
+import (
+	"errors"
+	"path"
+	"time"
+
+	"github.com/tarmac-project/hord/cache/admission"
+)
+
+// WritePolicy defines how Set updates the cache for keys matching a Policy.
+type WritePolicy string
+
+const (
+	// Write stores the new value within the cache. This is the default.
+	Write WritePolicy = "write"
+
+	// Invalidate removes the key from the cache, so the next Get fills it from the database.
+	Invalidate WritePolicy = "invalidate"
+)
+
+// Forever is a Policy TTL for values that never become stale.
+const Forever time.Duration = -1
+
+// Policy overrides the caching behavior for keys matching a pattern.
+type Policy struct {
+	// Pattern matches keys using the syntax of path.Match, for example "user:*". As with path.Match, * and ? do not
+	// match /, so keys with / need a pattern per level, for example "users/*/profile".
+	Pattern string
+
+	// TTL is the SoftTTL of matching keys. Default is 0, which uses the SoftTTL of Config. Forever disables
+	// staleness tracking for matching keys.
+	TTL time.Duration
+
+	// Admission decides which values of matching keys are stored within the cache. Default is nil, which uses the
+	// Admission policy of Config.
+	Admission admission.Policy
+
+	// WritePolicy defines how Set updates the cache for matching keys. Default is Write.
+	WritePolicy WritePolicy
+
+	// Bypass never caches matching keys, reading and writing them directly to and from the database.
+	Bypass bool
+}
+
+var (
+	// ErrInvalidPolicy is returned by Dial when a Policy has an invalid pattern or an unrecognized WritePolicy.
+	ErrInvalidPolicy = errors.New("invalid key policy")
+)
+
+// validate will return an error wrapping ErrInvalidPolicy if the Policy is invalid.
+func (p Policy) validate() error {
+	if _, err := path.Match(p.Pattern, ""); err != nil {
+		return errors.Join(ErrInvalidPolicy, err)
+	}
+
+	switch p.WritePolicy {
+	case "", Write, Invalidate:
+	default:
+		return ErrInvalidPolicy
+	}
+
+	return nil
+}
+
+// policy returns the first Policy matching the key, or the default Policy built from Config if none match.
+func (db *Lookaside) policy(key string) Policy {
+	for _, p := range db.policies {
+		if ok, _ := path.Match(p.Pattern, key); ok {
+			if p.TTL == 0 {
+				p.TTL = db.softTTL
+			}
+			if p.Admission == nil {
+				p.Admission = db.admission
+			}
+			return p
+		}
+	}
+
+	return Policy{TTL: db.softTTL, Admission: db.admission}
+}
@@ -0,0 +1,132 @@
+package lookaside
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/cache/admission"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+func TestPolicyValidation(t *testing.T) {
+	unitTests := map[string]struct {
+		policy        Policy
+		expectedError error
+	}{
+		"Valid":                {policy: Policy{Pattern: "user:*", TTL: time.Hour}},
+		"Valid Write Policy":   {policy: Policy{Pattern: "user:*", WritePolicy: Invalidate}},
+		"Invalid Pattern":      {policy: Policy{Pattern: "user:["}, expectedError: ErrInvalidPolicy},
+		"Invalid Write Policy": {policy: Policy{Pattern: "user:*", WritePolicy: "invalid"}, expectedError: ErrInvalidPolicy},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Dial(Config{
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+				Policies: []Policy{test.policy},
+			})
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Dial() returned error: %v, expected %v", err, test.expectedError)
+			}
+		})
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	db, backing, _ := setupStale(t, Config{
+		Policies: []Policy{
+			{Pattern: "user:*", TTL: 20 * time.Millisecond},
+			{Pattern: "lock:*", Bypass: true},
+			{Pattern: "config:*", TTL: Forever},
+			{Pattern: "session:*", WritePolicy: Invalidate},
+			{Pattern: "blob:*", Admission: admission.MaxSize(4)},
+		},
+		SoftTTL: time.Hour,
+	})
+
+	for _, k := range []string{"user:1", "lock:1", "config:1", "session:1", "blob:1", "other"} {
+		_ = backing.Set(k, []byte("data"))
+		if _, err := db.Get(k); err != nil {
+			t.Fatalf("Get() returned error: %s", err)
+		}
+	}
+
+	t.Run("TTL", func(t *testing.T) {
+		<-time.After(30 * time.Millisecond)
+		_ = backing.Set("user:1", []byte("new"))
+
+		// Matching keys become stale after the policy TTL
+		data, err := db.Get("user:1")
+		if err != nil || string(data) != "new" {
+			t.Errorf("Get() returned %s - %v, expected new", data, err)
+		}
+	})
+
+	t.Run("Forever", func(t *testing.T) {
+		cached, _ := db.cache.Get("config:1")
+		if _, storedAt := db.decode(cached); db.isStale(db.policy("config:1").TTL, storedAt.Add(-time.Hour*24)) {
+			t.Errorf("Expected keys cached forever to never become stale")
+		}
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		if p := db.policy("other"); p.TTL != time.Hour || p.Bypass || p.Admission != nil {
+			t.Errorf("Unexpected default policy - %+v", p)
+		}
+	})
+
+	t.Run("Bypass", func(t *testing.T) {
+		if _, err := db.cache.Get("lock:1"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected bypassed key to not be cached, got %v", err)
+		}
+
+		if err := db.Set("lock:1", []byte("held")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+		if _, err := db.cache.Get("lock:1"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected bypassed key to not be cached, got %v", err)
+		}
+
+		data, err := db.Get("lock:1")
+		if err != nil || string(data) != "held" {
+			t.Errorf("Get() returned %s - %v, expected held", data, err)
+		}
+
+		if n := db.Stats().Bypassed; n != 2 {
+			t.Errorf("Unexpected number of bypassed lookups - got %d, expected 2", n)
+		}
+	})
+
+	t.Run("Invalidate Write Policy", func(t *testing.T) {
+		if err := db.Set("session:1", []byte("new")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+		if _, err := db.cache.Get("session:1"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected key to be removed from the cache, got %v", err)
+		}
+
+		data, err := db.Get("session:1")
+		if err != nil || string(data) != "new" {
+			t.Errorf("Get() returned %s - %v, expected new", data, err)
+		}
+	})
+
+	t.Run("Admission", func(t *testing.T) {
+		if err := db.Set("blob:1", []byte("too large")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+		if _, err := db.cache.Get("blob:1"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected rejected value to not be cached, got %v", err)
+		}
+
+		if err := db.Set("other", []byte("too large")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+		if _, err := db.cache.Get("other"); err != nil {
+			t.Errorf("Expected value to be cached, got %v", err)
+		}
+	})
+}
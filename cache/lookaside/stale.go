@@ -0,0 +1,104 @@
+package lookaside
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// envelopeHeader identifies cached values stored with the time they were cached.
+var envelopeHeader = []byte("\x00hord-lookaside\x00")
+
+// envelopeSize is the size of the envelope header and timestamp preceding the cached value.
+var envelopeSize = len(envelopeHeader) + 8
+
+// encode will wrap the data with the current time when a SoftTTL is configured, either within Config or a Policy,
+// otherwise the data is returned as-is.
+func (db *Lookaside) encode(data []byte) []byte {
+	if !db.envelope {
+		return data
+	}
+
+	b := make([]byte, envelopeSize, envelopeSize+len(data))
+	copy(b, envelopeHeader)
+	binary.BigEndian.PutUint64(b[len(envelopeHeader):], uint64(time.Now().UnixNano()))
+	return append(b, data...)
+}
+
+// decode will unwrap cached data, returning the value and the time it was cached. Values cached without an envelope
+// return a zero time.
+func (db *Lookaside) decode(data []byte) ([]byte, time.Time) {
+	if len(data) < envelopeSize || !bytes.HasPrefix(data, envelopeHeader) {
+		return data, time.Time{}
+	}
+
+	storedAt := int64(binary.BigEndian.Uint64(data[len(envelopeHeader):envelopeSize]))
+	return data[envelopeSize:], time.Unix(0, storedAt)
+}
+
+// isStale returns true if data cached at storedAt has exceeded the ttl. Data cached without a timestamp is never stale.
+func (db *Lookaside) isStale(ttl time.Duration, storedAt time.Time) bool {
+	return ttl > 0 && !storedAt.IsZero() && time.Since(storedAt) >= ttl
+}
+
+// getStale will handle a Get for a stale cached value, either serving the stale value while refreshing it in the
+// background or refreshing it from the database and falling back to the stale value on error. With noFill, the value
+// is fetched from the database without refreshing the cache.
+func (db *Lookaside) getStale(key string, stale []byte, noFill bool) ([]byte, error) {
+	if db.staleWhileRevalidate && !noFill {
+		db.revalidate(key)
+		db.hit(stale)
+		return stale, ErrStale
+	}
+
+	fetch := db.refresh
+	if noFill {
+		fetch = db.data.Get
+	}
+
+	data, err := fetch(key)
+	if err != nil && db.serveStaleOnError && !errors.Is(err, hord.ErrNil) && !errors.Is(err, hord.ErrCacheError) {
+		db.hit(stale)
+		return stale, fmt.Errorf("%w: %w", ErrStale, err)
+	}
+
+	return db.miss(data, err)
+}
+
+// refresh will fetch the data from the data database and store it in the cache. Keys no longer found within the data
+// database are removed from the cache, unless replaced by a negative cache entry.
+func (db *Lookaside) refresh(key string) ([]byte, error) {
+	data, err := db.load(key)
+	if errors.Is(err, hord.ErrNil) && db.negativeTTL <= 0 {
+		_ = db.invalidate(key)
+	}
+
+	return data, err
+}
+
+// revalidate will refresh the key in the background unless a refresh for the key is already running.
+func (db *Lookaside) revalidate(key string) {
+	db.Lock()
+	defer db.Unlock()
+
+	if _, ok := db.revalidating[key]; ok {
+		return
+	}
+	db.revalidating[key] = struct{}{}
+
+	db.wg.Add(1)
+	go func() {
+		defer db.wg.Done()
+
+		// Failed refreshes leave the stale value in place so it can be served until a refresh succeeds
+		_, _ = db.refresh(key)
+
+		db.Lock()
+		delete(db.revalidating, key)
+		db.Unlock()
+	}()
+}
@@ -0,0 +1,189 @@
+package lookaside
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// setupStale is a helper function to create a Lookaside driver with a hashmap cache and a mock database that can be
+// switched into a failing state.
+func setupStale(t *testing.T, cfg Config) (*Lookaside, hord.Database, *bool) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	backing, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	failing := new(bool)
+	database, err := mock.Dial(mock.Config{
+		GetFunc: func(key string) ([]byte, error) {
+			if *failing {
+				return nil, ErrDatabaseTest
+			}
+			return backing.Get(key)
+		},
+		SetFunc:    backing.Set,
+		DeleteFunc: backing.Delete,
+		KeysFunc:   backing.Keys,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock database - %s", err)
+	}
+
+	cfg.Database = database
+	cfg.Cache = cache
+
+	db, err := Dial(cfg)
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	return db, backing, failing
+}
+
+func TestEnvelope(t *testing.T) {
+	db := &Lookaside{softTTL: time.Minute, envelope: true}
+
+	value, storedAt := db.decode(db.encode([]byte("data")))
+	if string(value) != "data" {
+		t.Errorf("Unexpected decoded value - %s", value)
+	}
+	if time.Since(storedAt) > time.Second {
+		t.Errorf("Unexpected stored at time - %s", storedAt)
+	}
+
+	value, storedAt = db.decode([]byte("raw"))
+	if string(value) != "raw" || !storedAt.IsZero() {
+		t.Errorf("Expected raw values to decode as-is - %s %s", value, storedAt)
+	}
+
+	if db.isStale(db.softTTL, storedAt) {
+		t.Errorf("Values without a timestamp should never be stale")
+	}
+
+	db.envelope = false
+	if string(db.encode([]byte("data"))) != "data" {
+		t.Errorf("Expected values to be stored as-is without a SoftTTL")
+	}
+}
+
+func TestSoftTTL(t *testing.T) {
+	db, backing, _ := setupStale(t, Config{SoftTTL: 20 * time.Millisecond})
+
+	if err := db.Set("key", []byte("v1")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+	_ = backing.Set("key", []byte("v2"))
+
+	data, err := db.Get("key")
+	if err != nil || string(data) != "v1" {
+		t.Errorf("Get() returned %s - %v, expected fresh v1", data, err)
+	}
+
+	<-time.After(30 * time.Millisecond)
+
+	data, err = db.Get("key")
+	if err != nil || string(data) != "v2" {
+		t.Errorf("Get() returned %s - %v, expected refreshed v2", data, err)
+	}
+
+	t.Run("Deleted From Database", func(t *testing.T) {
+		<-time.After(30 * time.Millisecond)
+		_ = backing.Delete("key")
+
+		_, err := db.Get("key")
+		if !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+
+		if _, err := db.GetCache().Get("key"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected stale value to be removed from the cache, got %v", err)
+		}
+	})
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	db, backing, _ := setupStale(t, Config{SoftTTL: 20 * time.Millisecond, StaleWhileRevalidate: true})
+
+	_ = db.Set("key", []byte("v1"))
+	_ = backing.Set("key", []byte("v2"))
+
+	<-time.After(30 * time.Millisecond)
+
+	data, err := db.Get("key")
+	if !errors.Is(err, ErrStale) {
+		t.Errorf("Get() returned error: %v, expected %s", err, ErrStale)
+	}
+	if string(data) != "v1" {
+		t.Errorf("Get() returned %s, expected stale v1", data)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err = db.Get("key")
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for background refresh - %v", err)
+		}
+		<-time.After(5 * time.Millisecond)
+	}
+
+	if string(data) != "v2" {
+		t.Errorf("Get() returned %s, expected refreshed v2", data)
+	}
+
+	db.Close()
+}
+
+func TestServeStaleOnError(t *testing.T) {
+	unitTests := map[string]struct {
+		serveStale    bool
+		expectedError error
+		expectedData  string
+	}{
+		"Serve Stale": {
+			serveStale:    true,
+			expectedError: ErrStale,
+			expectedData:  "v1",
+		},
+		"Serve Stale Includes Cause": {
+			serveStale:    true,
+			expectedError: ErrDatabaseTest,
+			expectedData:  "v1",
+		},
+		"Disabled": {
+			serveStale:    false,
+			expectedError: ErrDatabaseTest,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, _, failing := setupStale(t, Config{SoftTTL: 10 * time.Millisecond, ServeStaleOnError: test.serveStale})
+
+			_ = db.Set("key", []byte("v1"))
+			*failing = true
+
+			<-time.After(20 * time.Millisecond)
+
+			data, err := db.Get("key")
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Get() returned error: %v, expected %s", err, test.expectedError)
+			}
+			if string(data) != test.expectedData {
+				t.Errorf("Get() returned %s, expected %s", data, test.expectedData)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package lookaside
+
+import (
+	"sync/atomic"
+)
+
+// Stats provides counters describing cache activity.
+type Stats struct {
+	// Hits is the number of lookups answered with a value from the cache, including stale values.
+	Hits uint64
+
+	// Misses is the number of lookups answered from the database.
+	Misses uint64
+
+	// Fills is the number of values fetched from the database and stored within the cache.
+	Fills uint64
+
+	// FillErrors is the number of values fetched from the database that failed to be stored within the cache.
+	FillErrors uint64
+
+	// Invalidations is the number of keys removed from the cache.
+	Invalidations uint64
+
+	// CacheBytes is the number of bytes returned to lookups from the cache.
+	CacheBytes uint64
+
+	// DatabaseBytes is the number of bytes returned to lookups from the database.
+	DatabaseBytes uint64
+
+	// Coalesced is the number of calls that shared an in-flight database fetch instead of making their own.
+	Coalesced uint64
+
+	// NegativeHits is the number of lookups answered with hord.ErrNil from a negative cache entry.
+	NegativeHits uint64
+
+	// FilterRejects is the number of lookups answered with hord.ErrNil by the membership filter.
+	FilterRejects uint64
+
+	// FilterRebuilds is the number of times the membership filter has been built.
+	FilterRebuilds uint64
+
+	// AdmissionRejects is the number of values not stored within the cache because the Admission policy rejected them.
+	AdmissionRejects uint64
+
+	// Bypassed is the number of lookups answered directly from the database without the cache, either for keys
+	// matching a Policy with Bypass or requested by GetOptions with Bypass, or Refresh and NoFill.
+	Bypassed uint64
+
+	// Degradations is the number of times the cache has been degraded after failing.
+	Degradations uint64
+
+	// Recoveries is the number of times the cache has been used again after recovering.
+	Recoveries uint64
+
+	// DegradedReads is the number of lookups answered directly from the database while the cache was degraded.
+	DegradedReads uint64
+}
+
+// counters holds the cache activity counters, updated concurrently.
+type counters struct {
+	hits           atomic.Uint64
+	misses         atomic.Uint64
+	fills          atomic.Uint64
+	fillErrors     atomic.Uint64
+	invalidations  atomic.Uint64
+	cacheBytes     atomic.Uint64
+	databaseBytes  atomic.Uint64
+	coalesced      atomic.Uint64
+	negativeHits   atomic.Uint64
+	filterRejected atomic.Uint64
+	filterRebuilds atomic.Uint64
+	admitRejected  atomic.Uint64
+	bypassed       atomic.Uint64
+	degradations   atomic.Uint64
+	recoveries     atomic.Uint64
+	degradedReads  atomic.Uint64
+}
+
+// HitRatio returns the fraction of lookups answered from the cache, Hits / (Hits + Misses). Lookups answered by
+// negative cache entries or the membership filter are not included. A ratio of 0 is returned before any lookups.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Stats returns a snapshot of the cache activity counters.
+func (db *Lookaside) Stats() Stats {
+	return Stats{
+		Hits:             db.stats.hits.Load(),
+		Misses:           db.stats.misses.Load(),
+		Fills:            db.stats.fills.Load(),
+		FillErrors:       db.stats.fillErrors.Load(),
+		Invalidations:    db.stats.invalidations.Load(),
+		CacheBytes:       db.stats.cacheBytes.Load(),
+		DatabaseBytes:    db.stats.databaseBytes.Load(),
+		Coalesced:        db.stats.coalesced.Load(),
+		NegativeHits:     db.stats.negativeHits.Load(),
+		FilterRejects:    db.stats.filterRejected.Load(),
+		FilterRebuilds:   db.stats.filterRebuilds.Load(),
+		AdmissionRejects: db.stats.admitRejected.Load(),
+		Bypassed:         db.stats.bypassed.Load(),
+		Degradations:     db.stats.degradations.Load(),
+		Recoveries:       db.stats.recoveries.Load(),
+		DegradedReads:    db.stats.degradedReads.Load(),
+	}
+}
+
+// ResetStats will reset every cache activity counter to zero. Counters updated while resetting may keep the update.
+func (db *Lookaside) ResetStats() {
+	for _, c := range []*atomic.Uint64{
+		&db.stats.hits, &db.stats.misses, &db.stats.fills, &db.stats.fillErrors, &db.stats.invalidations,
+		&db.stats.cacheBytes, &db.stats.databaseBytes, &db.stats.coalesced, &db.stats.negativeHits,
+		&db.stats.filterRejected, &db.stats.filterRebuilds, &db.stats.admitRejected, &db.stats.bypassed,
+		&db.stats.degradations, &db.stats.recoveries, &db.stats.degradedReads,
+	} {
+		c.Store(0)
+	}
+}
+
+// hit records a lookup answered with data from the cache.
+func (db *Lookaside) hit(data []byte) {
+	db.stats.hits.Add(1)
+	db.stats.cacheBytes.Add(uint64(len(data)))
+}
+
+// miss records a lookup answered from the database, returning the result of the lookup as-is.
+func (db *Lookaside) miss(data []byte, err error) ([]byte, error) {
+	db.stats.misses.Add(1)
+	db.stats.databaseBytes.Add(uint64(len(data)))
+	return data, err
+}
+
+// invalidate will remove the key from the cache.
+func (db *Lookaside) invalidate(key string) error {
+	if err := db.cache.Delete(key); err != nil {
+		return err
+	}
+
+	db.stats.invalidations.Add(1)
+	return nil
+}
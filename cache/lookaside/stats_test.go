@@ -0,0 +1,113 @@
+package lookaside
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+func TestStats(t *testing.T) {
+	db, backing, _ := setupStale(t, Config{})
+	_ = backing.Set("key", []byte("data"))
+
+	// Miss and fill, followed by two hits
+	for i := 0; i < 3; i++ {
+		if _, err := db.Get("key"); err != nil {
+			t.Fatalf("Get() returned error: %s", err)
+		}
+	}
+	_, _ = db.Get("missing")
+
+	if err := db.Delete("key"); err != nil {
+		t.Fatalf("Delete() returned error: %s", err)
+	}
+
+	expected := Stats{
+		Hits:          2,
+		Misses:        2,
+		Fills:         1,
+		Invalidations: 1,
+		CacheBytes:    8,
+		DatabaseBytes: 4,
+	}
+	if s := db.Stats(); s != expected {
+		t.Errorf("Unexpected stats - got %+v, expected %+v", s, expected)
+	}
+
+	if r := db.Stats().HitRatio(); r != 0.5 {
+		t.Errorf("Unexpected hit ratio - got %f, expected 0.5", r)
+	}
+
+	db.ResetStats()
+	if s := db.Stats(); s != (Stats{}) {
+		t.Errorf("Expected stats to be reset - got %+v", s)
+	}
+
+	if r := db.Stats().HitRatio(); r != 0 {
+		t.Errorf("Unexpected hit ratio - got %f, expected 0", r)
+	}
+}
+
+func TestStatsFillErrors(t *testing.T) {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+	_ = database.Set("key", []byte("data"))
+
+	cache, err := mock.Dial(mock.Config{
+		GetFunc: func(_ string) ([]byte, error) {
+			return nil, hord.ErrNil
+		},
+		SetFunc: func(_ string, _ []byte) error {
+			return ErrCacheTest
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock cache - %s", err)
+	}
+
+	db, err := Dial(Config{Database: database, Cache: cache})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+
+	_, _ = db.Get("key")
+
+	if s := db.Stats(); s.Misses != 1 || s.Fills != 0 || s.FillErrors != 1 || s.DatabaseBytes != 4 {
+		t.Errorf("Unexpected stats - %+v", s)
+	}
+}
+
+func TestStatsConcurrency(t *testing.T) {
+	db, backing, _ := setupStale(t, Config{})
+	for i := 0; i < 10; i++ {
+		_ = backing.Set(fmt.Sprintf("key-%d", i), []byte("data"))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, _ = db.Get(fmt.Sprintf("key-%d", (i+j)%10))
+				if j%25 == 0 {
+					db.ResetStats()
+				}
+				_ = db.Stats()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	db.ResetStats()
+	_, _ = db.Get("key-0")
+	if s := db.Stats(); s.Hits+s.Misses != 1 {
+		t.Errorf("Unexpected stats after reset - %+v", s)
+	}
+}
@@ -0,0 +1,236 @@
+package lookaside
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/cache/keymap"
+)
+
+// Issue describes the type of inconsistency found between the cache and the database.
+type Issue string
+
+const (
+	// Mismatch is reported when a cached value differs from the value within the database.
+	Mismatch Issue = "mismatch"
+
+	// Orphan is reported when a cached value is not found within the database.
+	Orphan Issue = "orphan"
+
+	// Hidden is reported when a negative cache entry hides a value found within the database.
+	Hidden Issue = "hidden"
+)
+
+// VerifyConfig provides the configuration options for Verify().
+type VerifyConfig struct {
+	// Repair removes inconsistent entries from the cache, so the next Get fills them from the database.
+	Repair bool
+
+	// Rate is the maximum number of keys checked per second, limiting the load placed on the cache and the database.
+	// Default is 0, which checks keys without limit.
+	Rate int
+
+	// OnInconsistency is called for every inconsistent entry found.
+	OnInconsistency func(Inconsistency)
+}
+
+// Inconsistency describes an entry within the cache that does not match the database.
+type Inconsistency struct {
+	// Key is the inconsistent key.
+	Key string
+
+	// Issue is the type of inconsistency.
+	Issue Issue
+
+	// Repaired is true if the entry was removed from the cache.
+	Repaired bool
+}
+
+// Report describes the result of a verification.
+type Report struct {
+	// Checked is the number of cache keys checked.
+	Checked int
+
+	// Unchecked is the number of cache keys that could not be checked because the original key could not be recovered,
+	// for example keys hashed by keymap.SHA256 that are no longer within its index.
+	Unchecked int
+
+	// Mismatched is the number of cached values that differ from the database.
+	Mismatched int
+
+	// Orphaned is the number of cached values not found within the database.
+	Orphaned int
+
+	// Hidden is the number of negative cache entries hiding values found within the database.
+	Hidden int
+
+	// Repaired is the number of inconsistent entries removed from the cache.
+	Repaired int
+
+	// Failed is the number of keys that could not be checked or repaired.
+	Failed int
+}
+
+var (
+	// ErrVerify is returned when keys could not be checked or repaired.
+	ErrVerify = errors.New("unable to verify cache")
+
+	// ErrVerifyInterrupted is returned when the cache is closed while being verified.
+	ErrVerifyInterrupted = errors.New("cache closed during verification")
+)
+
+// Inconsistent returns the number of inconsistent entries found.
+func (r Report) Inconsistent() int {
+	return r.Mismatched + r.Orphaned + r.Hidden
+}
+
+// Verify will compare every entry within the cache against the database, reporting and optionally repairing
+// inconsistencies. Stale values are compared by their value, while negative cache entries are consistent while the key
+// is missing from the database. Entries that appear inconsistent are checked a second time so values changed during
+// verification are not reported.
+//
+// When the cache maps keys with a keymap.KeyMap, cache keys whose original key cannot be recovered are counted as
+// Unchecked rather than compared.
+//
+// Errors listing the cache keys are returned as-is, while keys that fail to be checked or repaired return an error
+// wrapping ErrVerify and the first failure.
+func (db *Lookaside) Verify(cfg VerifyConfig) (Report, error) {
+	if db == nil || db.data == nil || db.cache == nil {
+		return Report{}, hord.ErrNoDial
+	}
+
+	var r Report
+	keys, unchecked, err := db.verifyKeys()
+	if err != nil {
+		return Report{}, err
+	}
+	r.Unchecked = unchecked
+
+	var limit <-chan time.Time
+	if cfg.Rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(cfg.Rate))
+		defer ticker.Stop()
+		limit = ticker.C
+	}
+
+	var firstErr error
+	for i, key := range keys {
+		if limit != nil && i > 0 {
+			select {
+			case <-limit:
+			case <-db.done:
+				return r, ErrVerifyInterrupted
+			}
+		}
+
+		r.Checked++
+		issue, err := db.check(key)
+		if err == nil && issue != "" {
+			// Check again to rule out values changed while being compared
+			issue, err = db.check(key)
+		}
+		if err == nil && issue != "" {
+			inc := Inconsistency{Key: key, Issue: issue}
+			if cfg.Repair {
+				err = db.invalidate(key)
+				inc.Repaired = err == nil
+			}
+
+			switch issue {
+			case Mismatch:
+				r.Mismatched++
+			case Orphan:
+				r.Orphaned++
+			case Hidden:
+				r.Hidden++
+			}
+			if inc.Repaired {
+				r.Repaired++
+			}
+
+			if cfg.OnInconsistency != nil {
+				cfg.OnInconsistency(inc)
+			}
+		}
+
+		if err != nil {
+			r.Failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%w: key %s: %w", ErrVerify, key, err)
+			}
+		}
+	}
+
+	return r, firstErr
+}
+
+// mappedKeys is implemented by caches that map keys, such as keymap.KeyMap, to report cache keys whose original key
+// cannot be recovered.
+type mappedKeys interface {
+	MappedKeys() ([]keymap.MappedKey, error)
+}
+
+// verifyKeys will return the keys of the cache to verify, along with the number of cache keys whose original key
+// cannot be recovered.
+func (db *Lookaside) verifyKeys() ([]string, int, error) {
+	m, ok := db.cache.(mappedKeys)
+	if !ok {
+		keys, err := db.cache.Keys()
+		return keys, 0, err
+	}
+
+	mapped, err := m.MappedKeys()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var unchecked int
+	keys := make([]string, 0, len(mapped))
+	for _, k := range mapped {
+		if k.Key == "" {
+			unchecked++
+			continue
+		}
+		keys = append(keys, k.Key)
+	}
+
+	return keys, unchecked, nil
+}
+
+// check will compare the cached entry for the key against the database, returning the inconsistency found or an
+// empty Issue if the entry is consistent. Keys removed from the cache since being listed are consistent.
+func (db *Lookaside) check(key string) (Issue, error) {
+	cached, err := db.cache.Get(key)
+	if errors.Is(err, hord.ErrNil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	data, err := db.data.Get(key)
+	if err != nil && !errors.Is(err, hord.ErrNil) {
+		return "", err
+	}
+	missing := errors.Is(err, hord.ErrNil)
+
+	if _, ok := isNegative(cached); ok {
+		if missing {
+			return "", nil
+		}
+		return Hidden, nil
+	}
+
+	if missing {
+		return Orphan, nil
+	}
+
+	if value, _ := db.decode(cached); !bytes.Equal(value, data) {
+		return Mismatch, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,218 @@
+package lookaside
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/cache/keymap"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// setupVerify is a helper function creating a lookaside cache holding consistent, mismatched, orphaned, stale and
+// negative entries.
+func setupVerify(t *testing.T) (*Lookaside, hord.Database) {
+	db, backing, _ := setupStale(t, Config{SoftTTL: time.Minute, NegativeTTL: time.Minute})
+
+	for _, k := range []string{"consistent", "mismatch", "orphan", "stale"} {
+		if err := db.Set(k, []byte("data")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+	}
+
+	// Negative entries for a key still missing and a key since added to the database
+	_, _ = db.Get("missing")
+	_, _ = db.Get("hidden")
+	_ = backing.Set("hidden", []byte("data"))
+
+	_ = backing.Set("mismatch", []byte("changed"))
+	_ = backing.Delete("orphan")
+
+	// Stale values are compared by their value
+	stale := append([]byte{}, envelopeHeader...)
+	stale = binary.BigEndian.AppendUint64(stale, uint64(time.Now().Add(-time.Hour).UnixNano()))
+	_ = db.cache.Set("stale", append(stale, []byte("data")...))
+
+	return db, backing
+}
+
+func TestVerify(t *testing.T) {
+	unitTests := map[string]struct {
+		repair bool
+	}{
+		"Report":          {},
+		"Report & Repair": {repair: true},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, _ := setupVerify(t)
+
+			found := map[string]Issue{}
+			r, err := db.Verify(VerifyConfig{
+				Repair: test.repair,
+				OnInconsistency: func(inc Inconsistency) {
+					found[inc.Key] = inc.Issue
+					if inc.Repaired != test.repair {
+						t.Errorf("Unexpected repair of %s - got %t, expected %t", inc.Key, inc.Repaired, test.repair)
+					}
+				},
+			})
+			if err != nil {
+				t.Fatalf("Verify() returned error: %s", err)
+			}
+
+			expected := map[string]Issue{"mismatch": Mismatch, "orphan": Orphan, "hidden": Hidden}
+			if len(found) != len(expected) {
+				t.Errorf("Unexpected inconsistencies - got %v, expected %v", found, expected)
+			}
+			for k, issue := range expected {
+				if found[k] != issue {
+					t.Errorf("Unexpected inconsistency for %s - got %q, expected %q", k, found[k], issue)
+				}
+			}
+
+			if r.Checked != 6 || r.Mismatched != 1 || r.Orphaned != 1 || r.Hidden != 1 || r.Inconsistent() != 3 {
+				t.Errorf("Unexpected report - %+v", r)
+			}
+
+			keys, _ := db.CacheKeys()
+			sort.Strings(keys)
+			if test.repair {
+				if r.Repaired != 3 || len(keys) != 3 {
+					t.Errorf("Expected inconsistent entries to be removed - report %+v, cached keys %v", r, keys)
+				}
+
+				// Repaired keys are filled from the database on the next Get
+				data, err := db.Get("mismatch")
+				if err != nil || string(data) != "changed" {
+					t.Errorf("Get() returned %s - %v, expected changed", data, err)
+				}
+				return
+			}
+
+			if r.Repaired != 0 || len(keys) != 6 {
+				t.Errorf("Expected no entries to be removed - report %+v, cached keys %v", r, keys)
+			}
+		})
+	}
+}
+
+func TestVerifyRateLimit(t *testing.T) {
+	db, _ := setupVerify(t)
+
+	start := time.Now()
+	r, err := db.Verify(VerifyConfig{Rate: 100})
+	if err != nil {
+		t.Fatalf("Verify() returned error: %s", err)
+	}
+
+	// Six keys at 100 per second wait at least 50ms between the first and last key
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("Verification was not rate limited - took %s", d)
+	}
+
+	if r.Checked != 6 {
+		t.Errorf("Unexpected report - %+v", r)
+	}
+
+	t.Run("Interrupted by Close", func(t *testing.T) {
+		db, _ := setupVerify(t)
+		go func() {
+			<-time.After(20 * time.Millisecond)
+			db.Close()
+		}()
+
+		r, err := db.Verify(VerifyConfig{Rate: 10})
+		if !errors.Is(err, ErrVerifyInterrupted) || r.Checked >= 6 {
+			t.Errorf("Verify() returned %+v - %v, expected %s", r, err, ErrVerifyInterrupted)
+		}
+	})
+}
+
+func TestVerifyMappedKeys(t *testing.T) {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	backing, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	// Hashed keys written by another instance cannot be recovered by this one
+	other, err := keymap.Dial(keymap.Config{Database: backing, Mapper: keymap.SHA256("app")})
+	if err != nil {
+		t.Fatalf("keymap.Dial() returned error: %s", err)
+	}
+	_ = other.Set("unknown", []byte("data"))
+
+	cache, err := keymap.Dial(keymap.Config{Database: backing, Mapper: keymap.SHA256("app")})
+	if err != nil {
+		t.Fatalf("keymap.Dial() returned error: %s", err)
+	}
+
+	db, err := Dial(Config{Database: database, Cache: cache})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+
+	_ = db.Set("known", []byte("data"))
+	_ = database.Set("known", []byte("changed"))
+
+	r, err := db.Verify(VerifyConfig{})
+	if err != nil {
+		t.Fatalf("Verify() returned error: %s", err)
+	}
+
+	if r.Checked != 1 || r.Mismatched != 1 || r.Unchecked != 1 {
+		t.Errorf("Unexpected report - %+v", r)
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+	_ = cache.Set("key", []byte("data"))
+	_ = cache.Set("failing", []byte("data"))
+
+	database, err := mock.Dial(mock.Config{
+		GetFunc: func(key string) ([]byte, error) {
+			if key == "failing" {
+				return nil, ErrDatabaseTest
+			}
+			return []byte("data"), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock database - %s", err)
+	}
+
+	db, err := Dial(Config{Database: database, Cache: cache})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+
+	r, err := db.Verify(VerifyConfig{})
+	if !errors.Is(err, ErrVerify) || !errors.Is(err, ErrDatabaseTest) {
+		t.Errorf("Verify() returned error: %v, expected %s", err, ErrVerify)
+	}
+
+	if r.Checked != 2 || r.Failed != 1 || r.Inconsistent() != 0 {
+		t.Errorf("Unexpected report - %+v", r)
+	}
+
+	t.Run("Not Dialed", func(t *testing.T) {
+		var db *Lookaside
+		if _, err := db.Verify(VerifyConfig{}); !errors.Is(err, hord.ErrNoDial) {
+			t.Errorf("Verify() returned error: %v, expected %s", err, hord.ErrNoDial)
+		}
+	})
+}
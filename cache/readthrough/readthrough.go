@@ -0,0 +1,296 @@
+/*
+Package readthrough provides a Hord database driver for a read-through cache. To use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/cache/readthrough"
+	)
+
+With a read-through cache, callers only interact with the cache. When a key is not found within the cache, the value
+is fetched by a Loader and stored in the cache before being returned. By default, the Loader fetches values from the
+backing database, but a custom Loader can be provided to cache computed values or data from other sources.
+
+With the default Loader, Set and Delete write to the backing database before updating the cache. With a custom
+Loader, Set and Delete operate on the cache only, as the Loader remains the source of truth for values not found in the
+cache; a deleted key is loaded again by the next Get. Keys returns the keys of the backing database when one is
+provided, otherwise only the keys currently cached are known.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the cache.
+
+	// Handle database connection
+	var database hord.Database
+	...
+
+	// Handle cache connection
+	var cache hord.Database
+	...
+
+	var db hord.Database
+	db, err := readthrough.Dial(readthrough.Config{
+		Database: database,
+		Cache: 	  cache,
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the cache, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Handle cache connection
+	var cache hord.Database
+	cache, err := redis.Dial(redis.Config{})
+	if err != nil {
+		// Handle connection error
+	}
+
+	// Connect to the Cache database with a custom loader
+	db, err := readthrough.Dial(readthrough.Config{
+		Cache: cache,
+		Loader: func(key string) ([]byte, error) {
+			return render(key)
+		},
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+	// Retrieve a value, loading it on a cache miss
+	value, err := db.Get("key")
+	if err != nil {
+	    // Handle error
+	}
+*/
+package readthrough
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tarmac-project/hord"
+)
+
+// Loader fetches the value for a key that was not found within the cache. Loaders should return hord.ErrNil if no
+// value exists for the key.
+type Loader func(key string) ([]byte, error)
+
+// Config provides the configuration options for the ReadThrough driver.
+type Config struct {
+	// Database is the backing database used by the default Loader. Database is optional when a Loader is provided.
+	Database hord.Database
+
+	// Cache is the database callers interact with.
+	Cache hord.Database
+
+	// Loader fetches values on a cache miss. Default is the Get function of Database.
+	Loader Loader
+}
+
+// ReadThrough is used to store data in a read-through caching pattern. It also satisfies the Hord database interface.
+type ReadThrough struct {
+	data   hord.Database
+	cache  hord.Database
+	loader Loader
+
+	// writeData is true when the default Loader is used, so writes reach the backing database.
+	writeData bool
+}
+
+// Dial will create a new ReadThrough driver using the provided Config. It will return an error if the Cache is nil or
+// if neither a Database nor a Loader is provided.
+func Dial(cfg Config) (*ReadThrough, error) {
+	if cfg.Cache == nil || (cfg.Database == nil && cfg.Loader == nil) {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	db := &ReadThrough{
+		data:   cfg.Database,
+		cache:  cfg.Cache,
+		loader: cfg.Loader,
+	}
+
+	if db.loader == nil {
+		db.loader = cfg.Database.Get
+		db.writeData = true
+	}
+
+	return db, nil
+}
+
+// Setup will run the Setup function for both the database, if provided, and the cache.
+func (db *ReadThrough) Setup() error {
+	if db == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if db.data != nil {
+		if err := db.data.Setup(); err != nil {
+			return err
+		}
+	}
+
+	return db.cache.Setup()
+}
+
+// HealthCheck will run the HealthCheck function for both the database, if provided, and the cache.
+func (db *ReadThrough) HealthCheck() error {
+	if db == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if db.data != nil {
+		if err := db.data.HealthCheck(); err != nil {
+			return errors.Join(hord.ErrHealthCheckFailure, err)
+		}
+	}
+
+	if err := db.cache.HealthCheck(); err != nil {
+		return errors.Join(hord.ErrHealthCheckFailure, err)
+	}
+
+	return nil
+}
+
+// Get will get the data from the cache. If not found, the data is fetched using the Loader and stored in the cache.
+// If the loaded data cannot be stored in the cache, the data is returned along with an error wrapping
+// hord.ErrCacheError.
+func (db *ReadThrough) Get(key string) ([]byte, error) {
+	if db == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := hord.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	// Check the cache first
+	data, err := db.cache.Get(key)
+	if (err != nil) && !errors.Is(err, hord.ErrNil) {
+		return nil, err
+	} else if !errors.Is(err, hord.ErrNil) {
+		return data, nil
+	}
+
+	// Load the missing value
+	data, err = db.loader(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, hord.ErrNil
+	}
+
+	// Update the cache
+	err = db.cache.Set(key, data)
+	if err != nil {
+		return data, fmt.Errorf("%w: %w", hord.ErrCacheError, err)
+	}
+
+	return data, nil
+}
+
+// Set will store the data within the backing database when the default Loader is used, and then within the cache.
+// With a custom Loader, the data is only stored within the cache.
+func (db *ReadThrough) Set(key string, data []byte) error {
+	if db == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := hord.ValidKey(key); err != nil {
+		return err
+	}
+
+	if err := hord.ValidData(data); err != nil {
+		return err
+	}
+
+	if db.writeData {
+		if err := db.data.Set(key, data); err != nil {
+			return err
+		}
+	}
+
+	return db.cache.Set(key, data)
+}
+
+// Delete will delete the data from the backing database when the default Loader is used, and then from the cache.
+// With a custom Loader, the data is only deleted from the cache and the next Get for the key will fetch the value
+// using the Loader.
+func (db *ReadThrough) Delete(key string) error {
+	if db == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := hord.ValidKey(key); err != nil {
+		return err
+	}
+
+	if db.writeData {
+		if err := db.data.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return db.cache.Delete(key)
+}
+
+// Keys will return the keys from the backing database. With a custom Loader and no Database, the keys the Loader can
+// produce are unknown, so the keys from the cache are returned instead.
+func (db *ReadThrough) Keys() ([]string, error) {
+	if db == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if db.data != nil {
+		return db.data.Keys()
+	}
+
+	return db.cache.Keys()
+}
+
+// CacheKeys will return the keys from the cache.
+func (db *ReadThrough) CacheKeys() ([]string, error) {
+	if db == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.cache.Keys()
+}
+
+// GetCache will return the cache database.
+func (db *ReadThrough) GetCache() hord.Database {
+	return db.cache
+}
+
+// GetDatabase will return the backing database, which may be nil when a custom Loader is used.
+func (db *ReadThrough) GetDatabase() hord.Database {
+	return db.data
+}
+
+// Close will close the connections to both the database, if provided, and the cache.
+func (db *ReadThrough) Close() {
+	if db != nil && db.cache != nil {
+		if db.data != nil {
+			db.data.Close()
+		}
+		db.cache.Close()
+	}
+}
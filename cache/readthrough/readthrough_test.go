@@ -0,0 +1,440 @@
+package readthrough
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// Test Errors used for testing purposes
+var (
+	ErrDatabaseTest = errors.New("database error")
+	ErrCacheTest    = errors.New("cache error")
+	ErrLoaderTest   = errors.New("loader error")
+)
+
+// setupCache is a helper function to create a new Cache driver using the provided database and cache Config.
+func setupCache(cacheConfig mock.Config, databaseConfig mock.Config) (*ReadThrough, error) {
+	database, err := mock.Dial(databaseConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := mock.Dial(cacheConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return Dial(Config{
+		Database: database,
+		Cache:    cache,
+	})
+}
+
+func TestDial(t *testing.T) {
+	unitTests := map[string]struct {
+		config        Config
+		expectedError error
+	}{
+		"No Config": {
+			config:        Config{},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"No Database or Loader": {
+			config: Config{
+				Cache: &mock.Database{},
+			},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"No Cache": {
+			config: Config{
+				Database: &mock.Database{},
+			},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"Loader without Database": {
+			config: Config{
+				Cache:  &mock.Database{},
+				Loader: func(_ string) ([]byte, error) { return nil, hord.ErrNil },
+			},
+			expectedError: nil,
+		},
+		"Happy Path": {
+			config: Config{
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+			},
+			expectedError: nil,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Dial(test.config)
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Dial(%v) returned error: %s, expected %s", test.config, err, test.expectedError)
+			}
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	unitTests := map[string]struct {
+		databaseError error
+		cacheError    error
+		expectedError error
+	}{
+		"Database Error": {
+			databaseError: ErrDatabaseTest,
+			expectedError: ErrDatabaseTest,
+		},
+		"Cache Error": {
+			cacheError:    ErrCacheTest,
+			expectedError: ErrCacheTest,
+		},
+		"Happy Path": {},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, err := setupCache(
+				mock.Config{SetupFunc: func() error { return test.cacheError }},
+				mock.Config{SetupFunc: func() error { return test.databaseError }},
+			)
+			if err != nil {
+				t.Fatalf("Failed to connect to database - %s", err)
+			}
+
+			err = db.Setup()
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Setup() returned error: %s, expected %s", err, test.expectedError)
+			}
+		})
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	unitTests := map[string]struct {
+		databaseError error
+		cacheError    error
+		expectedError error
+	}{
+		"Database Error": {
+			databaseError: ErrDatabaseTest,
+			expectedError: ErrDatabaseTest,
+		},
+		"Cache Error": {
+			cacheError:    ErrCacheTest,
+			expectedError: ErrCacheTest,
+		},
+		"Happy Path": {},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, err := setupCache(
+				mock.Config{HealthCheckFunc: func() error { return test.cacheError }},
+				mock.Config{HealthCheckFunc: func() error { return test.databaseError }},
+			)
+			if err != nil {
+				t.Fatalf("Failed to connect to database - %s", err)
+			}
+
+			err = db.HealthCheck()
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("HealthCheck() returned error: %s, expected %s", err, test.expectedError)
+			}
+			if test.expectedError != nil && !errors.Is(err, hord.ErrHealthCheckFailure) {
+				t.Errorf("HealthCheck() error should contain ErrHealthCheckFailure")
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	cacheConfig := mock.Config{
+		GetFunc: func(key string) ([]byte, error) {
+			switch key {
+			case "cache-hit":
+				return []byte("cache-data"), nil
+			case "cache-error":
+				return nil, ErrCacheTest
+			}
+			return nil, hord.ErrNil
+		},
+		SetFunc: func(key string, _ []byte) error {
+			if key == "cache-write-error" {
+				return ErrCacheTest
+			}
+			return nil
+		},
+	}
+	databaseConfig := mock.Config{
+		GetFunc: func(key string) ([]byte, error) {
+			switch key {
+			case "cache-miss", "cache-write-error":
+				return []byte("database-data"), nil
+			case "database-error":
+				return nil, ErrDatabaseTest
+			}
+			return nil, hord.ErrNil
+		},
+	}
+
+	unitTests := map[string]struct {
+		key           string
+		expectedError error
+		expectedData  []byte
+	}{
+		"Cache Hit": {
+			key:          "cache-hit",
+			expectedData: []byte("cache-data"),
+		},
+		"Cache Miss": {
+			key:          "cache-miss",
+			expectedData: []byte("database-data"),
+		},
+		"Cache Error": {
+			key:           "cache-error",
+			expectedError: ErrCacheTest,
+		},
+		"Cache Write Error": {
+			key:           "cache-write-error",
+			expectedError: hord.ErrCacheError,
+			expectedData:  []byte("database-data"),
+		},
+		"Database Error": {
+			key:           "database-error",
+			expectedError: ErrDatabaseTest,
+		},
+		"Missing Key": {
+			key:           "missing",
+			expectedError: hord.ErrNil,
+		},
+		"Invalid Key": {
+			key:           "",
+			expectedError: hord.ErrInvalidKey,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, err := setupCache(cacheConfig, databaseConfig)
+			if err != nil {
+				t.Fatalf("Failed to connect to database - %s", err)
+			}
+
+			data, err := db.Get(test.key)
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Get(%s) returned error: %s, expected %s", test.key, err, test.expectedError)
+			}
+			if string(data) != string(test.expectedData) {
+				t.Errorf("Get(%s) returned data: %s, expected %s", test.key, data, test.expectedData)
+			}
+		})
+	}
+}
+
+func TestLoader(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	calls := 0
+	db, err := Dial(Config{
+		Cache: cache,
+		Loader: func(key string) ([]byte, error) {
+			calls++
+			switch key {
+			case "loader-error":
+				return nil, ErrLoaderTest
+			case "empty":
+				return nil, nil
+			}
+			return []byte("computed-" + key), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	t.Run("Load on Miss", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			data, err := db.Get("key")
+			if err != nil {
+				t.Fatalf("Get() returned error: %s", err)
+			}
+			if string(data) != "computed-key" {
+				t.Errorf("Get() returned data: %s, expected computed-key", data)
+			}
+		}
+
+		if calls != 1 {
+			t.Errorf("Loader called %d times, expected 1", calls)
+		}
+	})
+
+	t.Run("Delete Reloads", func(t *testing.T) {
+		if err := db.Delete("key"); err != nil {
+			t.Fatalf("Delete() returned error: %s", err)
+		}
+
+		if _, err := db.Get("key"); err != nil {
+			t.Fatalf("Get() returned error: %s", err)
+		}
+
+		if calls != 2 {
+			t.Errorf("Loader called %d times, expected 2", calls)
+		}
+	})
+
+	t.Run("Set Overrides Loader", func(t *testing.T) {
+		if err := db.Set("set", []byte("stored")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		data, err := db.Get("set")
+		if err != nil || string(data) != "stored" {
+			t.Errorf("Get() returned %s - %v, expected stored", data, err)
+		}
+
+		if calls != 2 {
+			t.Errorf("Loader called %d times, expected 2", calls)
+		}
+	})
+
+	t.Run("Keys From Cache", func(t *testing.T) {
+		keys, err := db.Keys()
+		if err != nil || len(keys) != 2 {
+			t.Errorf("Keys() returned %v - %v, expected the cached keys", keys, err)
+		}
+	})
+
+	t.Run("Loader Error", func(t *testing.T) {
+		_, err := db.Get("loader-error")
+		if !errors.Is(err, ErrLoaderTest) {
+			t.Errorf("Get() returned error: %v, expected %s", err, ErrLoaderTest)
+		}
+
+		if _, err := cache.Get("loader-error"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected failed load to not be cached, got %v", err)
+		}
+	})
+
+	t.Run("Empty Value", func(t *testing.T) {
+		_, err := db.Get("empty")
+		if !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+	})
+}
+
+func TestWrites(t *testing.T) {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	db, err := Dial(Config{Database: database, Cache: cache})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	t.Run("Set Persists", func(t *testing.T) {
+		if err := db.Set("key", []byte("data")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		data, err := database.Get("key")
+		if err != nil || string(data) != "data" {
+			t.Errorf("Expected the value to be stored within the database, got %s - %v", data, err)
+		}
+	})
+
+	t.Run("Keys From Database", func(t *testing.T) {
+		_ = database.Set("uncached", []byte("data"))
+
+		keys, err := db.Keys()
+		if err != nil || len(keys) != 2 {
+			t.Errorf("Keys() returned %v - %v, expected the database keys", keys, err)
+		}
+
+		keys, err = db.CacheKeys()
+		if err != nil || len(keys) != 1 || keys[0] != "key" {
+			t.Errorf("CacheKeys() returned %v - %v, expected [key]", keys, err)
+		}
+		_ = database.Delete("uncached")
+	})
+
+	t.Run("Delete Persists", func(t *testing.T) {
+		if err := db.Delete("key"); err != nil {
+			t.Fatalf("Delete() returned error: %s", err)
+		}
+
+		if _, err := db.Get("key"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+	})
+
+	t.Run("Database Errors", func(t *testing.T) {
+		db, err := setupCache(mock.Config{}, mock.Config{
+			SetFunc:    func(_ string, _ []byte) error { return ErrDatabaseTest },
+			DeleteFunc: func(_ string) error { return ErrDatabaseTest },
+		})
+		if err != nil {
+			t.Fatalf("Failed to connect to database - %s", err)
+		}
+
+		if err := db.Set("key", []byte("data")); !errors.Is(err, ErrDatabaseTest) {
+			t.Errorf("Set() returned error: %v, expected %s", err, ErrDatabaseTest)
+		}
+		if err := db.Delete("key"); !errors.Is(err, ErrDatabaseTest) {
+			t.Errorf("Delete() returned error: %v, expected %s", err, ErrDatabaseTest)
+		}
+	})
+
+	t.Run("Invalid Keys", func(t *testing.T) {
+		if err := db.Set("", []byte("data")); !errors.Is(err, hord.ErrInvalidKey) {
+			t.Errorf("Set() returned error: %v, expected %s", err, hord.ErrInvalidKey)
+		}
+		if err := db.Set("key", nil); !errors.Is(err, hord.ErrInvalidData) {
+			t.Errorf("Set() returned error: %v, expected %s", err, hord.ErrInvalidData)
+		}
+		if err := db.Delete(""); !errors.Is(err, hord.ErrInvalidKey) {
+			t.Errorf("Delete() returned error: %v, expected %s", err, hord.ErrInvalidKey)
+		}
+	})
+}
+
+func TestNilReadThrough(t *testing.T) {
+	var db *ReadThrough
+
+	if err := db.Setup(); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("Setup() returned error: %s, expected %s", err, hord.ErrNoDial)
+	}
+	if err := db.HealthCheck(); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("HealthCheck() returned error: %s, expected %s", err, hord.ErrNoDial)
+	}
+	if _, err := db.Get("key"); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("Get() returned error: %s, expected %s", err, hord.ErrNoDial)
+	}
+	if err := db.Set("key", []byte("data")); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("Set() returned error: %s, expected %s", err, hord.ErrNoDial)
+	}
+	if err := db.Delete("key"); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("Delete() returned error: %s, expected %s", err, hord.ErrNoDial)
+	}
+	if _, err := db.Keys(); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("Keys() returned error: %s, expected %s", err, hord.ErrNoDial)
+	}
+	db.Close()
+}
@@ -0,0 +1,520 @@
+/*
+Package refreshahead provides a Hord database driver for a refresh-ahead cache. To use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/cache/refreshahead"
+	)
+
+A refresh-ahead cache behaves like a look-aside cache, but tracks when each key was loaded into the cache. Cached
+values older than the TTL are treated as expired and reloaded from the database. When a key is read within the
+RefreshWindow before its TTL elapses, the key is reloaded in the background by a pool of workers, so frequently read
+keys are refreshed before they expire and callers avoid the latency of a synchronous reload. Expired values are
+removed from the cache every TTL, so load times are only tracked for keys read or written within the last TTL.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the cache.
+
+	// Handle database connection
+	var database hord.Database
+	...
+
+	// Handle cache connection
+	var cache hord.Database
+	...
+
+	var db hord.Database
+	db, err := refreshahead.Dial(refreshahead.Config{
+		Database:      database,
+		Cache:         cache,
+		TTL:           5 * time.Minute,
+		RefreshWindow: time.Minute,
+		Workers:       4,
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the cache, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Set a value
+	err = db.Set("key", []byte("value"))
+	if err != nil {
+	    // Handle error
+	}
+
+	// Retrieve a value, refreshing it in the background if it is close to expiring
+	value, err := db.Get("key")
+	if err != nil {
+	    // Handle error
+	}
+*/
+package refreshahead
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+const (
+	// DefaultTTL is the default time a cached value is considered fresh.
+	DefaultTTL = 5 * time.Minute
+
+	// DefaultWorkers is the default number of background refresh workers.
+	DefaultWorkers = 4
+
+	// queueSize is the number of refreshes that can be waiting for a worker. Refreshes are dropped when the queue is
+	// full.
+	queueSize = 1024
+)
+
+// Config provides the configuration options for the RefreshAhead driver.
+type Config struct {
+	Database hord.Database
+	Cache    hord.Database
+
+	// TTL is the time a cached value is considered fresh. Default is 5 minutes.
+	TTL time.Duration
+
+	// RefreshWindow is the period before the TTL elapses in which reading a key triggers a background refresh.
+	// Default is one fifth of the TTL.
+	RefreshWindow time.Duration
+
+	// Workers is the number of background refresh workers. Default is 4.
+	Workers int
+}
+
+// RefreshAhead is used to store data in a refresh-ahead caching pattern. It also satisfies the Hord database interface.
+type RefreshAhead struct {
+	sync.Mutex
+
+	data    hord.Database
+	cache   hord.Database
+	ttl     time.Duration
+	window  time.Duration
+	workers int
+
+	// loaded holds the time each key was loaded into the cache, removed once the value expires.
+	loaded map[string]time.Time
+
+	// refreshing holds the keys waiting for, or being processed by, a refresh worker, along with the generation of
+	// the key when the refresh was scheduled.
+	refreshing map[string]uint64
+
+	// generation is incremented by every Set and Delete of a key being refreshed, so refreshes racing a write do not
+	// store the value they read.
+	generation uint64
+
+	queue  chan string
+	done   chan struct{}
+	wg     sync.WaitGroup
+	closed bool
+
+	stats Stats
+}
+
+// Stats provides counters describing background refreshes.
+type Stats struct {
+	// Refreshes is the number of keys reloaded by background workers.
+	Refreshes uint64
+
+	// RefreshErrors is the number of background refreshes that failed.
+	RefreshErrors uint64
+
+	// Dropped is the number of refreshes dropped because the refresh queue was full.
+	Dropped uint64
+
+	// Expired is the number of expired values, either found by reads and reloaded from the database, or removed from
+	// the cache every TTL.
+	Expired uint64
+
+	// Superseded is the number of background refreshes discarded because the key was written while refreshing.
+	Superseded uint64
+}
+
+var (
+	// ErrInvalidRefreshWindow is returned by Dial when the RefreshWindow is not shorter than the TTL.
+	ErrInvalidRefreshWindow = errors.New("refresh window must be shorter than the TTL")
+)
+
+// Dial will create a new RefreshAhead driver using the provided Config and start the refresh workers. It will return
+// an error if either the Database or Cache values in Config are nil.
+func Dial(cfg Config) (*RefreshAhead, error) {
+	if (cfg.Database == nil) || (cfg.Cache == nil) {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	db := &RefreshAhead{
+		data:       cfg.Database,
+		cache:      cfg.Cache,
+		ttl:        cfg.TTL,
+		window:     cfg.RefreshWindow,
+		workers:    cfg.Workers,
+		loaded:     make(map[string]time.Time),
+		refreshing: make(map[string]uint64),
+		queue:      make(chan string, queueSize),
+		done:       make(chan struct{}),
+	}
+
+	if db.ttl <= 0 {
+		db.ttl = DefaultTTL
+	}
+
+	if db.window <= 0 {
+		db.window = db.ttl / 5
+	}
+
+	if db.window >= db.ttl {
+		return nil, ErrInvalidRefreshWindow
+	}
+
+	if db.workers <= 0 {
+		db.workers = DefaultWorkers
+	}
+
+	for i := 0; i < db.workers; i++ {
+		db.wg.Add(1)
+		go db.worker()
+	}
+
+	db.wg.Add(1)
+	go db.expire()
+
+	return db, nil
+}
+
+// Setup will run the Setup function for both the database and the cache.
+func (db *RefreshAhead) Setup() error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.data.Setup(); err != nil {
+		return err
+	}
+
+	return db.cache.Setup()
+}
+
+// HealthCheck will run the HealthCheck function for both the database and the cache.
+func (db *RefreshAhead) HealthCheck() error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	dataErr := db.data.HealthCheck()
+	cacheErr := db.cache.HealthCheck()
+
+	if dataErr != nil {
+		return errors.Join(hord.ErrHealthCheckFailure, dataErr)
+	} else if cacheErr != nil {
+		return errors.Join(hord.ErrHealthCheckFailure, cacheErr)
+	}
+
+	return nil
+}
+
+// Get will get the data from the cache database. Values within the refresh window are returned and refreshed in the
+// background, while expired values and cache misses are fetched from the database and stored in the cache.
+func (db *RefreshAhead) Get(key string) ([]byte, error) {
+	if db == nil || db.data == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	// Check the cache first
+	data, err := db.cache.Get(key)
+	if (err != nil) && !errors.Is(err, hord.ErrNil) {
+		return nil, err
+	} else if !errors.Is(err, hord.ErrNil) {
+		db.Lock()
+		loaded, tracked := db.loaded[key]
+		db.Unlock()
+
+		age := time.Since(loaded)
+		if !tracked || age < db.ttl {
+			// Values loaded by another instance are untracked and refreshed to begin tracking them
+			if !tracked || age >= db.ttl-db.window {
+				db.schedule(key)
+			}
+			return data, nil
+		}
+
+		db.Lock()
+		db.stats.Expired++
+		db.Unlock()
+	}
+
+	// Check the data database
+	data, err = db.data.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the cache
+	err = db.cache.Set(key, data)
+	if err != nil {
+		return data, fmt.Errorf("%w: %w", hord.ErrCacheError, err)
+	}
+	db.touch(key)
+
+	return data, nil
+}
+
+// Set will set the data in both the data and cache databases.
+func (db *RefreshAhead) Set(key string, data []byte) error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	db.supersede(key)
+	err := db.data.Set(key, data)
+	if err != nil {
+		return err
+	}
+
+	// Update cache only if database Set was successful
+	err = db.cache.Set(key, data)
+	if err != nil {
+		return err
+	}
+	db.touch(key)
+
+	return nil
+}
+
+// Delete will delete the data from both the data and cache databases.
+func (db *RefreshAhead) Delete(key string) error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	db.supersede(key)
+	dataErr := db.data.Delete(key)
+	cacheErr := db.cache.Delete(key)
+
+	db.Lock()
+	delete(db.loaded, key)
+	db.Unlock()
+
+	if dataErr != nil {
+		return dataErr
+	} else if cacheErr != nil {
+		return cacheErr
+	}
+
+	return nil
+}
+
+// Keys will return the keys from the data database.
+func (db *RefreshAhead) Keys() ([]string, error) {
+	if db == nil || db.data == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.data.Keys()
+}
+
+// CacheKeys will return the keys from the cache database.
+func (db *RefreshAhead) CacheKeys() ([]string, error) {
+	if db == nil || db.data == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.cache.Keys()
+}
+
+// Stats returns a snapshot of the refresh counters.
+func (db *RefreshAhead) Stats() Stats {
+	db.Lock()
+	defer db.Unlock()
+
+	return db.stats
+}
+
+// GetCache will return the cache database.
+func (db *RefreshAhead) GetCache() hord.Database {
+	return db.cache
+}
+
+// GetDatabase will return the data database.
+func (db *RefreshAhead) GetDatabase() hord.Database {
+	return db.data
+}
+
+// Close will stop the refresh workers and close the connections to both the database and the cache.
+func (db *RefreshAhead) Close() {
+	if db == nil || db.data == nil || db.cache == nil {
+		return
+	}
+
+	db.Lock()
+	if db.closed {
+		db.Unlock()
+		return
+	}
+	db.closed = true
+	db.Unlock()
+
+	close(db.done)
+	db.wg.Wait()
+
+	db.data.Close()
+	db.cache.Close()
+}
+
+// touch will record the key as loaded into the cache now.
+func (db *RefreshAhead) touch(key string) {
+	db.Lock()
+	defer db.Unlock()
+
+	db.loaded[key] = time.Now()
+}
+
+// schedule will queue the key for a background refresh unless a refresh is already pending.
+func (db *RefreshAhead) schedule(key string) {
+	db.Lock()
+	defer db.Unlock()
+
+	if _, ok := db.refreshing[key]; ok || db.closed {
+		return
+	}
+
+	select {
+	case db.queue <- key:
+		db.refreshing[key] = db.generation
+	default:
+		db.stats.Dropped++
+	}
+}
+
+// supersede will invalidate any pending refresh of the key before it is written.
+func (db *RefreshAhead) supersede(key string) {
+	db.Lock()
+	defer db.Unlock()
+
+	if _, ok := db.refreshing[key]; ok {
+		db.generation++
+		db.refreshing[key] = db.generation
+	}
+}
+
+// superseded returns true if the key was written since the refresh of generation gen was scheduled.
+func (db *RefreshAhead) superseded(key string, gen uint64) bool {
+	db.Lock()
+	defer db.Unlock()
+
+	return db.refreshing[key] != gen
+}
+
+// expire removes expired values from the cache every TTL, so load times are not kept for keys no longer read.
+func (db *RefreshAhead) expire() {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(db.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.done:
+			return
+		case <-ticker.C:
+		}
+
+		var expired []string
+		db.Lock()
+		for k, loaded := range db.loaded {
+			if time.Since(loaded) >= db.ttl {
+				expired = append(expired, k)
+				delete(db.loaded, k)
+			}
+		}
+		db.stats.Expired += uint64(len(expired))
+		db.Unlock()
+
+		// Values reloaded meanwhile are removed too, costing a reload on the next read
+		for _, k := range expired {
+			_ = db.cache.Delete(k)
+		}
+	}
+}
+
+// worker reloads queued keys from the database until the driver is closed.
+func (db *RefreshAhead) worker() {
+	defer db.wg.Done()
+
+	for {
+		select {
+		case <-db.done:
+			return
+		case key := <-db.queue:
+			db.Lock()
+			gen := db.refreshing[key]
+			db.Unlock()
+
+			err := db.refresh(key, gen)
+
+			db.Lock()
+			delete(db.refreshing, key)
+			if err != nil {
+				db.stats.RefreshErrors++
+			} else {
+				db.stats.Refreshes++
+			}
+			db.Unlock()
+		}
+	}
+}
+
+// refresh will reload the key from the database into the cache. Keys deleted from the database are removed from the
+// cache. Refreshes superseded by a Set or Delete of the key are discarded, removing the value from the cache if it
+// was stored while the key was being written.
+func (db *RefreshAhead) refresh(key string, gen uint64) error {
+	data, err := db.data.Get(key)
+	if errors.Is(err, hord.ErrNil) {
+		db.Lock()
+		delete(db.loaded, key)
+		db.Unlock()
+		return db.cache.Delete(key)
+	}
+	if err != nil {
+		return err
+	}
+
+	if db.superseded(key, gen) {
+		db.Lock()
+		db.stats.Superseded++
+		db.Unlock()
+		return nil
+	}
+
+	err = db.cache.Set(key, data)
+	if err != nil {
+		return err
+	}
+
+	if db.superseded(key, gen) {
+		db.Lock()
+		db.stats.Superseded++
+		db.Unlock()
+		return db.cache.Delete(key)
+	}
+	db.touch(key)
+
+	return nil
+}
@@ -0,0 +1,311 @@
+package refreshahead
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// Test Errors used for testing purposes
+var (
+	ErrDatabaseTest = errors.New("database error")
+)
+
+// setupCache is a helper function to create a new RefreshAhead driver backed by hashmap databases.
+func setupCache(t *testing.T, cfg Config) (*RefreshAhead, hord.Database, hord.Database) {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	cfg.Database = database
+	cfg.Cache = cache
+
+	db, err := Dial(cfg)
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	return db, database, cache
+}
+
+// waitFor polls the condition until it is true or the timeout elapses.
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for condition")
+		}
+		<-time.After(5 * time.Millisecond)
+	}
+}
+
+func TestDial(t *testing.T) {
+	unitTests := map[string]struct {
+		config        Config
+		expectedError error
+	}{
+		"No Config": {
+			config:        Config{},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"No Database": {
+			config: Config{
+				Cache: &mock.Database{},
+			},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"No Cache": {
+			config: Config{
+				Database: &mock.Database{},
+			},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"Window Longer Than TTL": {
+			config: Config{
+				Database:      &mock.Database{},
+				Cache:         &mock.Database{},
+				TTL:           time.Second,
+				RefreshWindow: 2 * time.Second,
+			},
+			expectedError: ErrInvalidRefreshWindow,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Dial(test.config)
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Dial(%v) returned error: %s, expected %s", test.config, err, test.expectedError)
+			}
+		})
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		db, err := Dial(Config{Database: &mock.Database{}, Cache: &mock.Database{}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+		defer db.Close()
+
+		if db.ttl != DefaultTTL || db.window != DefaultTTL/5 || db.workers != DefaultWorkers {
+			t.Errorf("Dial() did not set defaults - %+v", db)
+		}
+	})
+}
+
+func TestExpiry(t *testing.T) {
+	db, database, _ := setupCache(t, Config{TTL: 50 * time.Millisecond, RefreshWindow: time.Millisecond})
+	defer db.Close()
+
+	if err := db.Set("key", []byte("v1")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+	_ = database.Set("key", []byte("v2"))
+
+	data, err := db.Get("key")
+	if err != nil || string(data) != "v1" {
+		t.Errorf("Get() returned %s - %v, expected cached v1", data, err)
+	}
+
+	<-time.After(60 * time.Millisecond)
+
+	data, err = db.Get("key")
+	if err != nil || string(data) != "v2" {
+		t.Errorf("Get() returned %s - %v, expected reloaded v2", data, err)
+	}
+
+	if stats := db.Stats(); stats.Expired != 1 {
+		t.Errorf("Unexpected stats - %+v", stats)
+	}
+}
+
+func TestRefreshAhead(t *testing.T) {
+	t.Run("Within Refresh Window", func(t *testing.T) {
+		db, database, cache := setupCache(t, Config{TTL: time.Minute, RefreshWindow: 59 * time.Second})
+		defer db.Close()
+
+		if err := db.Set("key", []byte("v1")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+		_ = database.Set("key", []byte("v2"))
+
+		<-time.After(time.Second)
+
+		// The current value is served while the refresh happens in the background
+		data, err := db.Get("key")
+		if err != nil || string(data) != "v1" {
+			t.Errorf("Get() returned %s - %v, expected cached v1", data, err)
+		}
+
+		waitFor(t, func() bool {
+			data, _ := cache.Get("key")
+			return string(data) == "v2"
+		})
+
+		if stats := db.Stats(); stats.Refreshes != 1 {
+			t.Errorf("Unexpected stats - %+v", stats)
+		}
+	})
+
+	t.Run("Outside Refresh Window", func(t *testing.T) {
+		db, _, _ := setupCache(t, Config{TTL: time.Minute, RefreshWindow: time.Second})
+		defer db.Close()
+
+		_ = db.Set("key", []byte("v1"))
+		_, _ = db.Get("key")
+
+		<-time.After(50 * time.Millisecond)
+		if stats := db.Stats(); stats.Refreshes != 0 {
+			t.Errorf("Unexpected refresh of fresh key - %+v", stats)
+		}
+	})
+
+	t.Run("Untracked Key", func(t *testing.T) {
+		db, database, cache := setupCache(t, Config{TTL: time.Minute})
+		defer db.Close()
+
+		_ = cache.Set("key", []byte("v1"))
+		_ = database.Set("key", []byte("v2"))
+
+		data, err := db.Get("key")
+		if err != nil || string(data) != "v1" {
+			t.Errorf("Get() returned %s - %v, expected cached v1", data, err)
+		}
+
+		waitFor(t, func() bool {
+			data, _ := cache.Get("key")
+			return string(data) == "v2"
+		})
+	})
+
+	t.Run("Deleted From Database", func(t *testing.T) {
+		db, _, cache := setupCache(t, Config{TTL: time.Minute})
+		defer db.Close()
+
+		_ = cache.Set("key", []byte("v1"))
+		_, _ = db.Get("key")
+
+		waitFor(t, func() bool {
+			_, err := cache.Get("key")
+			return errors.Is(err, hord.ErrNil)
+		})
+	})
+}
+
+func TestRefreshErrors(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+	_ = cache.Set("key", []byte("v1"))
+
+	database, err := mock.Dial(mock.Config{
+		GetFunc: func(_ string) ([]byte, error) { return nil, ErrDatabaseTest },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock database - %s", err)
+	}
+
+	db, err := Dial(Config{Database: database, Cache: cache})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+	defer db.Close()
+
+	data, err := db.Get("key")
+	if err != nil || string(data) != "v1" {
+		t.Errorf("Get() returned %s - %v, expected cached v1", data, err)
+	}
+
+	waitFor(t, func() bool { return db.Stats().RefreshErrors == 1 })
+
+	data, err = cache.Get("key")
+	if err != nil || string(data) != "v1" {
+		t.Errorf("Expected failed refresh to keep cached value, got %s - %v", data, err)
+	}
+}
+
+func TestExpiredTracking(t *testing.T) {
+	db, _, cache := setupCache(t, Config{TTL: 20 * time.Millisecond, RefreshWindow: time.Millisecond})
+	defer db.Close()
+
+	for _, k := range []string{"a", "b", "c"} {
+		_ = db.Set(k, []byte("data"))
+	}
+
+	// Expired values are removed from the cache along with their load times
+	waitFor(t, func() bool {
+		db.Lock()
+		defer db.Unlock()
+		return len(db.loaded) == 0
+	})
+
+	if _, err := cache.Get("a"); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("Expected expired value to be removed from the cache, got %v", err)
+	}
+
+	data, err := db.Get("a")
+	if err != nil || string(data) != "data" {
+		t.Errorf("Get() returned %s - %v, expected data", data, err)
+	}
+
+	if stats := db.Stats(); stats.Expired != 3 {
+		t.Errorf("Unexpected stats - %+v", stats)
+	}
+}
+
+func TestRefreshSuperseded(t *testing.T) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+	_ = cache.Set("key", []byte("v1"))
+
+	// Database reads block until released, returning the value read before the key was deleted
+	reading := make(chan struct{}, 1)
+	release := make(chan struct{})
+	database, err := mock.Dial(mock.Config{
+		GetFunc: func(_ string) ([]byte, error) {
+			reading <- struct{}{}
+			<-release
+			return []byte("v1"), nil
+		},
+		DeleteFunc: func(_ string) error { return nil },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock database - %s", err)
+	}
+
+	db, err := Dial(Config{Database: database, Cache: cache})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+	defer db.Close()
+
+	// Untracked values are refreshed in the background
+	if _, err := db.Get("key"); err != nil {
+		t.Fatalf("Get() returned error: %s", err)
+	}
+	<-reading
+
+	if err := db.Delete("key"); err != nil {
+		t.Fatalf("Delete() returned error: %s", err)
+	}
+	close(release)
+
+	waitFor(t, func() bool { return db.Stats().Superseded == 1 })
+
+	if data, err := cache.Get("key"); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("Expected the deleted value to not be restored, got %s - %v", data, err)
+	}
+}
@@ -0,0 +1,422 @@
+/*
+Package tiered provides a Hord database driver composing multiple databases into a tiered cache, such as an in-process
+hashmap in front of Redis in front of Cassandra. To use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/cache/tiered"
+	)
+
+Tiers are ordered from fastest to slowest, with the last tier being the authoritative database. Get checks each tier
+in order and backfills the faster tiers when a value is found. Set and Delete write the database first and then each
+cache tier, from slowest to fastest, so a faster tier never holds a value the slower tiers do not.
+
+Each cache tier has its own policies:
+
+  - WritePolicy controls whether Set writes the value to the tier (Write, the default) or removes it so the next Get
+    backfills it (Invalidate).
+  - NoBackfill stops values found in slower tiers from being stored in the tier by Get.
+  - IgnoreErrors treats failures of the tier as cache misses, allowing the remaining tiers to serve requests. Failures
+    are counted within Stats().
+
+Failures of cache tiers that do not ignore errors are returned as an error wrapping hord.ErrCacheError. On Get, the
+data is returned along with the error when backfilling fails. The last tier has no policies, and Dial returns
+ErrDatabasePolicy if any are set.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the cache.
+
+	// Handle tier connections
+	var local, remote, database hord.Database
+	...
+
+	var db hord.Database
+	db, err := tiered.Dial(tiered.Config{
+		Tiers: []tiered.Tier{
+			{Database: local, WritePolicy: tiered.Invalidate},
+			{Database: remote, IgnoreErrors: true},
+			{Database: database},
+		},
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the cache, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Set a value
+	err = db.Set("key", []byte("value"))
+	if err != nil {
+	    // Handle error
+	}
+
+	// Retrieve a value
+	value, err := db.Get("key")
+	if err != nil {
+	    // Handle error
+	}
+*/
+package tiered
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/tarmac-project/hord"
+)
+
+// WritePolicy defines how Set updates a cache tier.
+type WritePolicy string
+
+const (
+	// Write stores the value within the tier on Set.
+	Write WritePolicy = "write"
+
+	// Invalidate removes the key from the tier on Set, so the value is backfilled by the next Get.
+	Invalidate WritePolicy = "invalidate"
+)
+
+// Config provides the configuration options for the Tiered driver.
+type Config struct {
+	// Tiers is the list of tiers ordered from fastest to slowest. The last tier is the authoritative database. At
+	// least two tiers are required.
+	Tiers []Tier
+}
+
+// Tier provides the configuration options for a single tier.
+type Tier struct {
+	Database hord.Database
+
+	// WritePolicy defines how Set updates the tier. Default is Write.
+	WritePolicy WritePolicy
+
+	// NoBackfill disables storing values found within slower tiers in this tier.
+	NoBackfill bool
+
+	// IgnoreErrors treats failures of this tier as cache misses rather than returning them.
+	IgnoreErrors bool
+}
+
+// Tiered is used to store data across multiple cache tiers. It also satisfies the Hord database interface.
+type Tiered struct {
+	tiers []Tier
+
+	hits       []atomic.Uint64
+	misses     atomic.Uint64
+	backfills  atomic.Uint64
+	tierErrors atomic.Uint64
+}
+
+// Stats provides counters describing cache activity across the tiers.
+type Stats struct {
+	// Hits is the number of lookups answered by each tier, in tier order.
+	Hits []uint64
+
+	// Misses is the number of lookups not found within any tier.
+	Misses uint64
+
+	// Backfills is the number of values stored within faster tiers after being found within a slower tier.
+	Backfills uint64
+
+	// TierErrors is the number of failed cache tier operations, including ignored failures.
+	TierErrors uint64
+}
+
+var (
+	// ErrInvalidPolicy is returned by Dial when a tier WritePolicy is not recognized.
+	ErrInvalidPolicy = errors.New("invalid tier write policy")
+
+	// ErrDatabasePolicy is returned by Dial when policies are set on the last tier, which is the database.
+	ErrDatabasePolicy = errors.New("policies are not supported by the database tier")
+
+	// ErrTooFewTiers is returned by Dial when fewer than two tiers are configured.
+	ErrTooFewTiers = errors.New("at least two tiers are required")
+)
+
+// Dial will create a new Tiered driver using the provided Config. It will return an error if fewer than two tiers are
+// provided, any tier Database is nil, any WritePolicy is invalid or policies are set on the last tier.
+func Dial(cfg Config) (*Tiered, error) {
+	if len(cfg.Tiers) < 2 {
+		return nil, ErrTooFewTiers
+	}
+
+	db := &Tiered{
+		tiers: make([]Tier, len(cfg.Tiers)),
+		hits:  make([]atomic.Uint64, len(cfg.Tiers)),
+	}
+
+	for i, t := range cfg.Tiers {
+		if t.Database == nil {
+			return nil, hord.ErrInvalidDatabase
+		}
+
+		switch t.WritePolicy {
+		case "":
+			t.WritePolicy = Write
+		case Write, Invalidate:
+		default:
+			return nil, ErrInvalidPolicy
+		}
+
+		if i == len(cfg.Tiers)-1 && (t.WritePolicy != Write || t.NoBackfill || t.IgnoreErrors) {
+			return nil, ErrDatabasePolicy
+		}
+
+		db.tiers[i] = t
+	}
+
+	return db, nil
+}
+
+// Setup will run the Setup function for every tier, starting with the database.
+func (db *Tiered) Setup() error {
+	if db == nil || len(db.tiers) == 0 {
+		return hord.ErrNoDial
+	}
+
+	for i := len(db.tiers) - 1; i >= 0; i-- {
+		if err := db.tiers[i].Database.Setup(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HealthCheck will run the HealthCheck function for every tier.
+func (db *Tiered) HealthCheck() error {
+	if db == nil || len(db.tiers) == 0 {
+		return hord.ErrNoDial
+	}
+
+	for i := len(db.tiers) - 1; i >= 0; i-- {
+		if err := db.tiers[i].Database.HealthCheck(); err != nil {
+			return errors.Join(hord.ErrHealthCheckFailure, err)
+		}
+	}
+
+	return nil
+}
+
+// Get will check each tier in order, returning the data from the first tier that holds the key and storing it within
+// the faster tiers. Failures to store the data return the data along with an error wrapping hord.ErrCacheError.
+func (db *Tiered) Get(key string) ([]byte, error) {
+	if db == nil || len(db.tiers) == 0 {
+		return nil, hord.ErrNoDial
+	}
+
+	last := len(db.tiers) - 1
+	for i, t := range db.tiers {
+		data, err := t.Database.Get(key)
+		if err == nil {
+			db.hits[i].Add(1)
+			return data, db.backfill(key, data, i)
+		}
+
+		if errors.Is(err, hord.ErrNil) {
+			continue
+		}
+
+		if i == last {
+			return nil, err
+		}
+
+		db.tierErrors.Add(1)
+		if !t.IgnoreErrors {
+			return nil, err
+		}
+	}
+
+	db.misses.Add(1)
+	return nil, hord.ErrNil
+}
+
+// backfill will store the data within every tier faster than the tier it was found in.
+func (db *Tiered) backfill(key string, data []byte, found int) error {
+	var errs []error
+	for i := found - 1; i >= 0; i-- {
+		t := db.tiers[i]
+		if t.NoBackfill {
+			continue
+		}
+
+		if err := t.Database.Set(key, data); err != nil {
+			db.tierErrors.Add(1)
+			if !t.IgnoreErrors {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		db.backfills.Add(1)
+	}
+
+	return cacheError(errs)
+}
+
+// Set will set the data in the database and then update each cache tier, from slowest to fastest, according to its
+// WritePolicy. Cache tiers that fail to store the value have the key removed, and an error wrapping
+// hord.ErrCacheError is returned unless the tier ignores errors.
+func (db *Tiered) Set(key string, data []byte) error {
+	if db == nil || len(db.tiers) == 0 {
+		return hord.ErrNoDial
+	}
+
+	if err := hord.ValidKey(key); err != nil {
+		return err
+	}
+
+	if err := hord.ValidData(data); err != nil {
+		return err
+	}
+
+	last := len(db.tiers) - 1
+	if err := db.tiers[last].Database.Set(key, data); err != nil {
+		return err
+	}
+
+	// Update cache tiers only if database Set was successful
+	var errs []error
+	for i := last - 1; i >= 0; i-- {
+		t := db.tiers[i]
+
+		var err error
+		switch t.WritePolicy {
+		case Write:
+			err = t.Database.Set(key, data)
+			if err != nil {
+				// Remove the previous value so it is not served, failing to do so is reported with the write error
+				if delErr := t.Database.Delete(key); delErr != nil {
+					err = errors.Join(err, delErr)
+				}
+			}
+		case Invalidate:
+			err = t.Database.Delete(key)
+		}
+
+		if err != nil {
+			db.tierErrors.Add(1)
+			if !t.IgnoreErrors {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return cacheError(errs)
+}
+
+// Delete will delete the data from the database and then each cache tier, from slowest to fastest. Cache tier
+// failures return an error wrapping hord.ErrCacheError unless the tier ignores errors.
+func (db *Tiered) Delete(key string) error {
+	if db == nil || len(db.tiers) == 0 {
+		return hord.ErrNoDial
+	}
+
+	last := len(db.tiers) - 1
+	dataErr := db.tiers[last].Database.Delete(key)
+
+	var errs []error
+	for i := last - 1; i >= 0; i-- {
+		t := db.tiers[i]
+		if err := t.Database.Delete(key); err != nil {
+			db.tierErrors.Add(1)
+			if !t.IgnoreErrors {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if dataErr != nil {
+		return dataErr
+	}
+
+	return cacheError(errs)
+}
+
+// Keys will return the keys from the database.
+func (db *Tiered) Keys() ([]string, error) {
+	if db == nil || len(db.tiers) == 0 {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.tiers[len(db.tiers)-1].Database.Keys()
+}
+
+// CacheKeys will return the keys from the fastest cache tier.
+func (db *Tiered) CacheKeys() ([]string, error) {
+	if db == nil || len(db.tiers) == 0 {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.tiers[0].Database.Keys()
+}
+
+// Stats returns a snapshot of the cache activity counters.
+func (db *Tiered) Stats() Stats {
+	s := Stats{
+		Hits:       make([]uint64, len(db.hits)),
+		Misses:     db.misses.Load(),
+		Backfills:  db.backfills.Load(),
+		TierErrors: db.tierErrors.Load(),
+	}
+
+	for i := range db.hits {
+		s.Hits[i] = db.hits[i].Load()
+	}
+
+	return s
+}
+
+// GetTier will return the database of the tier at index i, or nil if no such tier exists.
+func (db *Tiered) GetTier(i int) hord.Database {
+	if db == nil || i < 0 || i >= len(db.tiers) {
+		return nil
+	}
+
+	return db.tiers[i].Database
+}
+
+// GetCache will return the fastest cache tier.
+func (db *Tiered) GetCache() hord.Database {
+	return db.GetTier(0)
+}
+
+// GetDatabase will return the database.
+func (db *Tiered) GetDatabase() hord.Database {
+	if db == nil {
+		return nil
+	}
+
+	return db.GetTier(len(db.tiers) - 1)
+}
+
+// Close will close the connections to every tier.
+func (db *Tiered) Close() {
+	if db != nil {
+		for _, t := range db.tiers {
+			t.Database.Close()
+		}
+	}
+}
+
+// cacheError will join cache tier errors into a single error wrapping hord.ErrCacheError, or return nil if there are
+// none.
+func cacheError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", hord.ErrCacheError, errors.Join(errs...))
+}
@@ -0,0 +1,361 @@
+package tiered
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// Test Errors used for testing purposes
+var (
+	ErrDatabaseTest = errors.New("database error")
+	ErrCacheTest    = errors.New("cache error")
+)
+
+// newHashmap is a helper function to create a hashmap database for use as a tier.
+func newHashmap(t *testing.T) hord.Database {
+	db, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to hashmap - %s", err)
+	}
+	return db
+}
+
+// newFailing is a helper function to create a mock database where every operation fails with err.
+func newFailing(t *testing.T, err error) hord.Database {
+	db, dialErr := mock.Dial(mock.Config{
+		GetFunc:    func(_ string) ([]byte, error) { return nil, err },
+		SetFunc:    func(_ string, _ []byte) error { return err },
+		DeleteFunc: func(_ string) error { return err },
+	})
+	if dialErr != nil {
+		t.Fatalf("Failed to create mock database - %s", dialErr)
+	}
+	return db
+}
+
+func TestDial(t *testing.T) {
+	unitTests := map[string]struct {
+		config        Config
+		expectedError error
+	}{
+		"No Config": {
+			config:        Config{},
+			expectedError: ErrTooFewTiers,
+		},
+		"Single Tier": {
+			config:        Config{Tiers: []Tier{{Database: &mock.Database{}}}},
+			expectedError: ErrTooFewTiers,
+		},
+		"Nil Database": {
+			config:        Config{Tiers: []Tier{{Database: &mock.Database{}}, {}}},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"Invalid Policy": {
+			config: Config{Tiers: []Tier{
+				{Database: &mock.Database{}, WritePolicy: "invalid"},
+				{Database: &mock.Database{}},
+			}},
+			expectedError: ErrInvalidPolicy,
+		},
+		"Database Write Policy": {
+			config: Config{Tiers: []Tier{
+				{Database: &mock.Database{}},
+				{Database: &mock.Database{}, WritePolicy: Invalidate},
+			}},
+			expectedError: ErrDatabasePolicy,
+		},
+		"Database No Backfill": {
+			config: Config{Tiers: []Tier{
+				{Database: &mock.Database{}},
+				{Database: &mock.Database{}, NoBackfill: true},
+			}},
+			expectedError: ErrDatabasePolicy,
+		},
+		"Database Ignore Errors": {
+			config: Config{Tiers: []Tier{
+				{Database: &mock.Database{}},
+				{Database: &mock.Database{}, IgnoreErrors: true},
+			}},
+			expectedError: ErrDatabasePolicy,
+		},
+		"Happy Path": {
+			config: Config{Tiers: []Tier{
+				{Database: &mock.Database{}},
+				{Database: &mock.Database{}, WritePolicy: Invalidate},
+				{Database: &mock.Database{}},
+			}},
+			expectedError: nil,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Dial(test.config)
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Dial(%v) returned error: %s, expected %s", test.config, err, test.expectedError)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	l1, l2, l3 := newHashmap(t), newHashmap(t), newHashmap(t)
+	db, err := Dial(Config{Tiers: []Tier{{Database: l1}, {Database: l2, NoBackfill: true}, {Database: l3}}})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+
+	_ = l3.Set("key", []byte("data"))
+
+	t.Run("Backfill", func(t *testing.T) {
+		data, err := db.Get("key")
+		if err != nil || string(data) != "data" {
+			t.Fatalf("Get() returned %s - %v, expected data", data, err)
+		}
+
+		if v, err := l1.Get("key"); err != nil || string(v) != "data" {
+			t.Errorf("Expected L1 to be backfilled - %s %v", v, err)
+		}
+		if _, err := l2.Get("key"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected L2 to not be backfilled - %v", err)
+		}
+	})
+
+	t.Run("Fastest Tier Hit", func(t *testing.T) {
+		_ = l3.Delete("key")
+
+		data, err := db.Get("key")
+		if err != nil || string(data) != "data" {
+			t.Errorf("Get() returned %s - %v, expected data", data, err)
+		}
+	})
+
+	t.Run("Miss", func(t *testing.T) {
+		if _, err := db.Get("missing"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+	})
+
+	stats := db.Stats()
+	if stats.Hits[0] != 1 || stats.Hits[1] != 0 || stats.Hits[2] != 1 {
+		t.Errorf("Unexpected tier hits - %v", stats.Hits)
+	}
+	if stats.Misses != 1 || stats.Backfills != 1 {
+		t.Errorf("Unexpected stats - %+v", stats)
+	}
+}
+
+func TestGetTierFailure(t *testing.T) {
+	unitTests := map[string]struct {
+		ignoreErrors  bool
+		expectedData  string
+		expectedError error
+	}{
+		"Returned": {
+			expectedError: ErrCacheTest,
+		},
+		"Ignored": {
+			ignoreErrors: true,
+			expectedData: "data",
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			l3 := newHashmap(t)
+			_ = l3.Set("key", []byte("data"))
+
+			db, err := Dial(Config{Tiers: []Tier{
+				{Database: newFailing(t, ErrCacheTest), IgnoreErrors: test.ignoreErrors},
+				{Database: l3},
+			}})
+			if err != nil {
+				t.Fatalf("Dial() returned error: %s", err)
+			}
+
+			data, err := db.Get("key")
+			if !errors.Is(err, test.expectedError) || string(data) != test.expectedData {
+				t.Errorf("Get() returned %s - %v, expected %s - %v", data, err, test.expectedData, test.expectedError)
+			}
+
+			if n := db.Stats().TierErrors; n == 0 {
+				t.Errorf("Expected tier errors to be counted")
+			}
+		})
+	}
+
+	t.Run("Backfill Failure", func(t *testing.T) {
+		l1 := newHashmap(t)
+		l3 := newHashmap(t)
+		_ = l3.Set("key", []byte("data"))
+
+		failing, err := mock.Dial(mock.Config{
+			GetFunc: func(_ string) ([]byte, error) { return nil, hord.ErrNil },
+			SetFunc: func(_ string, _ []byte) error { return ErrCacheTest },
+		})
+		if err != nil {
+			t.Fatalf("Failed to create mock database - %s", err)
+		}
+
+		db, err := Dial(Config{Tiers: []Tier{{Database: l1}, {Database: failing}, {Database: l3}}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+
+		data, err := db.Get("key")
+		if !errors.Is(err, hord.ErrCacheError) || string(data) != "data" {
+			t.Errorf("Get() returned %s - %v, expected data - %s", data, err, hord.ErrCacheError)
+		}
+
+		if v, err := l1.Get("key"); err != nil || string(v) != "data" {
+			t.Errorf("Expected L1 to be backfilled - %s %v", v, err)
+		}
+	})
+
+	t.Run("Database Failure", func(t *testing.T) {
+		db, err := Dial(Config{Tiers: []Tier{{Database: newHashmap(t)}, {Database: newFailing(t, ErrDatabaseTest)}}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+
+		if _, err := db.Get("key"); !errors.Is(err, ErrDatabaseTest) {
+			t.Errorf("Get() returned error: %v, expected %s", err, ErrDatabaseTest)
+		}
+	})
+}
+
+func TestSet(t *testing.T) {
+	t.Run("Write Policies", func(t *testing.T) {
+		l1, l2, l3 := newHashmap(t), newHashmap(t), newHashmap(t)
+		db, err := Dial(Config{Tiers: []Tier{{Database: l1, WritePolicy: Invalidate}, {Database: l2}, {Database: l3}}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+
+		_ = l1.Set("key", []byte("old"))
+
+		if err := db.Set("key", []byte("data")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		if _, err := l1.Get("key"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected L1 to be invalidated - %v", err)
+		}
+		for i, tier := range []hord.Database{l2, l3} {
+			if v, err := tier.Get("key"); err != nil || string(v) != "data" {
+				t.Errorf("Expected tier %d to hold data - %s %v", i+1, v, err)
+			}
+		}
+	})
+
+	unitTests := map[string]struct {
+		tiers         func(t *testing.T) []Tier
+		expectedError error
+	}{
+		"Database Failure": {
+			tiers: func(t *testing.T) []Tier {
+				return []Tier{{Database: newHashmap(t)}, {Database: newFailing(t, ErrDatabaseTest)}}
+			},
+			expectedError: ErrDatabaseTest,
+		},
+		"Cache Failure": {
+			tiers: func(t *testing.T) []Tier {
+				return []Tier{{Database: newFailing(t, ErrCacheTest)}, {Database: newHashmap(t)}}
+			},
+			expectedError: hord.ErrCacheError,
+		},
+		"Ignored Cache Failure": {
+			tiers: func(t *testing.T) []Tier {
+				return []Tier{{Database: newFailing(t, ErrCacheTest), IgnoreErrors: true}, {Database: newHashmap(t)}}
+			},
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, err := Dial(Config{Tiers: test.tiers(t)})
+			if err != nil {
+				t.Fatalf("Dial() returned error: %s", err)
+			}
+
+			err = db.Set("key", []byte("data"))
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Set() returned error: %v, expected %v", err, test.expectedError)
+			}
+		})
+	}
+
+	t.Run("Failed Write Removes Old Value", func(t *testing.T) {
+		old := newHashmap(t)
+		_ = old.Set("key", []byte("old"))
+
+		var deleted bool
+		l1, err := mock.Dial(mock.Config{
+			SetFunc:    func(_ string, _ []byte) error { return ErrCacheTest },
+			DeleteFunc: func(_ string) error { deleted = true; return nil },
+		})
+		if err != nil {
+			t.Fatalf("Failed to create mock database - %s", err)
+		}
+
+		db, err := Dial(Config{Tiers: []Tier{{Database: l1, IgnoreErrors: true}, {Database: newHashmap(t)}}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+
+		if err := db.Set("key", []byte("data")); err != nil {
+			t.Errorf("Set() returned error: %s", err)
+		}
+		if !deleted {
+			t.Errorf("Expected key to be removed from the failed tier")
+		}
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		db, err := Dial(Config{Tiers: []Tier{{Database: newHashmap(t)}, {Database: newHashmap(t)}}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+
+		if err := db.Set("", []byte("data")); !errors.Is(err, hord.ErrInvalidKey) {
+			t.Errorf("Set() returned error: %v, expected %s", err, hord.ErrInvalidKey)
+		}
+	})
+}
+
+func TestDelete(t *testing.T) {
+	l1, l2, l3 := newHashmap(t), newHashmap(t), newHashmap(t)
+	db, err := Dial(Config{Tiers: []Tier{{Database: l1}, {Database: l2}, {Database: l3}}})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+
+	if err := db.Set("key", []byte("data")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+
+	if err := db.Delete("key"); err != nil {
+		t.Fatalf("Delete() returned error: %s", err)
+	}
+
+	for i, tier := range []hord.Database{l1, l2, l3} {
+		if _, err := tier.Get("key"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected key to be deleted from tier %d - %v", i, err)
+		}
+	}
+
+	t.Run("Cache Failure", func(t *testing.T) {
+		db, err := Dial(Config{Tiers: []Tier{{Database: newFailing(t, ErrCacheTest)}, {Database: newHashmap(t)}}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+
+		if err := db.Delete("key"); !errors.Is(err, hord.ErrCacheError) {
+			t.Errorf("Delete() returned error: %v, expected %s", err, hord.ErrCacheError)
+		}
+	})
+}
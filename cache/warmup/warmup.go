@@ -0,0 +1,384 @@
+/*
+Package warmup provides a Hord database driver that warms a cache during Setup(), so a freshly deployed cache does not
+send every request to the database. To use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/cache/warmup"
+	)
+
+The keys to warm are listed from the Source database by a KeySource:
+
+  - AllKeys() warms every key.
+  - Prefix() warms every key starting with a prefix.
+  - File() warms the keys listed within a file, one per line.
+  - List() warms a fixed list of keys.
+
+Each key is warmed by calling Get on the wrapped cache implementation, which fills the cache on a miss in the same way
+as any other lookup. Keys are warmed by a bounded number of concurrent workers, and progress is reported to the
+OnProgress callback.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the cache.
+
+	// Handle database connection
+	var database hord.Database
+	...
+
+	// Handle look-aside cache connection using the database
+	var cache hord.Database
+	...
+
+	var db hord.Database
+	db, err := warmup.Dial(warmup.Config{
+		Database:    cache,
+		Source:      database,
+		Keys:        warmup.Prefix("user:"),
+		Concurrency: 16,
+		OnProgress: func(p warmup.Progress) {
+			log.Printf("warmed %d of %d keys", p.Done(), p.Total)
+		},
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial(), and
+warms the cache on every execution.
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+The warm-up can also be run without wrapping the cache implementation using Run().
+*/
+package warmup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tarmac-project/hord"
+)
+
+const (
+	// DefaultConcurrency is the default number of keys warmed concurrently.
+	DefaultConcurrency = 8
+
+	// DefaultProgressInterval is the default number of keys warmed between progress reports.
+	DefaultProgressInterval = 100
+)
+
+// KeySource lists the keys to warm from the source database.
+type KeySource func(source hord.Database) ([]string, error)
+
+// Config provides the configuration options for the WarmUp driver.
+type Config struct {
+	// Database is the cache implementation to warm, filled by calling Get for every key.
+	Database hord.Database
+
+	// Source is the database keys are listed from. Default is Database.
+	Source hord.Database
+
+	// Keys lists the keys to warm. Default is AllKeys().
+	Keys KeySource
+
+	// Concurrency is the number of keys warmed concurrently. Default is 8.
+	Concurrency int
+
+	// OnProgress is called with the progress of the warm-up every ProgressInterval keys and once complete. Calls made
+	// while keys are being warmed may be concurrent and out of order, the final call is made once all keys are warmed.
+	OnProgress func(Progress)
+
+	// ProgressInterval is the number of keys warmed between progress reports. Default is 100.
+	ProgressInterval int
+
+	// IgnoreErrors stops Setup() from returning errors for keys that fail to warm. Failures are still reported
+	// within Progress.
+	IgnoreErrors bool
+}
+
+// Progress describes the progress of a warm-up.
+type Progress struct {
+	// Total is the number of keys to warm.
+	Total int
+
+	// Warmed is the number of keys read through the cache.
+	Warmed int
+
+	// Missing is the number of keys no longer found within the database.
+	Missing int
+
+	// Failed is the number of keys that failed to warm.
+	Failed int
+}
+
+// WarmUp wraps a cache implementation, warming it during Setup(). It also satisfies the Hord database interface.
+type WarmUp struct {
+	data hord.Database
+	cfg  Config
+
+	lock     sync.Mutex
+	progress Progress
+}
+
+var (
+	// ErrWarmUp is returned when keys fail to warm.
+	ErrWarmUp = errors.New("unable to warm cache")
+)
+
+// Done returns the number of keys processed.
+func (p Progress) Done() int {
+	return p.Warmed + p.Missing + p.Failed
+}
+
+// AllKeys returns a KeySource listing every key within the source database.
+func AllKeys() KeySource {
+	return func(source hord.Database) ([]string, error) {
+		return source.Keys()
+	}
+}
+
+// Prefix returns a KeySource listing every key within the source database starting with prefix.
+func Prefix(prefix string) KeySource {
+	return func(source hord.Database) ([]string, error) {
+		keys, err := source.Keys()
+		if err != nil {
+			return nil, err
+		}
+
+		var matched []string
+		for _, k := range keys {
+			if strings.HasPrefix(k, prefix) {
+				matched = append(matched, k)
+			}
+		}
+		return matched, nil
+	}
+}
+
+// File returns a KeySource listing the keys within the file at path, one per line. Blank lines and lines starting with
+// # are ignored.
+func File(path string) KeySource {
+	return func(_ hord.Database) ([]string, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open key file: %w", err)
+		}
+		defer f.Close() // nolint:errcheck
+
+		var keys []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			keys = append(keys, line)
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("unable to read key file: %w", err)
+		}
+		return keys, nil
+	}
+}
+
+// List returns a KeySource listing the provided keys.
+func List(keys ...string) KeySource {
+	return func(_ hord.Database) ([]string, error) {
+		return keys, nil
+	}
+}
+
+// Dial will create a new WarmUp driver using the provided Config. It will return an error if the Database value in
+// Config is nil.
+func Dial(cfg Config) (*WarmUp, error) {
+	if cfg.Database == nil {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	return &WarmUp{data: cfg.Database, cfg: cfg}, nil
+}
+
+// Setup will run the Setup function for the cache implementation and then warm it. An error wrapping ErrWarmUp is
+// returned if keys fail to warm, unless IgnoreErrors is set.
+func (db *WarmUp) Setup() error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.data.Setup(); err != nil {
+		return err
+	}
+
+	p, err := Run(db.cfg)
+
+	db.lock.Lock()
+	db.progress = p
+	db.lock.Unlock()
+
+	if err != nil && (!db.cfg.IgnoreErrors || !errors.Is(err, ErrWarmUp)) {
+		return err
+	}
+
+	return nil
+}
+
+// HealthCheck will run the HealthCheck function for the cache implementation.
+func (db *WarmUp) HealthCheck() error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.HealthCheck()
+}
+
+// Get will get the data from the cache implementation.
+func (db *WarmUp) Get(key string) ([]byte, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.data.Get(key)
+}
+
+// Set will set the data using the cache implementation.
+func (db *WarmUp) Set(key string, data []byte) error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.Set(key, data)
+}
+
+// Delete will delete the data using the cache implementation.
+func (db *WarmUp) Delete(key string) error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.Delete(key)
+}
+
+// Keys will return the keys from the cache implementation.
+func (db *WarmUp) Keys() ([]string, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.data.Keys()
+}
+
+// Close will close the cache implementation.
+func (db *WarmUp) Close() {
+	if db != nil && db.data != nil {
+		db.data.Close()
+	}
+}
+
+// Progress returns the progress of the last warm-up.
+func (db *WarmUp) Progress() Progress {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return db.progress
+}
+
+// GetDatabase will return the wrapped cache implementation.
+func (db *WarmUp) GetDatabase() hord.Database {
+	return db.data
+}
+
+// Unwrap will return the wrapped cache implementation, so its own methods can be reached with a type assertion.
+func (db *WarmUp) Unwrap() hord.Database {
+	return db.data
+}
+
+// Run will warm the cache implementation within Config, returning the final progress. Errors listing keys are
+// returned as-is, while keys that fail to warm return an error wrapping ErrWarmUp and the first failure.
+func Run(cfg Config) (Progress, error) {
+	if cfg.Database == nil {
+		return Progress{}, hord.ErrInvalidDatabase
+	}
+
+	if cfg.Source == nil {
+		cfg.Source = cfg.Database
+	}
+
+	if cfg.Keys == nil {
+		cfg.Keys = AllKeys()
+	}
+
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultConcurrency
+	}
+
+	if cfg.ProgressInterval <= 0 {
+		cfg.ProgressInterval = DefaultProgressInterval
+	}
+
+	keys, err := cfg.Keys(cfg.Source)
+	if err != nil {
+		return Progress{}, err
+	}
+
+	var lock sync.Mutex
+	var firstErr error
+	p := Progress{Total: len(keys)}
+
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range queue {
+				_, err := cfg.Database.Get(key)
+
+				lock.Lock()
+				switch {
+				case err == nil || errors.Is(err, hord.ErrStale):
+					// Stale values are returned along with valid data and are refreshed by the cache
+					p.Warmed++
+				case errors.Is(err, hord.ErrNil):
+					p.Missing++
+				default:
+					p.Failed++
+					if firstErr == nil {
+						firstErr = fmt.Errorf("%w: key %s: %w", ErrWarmUp, key, err)
+					}
+				}
+
+				report := cfg.OnProgress != nil && p.Done()%cfg.ProgressInterval == 0 && p.Done() < p.Total
+				current := p
+				lock.Unlock()
+
+				// Report outside the lock so slow callbacks do not stall the other workers
+				if report {
+					cfg.OnProgress(current)
+				}
+			}
+		}()
+	}
+
+	for _, key := range keys {
+		queue <- key
+	}
+	close(queue)
+	wg.Wait()
+
+	if cfg.OnProgress != nil {
+		cfg.OnProgress(p)
+	}
+
+	return p, firstErr
+}
@@ -0,0 +1,267 @@
+package warmup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// Test Errors used for testing purposes
+var (
+	ErrCacheTest = errors.New("cache error")
+)
+
+// recorder is a cache implementation recording the keys read through it.
+type recorder struct {
+	sync.Mutex
+	keys []string
+}
+
+// setup is a helper function creating a source database holding the provided keys and a recorder cache
+// implementation reading from it.
+func setup(t *testing.T, keys ...string) (hord.Database, hord.Database, *recorder) {
+	source, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	for _, k := range keys {
+		_ = source.Set(k, []byte("data"))
+	}
+
+	rec := &recorder{}
+	cache, err := mock.Dial(mock.Config{
+		GetFunc: func(key string) ([]byte, error) {
+			rec.Lock()
+			rec.keys = append(rec.keys, key)
+			rec.Unlock()
+			return source.Get(key)
+		},
+		KeysFunc: source.Keys,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock cache - %s", err)
+	}
+
+	return cache, source, rec
+}
+
+// warmed returns the sorted keys read through the recorder.
+func (r *recorder) warmed() []string {
+	r.Lock()
+	defer r.Unlock()
+
+	keys := append([]string{}, r.keys...)
+	sort.Strings(keys)
+	return keys
+}
+
+func TestKeySources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.txt")
+	if err := os.WriteFile(path, []byte("# hot keys\nuser:1\n\n  config:a  \n"), 0600); err != nil {
+		t.Fatalf("Failed to write key file - %s", err)
+	}
+
+	unitTests := map[string]struct {
+		keys     KeySource
+		expected []string
+	}{
+		"Default":  {keys: nil, expected: []string{"config:a", "user:1", "user:2"}},
+		"All Keys": {keys: AllKeys(), expected: []string{"config:a", "user:1", "user:2"}},
+		"Prefix":   {keys: Prefix("user:"), expected: []string{"user:1", "user:2"}},
+		"File":     {keys: File(path), expected: []string{"config:a", "user:1"}},
+		"List":     {keys: List("user:2"), expected: []string{"user:2"}},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			cache, source, rec := setup(t, "user:1", "user:2", "config:a")
+
+			p, err := Run(Config{Database: cache, Source: source, Keys: test.keys})
+			if err != nil {
+				t.Fatalf("Run() returned error: %s", err)
+			}
+
+			warmed := rec.warmed()
+			if fmt.Sprint(warmed) != fmt.Sprint(test.expected) {
+				t.Errorf("Unexpected keys warmed - got %v, expected %v", warmed, test.expected)
+			}
+
+			if p.Total != len(test.expected) || p.Warmed != len(test.expected) {
+				t.Errorf("Unexpected progress - %+v", p)
+			}
+		})
+	}
+
+	t.Run("Missing File", func(t *testing.T) {
+		cache, _, _ := setup(t)
+		if _, err := Run(Config{Database: cache, Keys: File(filepath.Join(t.TempDir(), "missing"))}); err == nil {
+			t.Errorf("Expected error when the key file does not exist")
+		}
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("Bounded Concurrency", func(t *testing.T) {
+		var active, peak atomic.Int64
+		cache, err := mock.Dial(mock.Config{
+			GetFunc: func(_ string) ([]byte, error) {
+				n := active.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				<-time.After(time.Millisecond)
+				active.Add(-1)
+				return []byte("data"), nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create mock cache - %s", err)
+		}
+
+		keys := make([]string, 50)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("key-%d", i)
+		}
+
+		var lock sync.Mutex
+		var reports []Progress
+		p, err := Run(Config{
+			Database:         cache,
+			Keys:             List(keys...),
+			Concurrency:      3,
+			ProgressInterval: 10,
+			OnProgress: func(p Progress) {
+				lock.Lock()
+				defer lock.Unlock()
+				reports = append(reports, p)
+			},
+		})
+		if err != nil {
+			t.Fatalf("Run() returned error: %s", err)
+		}
+
+		if n := peak.Load(); n > 3 {
+			t.Errorf("Unexpected concurrency - got %d, expected at most 3", n)
+		}
+
+		if p.Warmed != 50 {
+			t.Errorf("Unexpected progress - %+v", p)
+		}
+
+		// Reports every 10 keys, with the final report once complete
+		if len(reports) != 5 || reports[len(reports)-1].Done() != 50 {
+			t.Errorf("Unexpected progress reports - %+v", reports)
+		}
+	})
+
+	t.Run("Missing and Failed Keys", func(t *testing.T) {
+		cache, err := mock.Dial(mock.Config{
+			GetFunc: func(key string) ([]byte, error) {
+				switch key {
+				case "missing":
+					return nil, hord.ErrNil
+				case "failing":
+					return nil, ErrCacheTest
+				case "stale":
+					return []byte("data"), hord.ErrStale
+				}
+				return []byte("data"), nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create mock cache - %s", err)
+		}
+
+		p, err := Run(Config{Database: cache, Keys: List("key", "missing", "failing", "stale")})
+		if !errors.Is(err, ErrWarmUp) || !errors.Is(err, ErrCacheTest) {
+			t.Errorf("Run() returned error: %v, expected %s", err, ErrWarmUp)
+		}
+
+		// Stale values are returned with valid data and count as warmed
+		if p.Warmed != 2 || p.Missing != 1 || p.Failed != 1 {
+			t.Errorf("Unexpected progress - %+v", p)
+		}
+	})
+
+	t.Run("No Database", func(t *testing.T) {
+		if _, err := Run(Config{}); !errors.Is(err, hord.ErrInvalidDatabase) {
+			t.Errorf("Run() returned error: %v, expected %s", err, hord.ErrInvalidDatabase)
+		}
+	})
+}
+
+func TestWarmUp(t *testing.T) {
+	if _, err := Dial(Config{}); !errors.Is(err, hord.ErrInvalidDatabase) {
+		t.Errorf("Dial() returned error: %v, expected %s", err, hord.ErrInvalidDatabase)
+	}
+
+	unitTests := map[string]struct {
+		ignoreErrors  bool
+		expectedError error
+	}{
+		"Errors Returned": {expectedError: ErrWarmUp},
+		"Errors Ignored":  {ignoreErrors: true},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			cache, source, rec := setup(t, "key")
+			_ = source.Set("failing", []byte("data"))
+
+			failing, err := mock.Dial(mock.Config{
+				GetFunc: func(key string) ([]byte, error) {
+					if key == "failing" {
+						return nil, ErrCacheTest
+					}
+					return cache.Get(key)
+				},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create mock cache - %s", err)
+			}
+
+			db, err := Dial(Config{Database: failing, Source: source, IgnoreErrors: test.ignoreErrors})
+			if err != nil {
+				t.Fatalf("Dial() returned error: %s", err)
+			}
+			defer db.Close()
+
+			if len(rec.warmed()) != 0 {
+				t.Fatalf("Expected no keys to be warmed before Setup")
+			}
+
+			if err := db.Setup(); !errors.Is(err, test.expectedError) {
+				t.Errorf("Setup() returned error: %v, expected %v", err, test.expectedError)
+			}
+
+			if p := db.Progress(); p.Total != 2 || p.Warmed != 1 || p.Failed != 1 {
+				t.Errorf("Unexpected progress - %+v", p)
+			}
+
+			if data, err := db.Get("key"); err != nil || string(data) != "data" {
+				t.Errorf("Get() returned %s - %v, expected data", data, err)
+			}
+		})
+	}
+
+	t.Run("Not Dialed", func(t *testing.T) {
+		var db *WarmUp
+		if err := db.Setup(); !errors.Is(err, hord.ErrNoDial) {
+			t.Errorf("Setup() returned error: %v, expected %s", err, hord.ErrNoDial)
+		}
+	})
+}
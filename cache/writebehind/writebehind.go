@@ -0,0 +1,603 @@
+/*
+Package writebehind provides a Hord database driver for a write-behind cache. To use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/cache/writebehind"
+	)
+
+With a write-behind cache, Set and Delete update the cache immediately and queue the change for the database. Queued
+changes are coalesced, so only the latest change for each key is written, and flushed to the database in batches
+either every FlushInterval or once BatchSize keys are queued. Queued changes are also flushed on Close.
+
+Flush errors are counted within Stats(). Failed changes remain queued and are retried on the next flush unless a newer
+change for the same key has been queued. Changes still failing after MaxRetries retries are dropped and reported once
+to the OnError callback.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the cache.
+
+	// Handle database connection
+	var database hord.Database
+	...
+
+	// Handle cache connection
+	var cache hord.Database
+	...
+
+	var db hord.Database
+	db, err := writebehind.Dial(writebehind.Config{
+		Database:      database,
+		Cache:         cache,
+		FlushInterval: 5 * time.Second,
+		BatchSize:     500,
+		MaxRetries:    10,
+		OnError: func(key string, err error) {
+			log.Printf("dropped change to %s: %s", key, err)
+		},
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the cache, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Set a value, the database is updated on the next flush
+	err = db.Set("key", []byte("value"))
+	if err != nil {
+	    // Handle error
+	}
+
+	// Retrieve a value
+	value, err := db.Get("key")
+	if err != nil {
+	    // Handle error
+	}
+
+	// Flush queued changes and close the database
+	db.Close()
+*/
+package writebehind
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+const (
+	// DefaultFlushInterval is the default time between flushes.
+	DefaultFlushInterval = time.Second
+
+	// DefaultBatchSize is the default number of queued keys that triggers a flush.
+	DefaultBatchSize = 100
+
+	// DefaultMaxRetries is the default number of times a failed change is retried before being dropped.
+	DefaultMaxRetries = 10
+)
+
+// Config provides the configuration options for the WriteBehind driver.
+type Config struct {
+	Database hord.Database
+	Cache    hord.Database
+
+	// FlushInterval is the time between flushes of queued changes. Default is 1 second.
+	FlushInterval time.Duration
+
+	// BatchSize is the number of queued keys that triggers a flush before the FlushInterval elapses. Default is 100.
+	BatchSize int
+
+	// MaxRetries is the number of flushes that retry a failed change before it is dropped. Default is 10.
+	MaxRetries int
+
+	// OnError is called once for each queued change dropped after failing to be written to the database, with the
+	// error of the last attempt.
+	OnError func(key string, err error)
+}
+
+// WriteBehind is used to store data in a write-behind caching pattern. It also satisfies the Hord database interface.
+type WriteBehind struct {
+	sync.Mutex
+
+	data       hord.Database
+	cache      hord.Database
+	interval   time.Duration
+	batchSize  int
+	maxRetries int
+	onError    func(key string, err error)
+
+	// pending holds the latest queued change for each key, a nil value represents a delete.
+	pending map[string][]byte
+
+	// flushing holds the changes being written by the running flush, each removed once its write finishes.
+	flushing map[string][]byte
+
+	// failures holds the number of failed writes of the change queued for each key.
+	failures map[string]int
+
+	// filling holds the keys being filled into the cache by Get, along with the generation of the key when the
+	// database was read.
+	filling map[string]uint64
+
+	// generation is incremented by every Set and Delete of a key being filled, so fills racing a write do not store
+	// the value they read.
+	generation uint64
+
+	// flushLock serializes flushes.
+	flushLock sync.Mutex
+
+	trigger chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	closed  bool
+
+	stats Stats
+}
+
+// Stats provides counters describing queued and flushed changes.
+type Stats struct {
+	// Queued is the number of keys currently waiting to be written to the database, including keys being flushed.
+	Queued int
+
+	// Coalesced is the number of changes replaced by a newer change to the same key before being flushed.
+	Coalesced uint64
+
+	// Flushes is the number of completed flushes.
+	Flushes uint64
+
+	// Flushed is the number of changes written to the database.
+	Flushed uint64
+
+	// FlushErrors is the number of changes that failed to be written to the database.
+	FlushErrors uint64
+
+	// Dropped is the number of changes dropped after failing to be written MaxRetries times.
+	Dropped uint64
+}
+
+// Dial will create a new WriteBehind driver using the provided Config and start flushing queued changes in the
+// background. It will return an error if either the Database or Cache values in Config are nil.
+func Dial(cfg Config) (*WriteBehind, error) {
+	if (cfg.Database == nil) || (cfg.Cache == nil) {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	db := &WriteBehind{
+		data:       cfg.Database,
+		cache:      cfg.Cache,
+		interval:   cfg.FlushInterval,
+		batchSize:  cfg.BatchSize,
+		maxRetries: cfg.MaxRetries,
+		onError:    cfg.OnError,
+		pending:    make(map[string][]byte),
+		flushing:   make(map[string][]byte),
+		failures:   make(map[string]int),
+		filling:    make(map[string]uint64),
+		trigger:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+
+	if db.interval <= 0 {
+		db.interval = DefaultFlushInterval
+	}
+
+	if db.batchSize <= 0 {
+		db.batchSize = DefaultBatchSize
+	}
+
+	if db.maxRetries <= 0 {
+		db.maxRetries = DefaultMaxRetries
+	}
+
+	go db.run()
+
+	return db, nil
+}
+
+// Setup will run the Setup function for both the database and the cache.
+func (db *WriteBehind) Setup() error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.data.Setup(); err != nil {
+		return err
+	}
+
+	return db.cache.Setup()
+}
+
+// HealthCheck will run the HealthCheck function for both the database and the cache.
+func (db *WriteBehind) HealthCheck() error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	dataErr := db.data.HealthCheck()
+	cacheErr := db.cache.HealthCheck()
+
+	if dataErr != nil {
+		return errors.Join(hord.ErrHealthCheckFailure, dataErr)
+	} else if cacheErr != nil {
+		return errors.Join(hord.ErrHealthCheckFailure, cacheErr)
+	}
+
+	return nil
+}
+
+// Get will get the data from the cache database. If not found, queued changes, including changes being flushed, are
+// checked before the data is fetched from the database and stored in the cache. Values read from the database are not
+// stored in the cache if the key is written while being read.
+func (db *WriteBehind) Get(key string) ([]byte, error) {
+	if db == nil || db.data == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	// Check the cache first
+	data, err := db.cache.Get(key)
+	if (err != nil) && !errors.Is(err, hord.ErrNil) {
+		return nil, err
+	} else if !errors.Is(err, hord.ErrNil) {
+		return data, nil
+	}
+
+	// Check queued changes, which may have been evicted from the cache
+	db.Lock()
+	data, ok := db.queued(key)
+	var gen uint64
+	if !ok {
+		gen = db.fill(key)
+	}
+	db.Unlock()
+	if ok {
+		if data == nil {
+			return nil, hord.ErrNil
+		}
+		return data, nil
+	}
+	defer db.filled(key, gen)
+
+	// Check the data database
+	data, err = db.data.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the cache, unless the key was written while being read
+	if db.superseded(key, gen) {
+		return data, nil
+	}
+
+	err = db.cache.Set(key, data)
+	if err != nil {
+		return data, fmt.Errorf("%w: %w", hord.ErrCacheError, err)
+	}
+
+	// Remove the value read if the key was written while it was stored, as it may have replaced the newer value
+	if db.superseded(key, gen) {
+		_ = db.cache.Delete(key)
+	}
+
+	return data, nil
+}
+
+// Set will set the data in the cache and queue it to be written to the database.
+func (db *WriteBehind) Set(key string, data []byte) error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := hord.ValidKey(key); err != nil {
+		return err
+	}
+
+	if err := hord.ValidData(data); err != nil {
+		return err
+	}
+
+	db.supersede(key)
+	err := db.cache.Set(key, data)
+	if err != nil {
+		return err
+	}
+
+	db.enqueue(key, data)
+	return nil
+}
+
+// Delete will delete the data from the cache and queue the delete for the database.
+func (db *WriteBehind) Delete(key string) error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := hord.ValidKey(key); err != nil {
+		return err
+	}
+
+	db.supersede(key)
+	err := db.cache.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	db.enqueue(key, nil)
+	return nil
+}
+
+// Keys will return the keys from the data database, including queued changes that have not yet been flushed.
+func (db *WriteBehind) Keys() ([]string, error) {
+	if db == nil || db.data == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	keys, err := db.data.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	db.Lock()
+	defer db.Unlock()
+
+	if len(db.pending) == 0 && len(db.flushing) == 0 {
+		return keys, nil
+	}
+
+	var merged []string
+	for _, k := range keys {
+		if _, ok := db.queued(k); !ok {
+			merged = append(merged, k)
+		}
+	}
+
+	for k, v := range db.flushing {
+		if _, ok := db.pending[k]; !ok && v != nil {
+			merged = append(merged, k)
+		}
+	}
+
+	for k, v := range db.pending {
+		if v != nil {
+			merged = append(merged, k)
+		}
+	}
+
+	return merged, nil
+}
+
+// CacheKeys will return the keys from the cache database.
+func (db *WriteBehind) CacheKeys() ([]string, error) {
+	if db == nil || db.data == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.cache.Keys()
+}
+
+// Flush will write all queued changes to the database. Changes remain visible to Get and Keys until their write
+// finishes. It returns the errors of any changes that could not be written, which remain queued for the next flush
+// until they have been retried MaxRetries times.
+func (db *WriteBehind) Flush() error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	db.flushLock.Lock()
+	defer db.flushLock.Unlock()
+
+	db.Lock()
+	batch := db.pending
+	db.pending = make(map[string][]byte)
+	db.flushing = batch
+	db.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var errs []error
+	var flushed uint64
+	for key, data := range batch {
+		var err error
+		if data == nil {
+			err = db.data.Delete(key)
+		} else {
+			err = db.data.Set(key, data)
+		}
+
+		dropped := db.finish(key, data, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to write %s: %w", key, err))
+			if dropped && db.onError != nil {
+				db.onError(key, err)
+			}
+			continue
+		}
+		flushed++
+	}
+
+	db.Lock()
+	db.stats.Flushes++
+	db.stats.Flushed += flushed
+	db.stats.FlushErrors += uint64(len(errs))
+	db.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// Stats returns a snapshot of the queue and flush counters.
+func (db *WriteBehind) Stats() Stats {
+	db.Lock()
+	defer db.Unlock()
+
+	s := db.stats
+	s.Queued = len(db.pending)
+	for k := range db.flushing {
+		if _, ok := db.pending[k]; !ok {
+			s.Queued++
+		}
+	}
+	return s
+}
+
+// GetCache will return the cache database.
+func (db *WriteBehind) GetCache() hord.Database {
+	return db.cache
+}
+
+// GetDatabase will return the data database.
+func (db *WriteBehind) GetDatabase() hord.Database {
+	return db.data
+}
+
+// Close will stop background flushing, flush any queued changes, and close the connections to both the database and
+// the cache. Changes that fail to be written by the final flush are lost.
+func (db *WriteBehind) Close() {
+	if db == nil || db.data == nil || db.cache == nil {
+		return
+	}
+
+	db.Lock()
+	if db.closed {
+		db.Unlock()
+		return
+	}
+	db.closed = true
+	db.Unlock()
+
+	close(db.done)
+	<-db.stopped
+
+	_ = db.Flush()
+
+	db.data.Close()
+	db.cache.Close()
+}
+
+// run flushes queued changes every interval or when triggered by the batch size, until the driver is closed.
+func (db *WriteBehind) run() {
+	defer close(db.stopped)
+
+	ticker := time.NewTicker(db.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.done:
+			return
+		case <-ticker.C:
+		case <-db.trigger:
+		}
+		_ = db.Flush()
+	}
+}
+
+// queued returns the latest queued change for the key, checking changes being flushed when none is pending. The lock
+// must be held by the caller.
+func (db *WriteBehind) queued(key string) ([]byte, bool) {
+	if data, ok := db.pending[key]; ok {
+		return data, true
+	}
+	data, ok := db.flushing[key]
+	return data, ok
+}
+
+// fill will record that the key is being filled into the cache, returning the generation of the key. The lock must
+// be held by the caller.
+func (db *WriteBehind) fill(key string) uint64 {
+	if gen, ok := db.filling[key]; ok {
+		return gen
+	}
+	db.filling[key] = db.generation
+	return db.generation
+}
+
+// filled will stop tracking the fill of the key, unless the key was written since.
+func (db *WriteBehind) filled(key string, gen uint64) {
+	db.Lock()
+	defer db.Unlock()
+
+	if db.filling[key] == gen {
+		delete(db.filling, key)
+	}
+}
+
+// supersede will invalidate any fill of the key before it is written.
+func (db *WriteBehind) supersede(key string) {
+	db.Lock()
+	defer db.Unlock()
+
+	if _, ok := db.filling[key]; ok {
+		db.generation++
+		db.filling[key] = db.generation
+	}
+}
+
+// superseded returns true if the key was written since the fill of generation gen started.
+func (db *WriteBehind) superseded(key string, gen uint64) bool {
+	db.Lock()
+	defer db.Unlock()
+
+	g, ok := db.filling[key]
+	return !ok || g != gen
+}
+
+// enqueue will queue a change for the key, replacing any queued change for the same key. A change to a key being
+// flushed is queued for the next flush, as the running write may finish after it.
+func (db *WriteBehind) enqueue(key string, data []byte) {
+	db.Lock()
+	if _, ok := db.pending[key]; ok {
+		db.stats.Coalesced++
+	}
+	db.pending[key] = data
+	delete(db.failures, key)
+	full := len(db.pending) >= db.batchSize
+	db.Unlock()
+
+	if full {
+		select {
+		case db.trigger <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// finish will remove a flushed change for the key once its write has finished. A failed change is queued again,
+// so the key is never missing from both, unless a newer change has been queued or it has failed more than
+// MaxRetries times. It returns true if the failed change was dropped.
+func (db *WriteBehind) finish(key string, data []byte, err error) bool {
+	db.Lock()
+	defer db.Unlock()
+
+	delete(db.flushing, key)
+	if _, ok := db.pending[key]; ok || err == nil {
+		delete(db.failures, key)
+		return false
+	}
+
+	db.failures[key]++
+	if db.failures[key] > db.maxRetries {
+		delete(db.failures, key)
+		db.stats.Dropped++
+		return true
+	}
+
+	db.pending[key] = data
+	return false
+}
@@ -0,0 +1,531 @@
+package writebehind
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// Test Errors used for testing purposes
+var (
+	ErrDatabaseTest = errors.New("database error")
+	ErrCacheTest    = errors.New("cache error")
+)
+
+// recorder is a mock database that records writes for testing purposes.
+type recorder struct {
+	sync.Mutex
+	data map[string][]byte
+	err  error
+
+	// gate, when set, blocks writes until it is closed.
+	gate chan struct{}
+}
+
+// wait blocks until the gate is closed, if set.
+func (r *recorder) wait() {
+	r.Lock()
+	gate := r.gate
+	r.Unlock()
+	if gate != nil {
+		<-gate
+	}
+}
+
+// database returns a mock database backed by the recorder.
+func (r *recorder) database(t *testing.T) hord.Database {
+	r.data = make(map[string][]byte)
+	db, err := mock.Dial(mock.Config{
+		GetFunc: func(key string) ([]byte, error) {
+			r.Lock()
+			defer r.Unlock()
+			if v, ok := r.data[key]; ok {
+				return v, nil
+			}
+			return nil, hord.ErrNil
+		},
+		SetFunc: func(key string, data []byte) error {
+			r.wait()
+			r.Lock()
+			defer r.Unlock()
+			if r.err != nil {
+				return r.err
+			}
+			r.data[key] = data
+			return nil
+		},
+		DeleteFunc: func(key string) error {
+			r.wait()
+			r.Lock()
+			defer r.Unlock()
+			if r.err != nil {
+				return r.err
+			}
+			delete(r.data, key)
+			return nil
+		},
+		KeysFunc: func() ([]string, error) {
+			r.Lock()
+			defer r.Unlock()
+			var keys []string
+			for k := range r.data {
+				keys = append(keys, k)
+			}
+			return keys, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock database - %s", err)
+	}
+	return db
+}
+
+// value returns the value written to the recorder for the key.
+func (r *recorder) value(key string) string {
+	r.Lock()
+	defer r.Unlock()
+	return string(r.data[key])
+}
+
+// setupCache is a helper function to create a new WriteBehind driver backed by a recorder and a hashmap cache.
+func setupCache(t *testing.T, cfg Config) (*WriteBehind, *recorder) {
+	cache, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to cache - %s", err)
+	}
+
+	r := &recorder{}
+	cfg.Database = r.database(t)
+	cfg.Cache = cache
+
+	db, err := Dial(cfg)
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	return db, r
+}
+
+// waitFor polls the condition until it is true or the timeout elapses.
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for condition")
+		}
+		<-time.After(5 * time.Millisecond)
+	}
+}
+
+func TestDial(t *testing.T) {
+	unitTests := map[string]struct {
+		config        Config
+		expectedError error
+	}{
+		"No Config": {
+			config:        Config{},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"No Database": {
+			config: Config{
+				Cache: &mock.Database{},
+			},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"No Cache": {
+			config: Config{
+				Database: &mock.Database{},
+			},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Dial(test.config)
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Dial(%v) returned error: %s, expected %s", test.config, err, test.expectedError)
+			}
+		})
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		db, err := Dial(Config{Database: &mock.Database{}, Cache: &mock.Database{}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+		defer db.Close()
+
+		if db.interval != DefaultFlushInterval || db.batchSize != DefaultBatchSize || db.maxRetries != DefaultMaxRetries {
+			t.Errorf("Dial() did not set defaults - %+v", db)
+		}
+	})
+}
+
+func TestCoalescing(t *testing.T) {
+	db, r := setupCache(t, Config{FlushInterval: time.Hour})
+	defer db.Close()
+
+	for _, v := range []string{"v1", "v2", "v3"} {
+		if err := db.Set("key", []byte(v)); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+	}
+	if err := db.Set("other", []byte("data")); err != nil {
+		t.Fatalf("Set() returned error: %s", err)
+	}
+
+	t.Run("Queued Before Flush", func(t *testing.T) {
+		if v := r.value("key"); v != "" {
+			t.Errorf("Database written before flush - %s", v)
+		}
+
+		stats := db.Stats()
+		if stats.Queued != 2 || stats.Coalesced != 2 {
+			t.Errorf("Unexpected stats - %+v", stats)
+		}
+
+		data, err := db.Get("key")
+		if err != nil || string(data) != "v3" {
+			t.Errorf("Get() returned %s - %v, expected v3", data, err)
+		}
+
+		keys, err := db.Keys()
+		if err != nil {
+			t.Fatalf("Keys() returned error: %s", err)
+		}
+		sort.Strings(keys)
+		if len(keys) != 2 || keys[0] != "key" || keys[1] != "other" {
+			t.Errorf("Unexpected keys - %v", keys)
+		}
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		if err := db.Flush(); err != nil {
+			t.Fatalf("Flush() returned error: %s", err)
+		}
+
+		if v := r.value("key"); v != "v3" {
+			t.Errorf("Unexpected database value - got %s, expected v3", v)
+		}
+
+		stats := db.Stats()
+		if stats.Queued != 0 || stats.Flushes != 1 || stats.Flushed != 2 {
+			t.Errorf("Unexpected stats - %+v", stats)
+		}
+	})
+
+	t.Run("Queued Delete", func(t *testing.T) {
+		if err := db.Delete("other"); err != nil {
+			t.Fatalf("Delete() returned error: %s", err)
+		}
+
+		keys, err := db.Keys()
+		if err != nil {
+			t.Fatalf("Keys() returned error: %s", err)
+		}
+		if len(keys) != 1 || keys[0] != "key" {
+			t.Errorf("Unexpected keys - %v", keys)
+		}
+
+		if err := db.Flush(); err != nil {
+			t.Fatalf("Flush() returned error: %s", err)
+		}
+		if v := r.value("other"); v != "" {
+			t.Errorf("Expected key to be deleted from database, got %s", v)
+		}
+	})
+}
+
+func TestFlushTriggers(t *testing.T) {
+	t.Run("Batch Size", func(t *testing.T) {
+		db, r := setupCache(t, Config{FlushInterval: time.Hour, BatchSize: 2})
+		defer db.Close()
+
+		_ = db.Set("a", []byte("a"))
+		_ = db.Set("b", []byte("b"))
+
+		waitFor(t, func() bool { return r.value("a") == "a" && r.value("b") == "b" })
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		db, r := setupCache(t, Config{FlushInterval: 10 * time.Millisecond})
+		defer db.Close()
+
+		_ = db.Set("a", []byte("a"))
+
+		waitFor(t, func() bool { return r.value("a") == "a" })
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		db, r := setupCache(t, Config{FlushInterval: time.Hour})
+
+		_ = db.Set("a", []byte("a"))
+		db.Close()
+
+		if v := r.value("a"); v != "a" {
+			t.Errorf("Expected queued change to be flushed on Close, got %s", v)
+		}
+
+		// Closing again should be a no-op
+		db.Close()
+	})
+}
+
+func TestFlushErrors(t *testing.T) {
+	var mu sync.Mutex
+	var failed []string
+
+	db, r := setupCache(t, Config{
+		FlushInterval: time.Hour,
+		MaxRetries:    2,
+		OnError: func(key string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.Is(err, ErrDatabaseTest) {
+				failed = append(failed, key)
+			}
+		},
+	})
+	defer db.Close()
+
+	r.Lock()
+	r.err = ErrDatabaseTest
+	r.Unlock()
+
+	_ = db.Set("key", []byte("data"))
+	_ = db.Set("dropped", []byte("data"))
+
+	err := db.Flush()
+	if !errors.Is(err, ErrDatabaseTest) {
+		t.Errorf("Flush() returned error: %v, expected %s", err, ErrDatabaseTest)
+	}
+
+	stats := db.Stats()
+	if stats.FlushErrors != 2 || stats.Queued != 2 {
+		t.Errorf("Expected failed changes to be requeued - %+v", stats)
+	}
+
+	// Failed changes are retried MaxRetries times, newer changes start counting again
+	_ = db.Set("key", []byte("data"))
+	_ = db.Flush()
+	_ = db.Flush()
+
+	mu.Lock()
+	if len(failed) != 1 || failed[0] != "dropped" {
+		t.Errorf("Expected OnError to be called once for the dropped key - %v", failed)
+	}
+	mu.Unlock()
+
+	stats = db.Stats()
+	if stats.Dropped != 1 || stats.Queued != 1 {
+		t.Errorf("Expected the change retried MaxRetries times to be dropped - %+v", stats)
+	}
+
+	r.Lock()
+	r.err = nil
+	r.Unlock()
+
+	if err := db.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %s", err)
+	}
+	if v := r.value("key"); v != "data" {
+		t.Errorf("Expected requeued change to be written, got %s", v)
+	}
+	if v := r.value("dropped"); v != "" {
+		t.Errorf("Expected dropped change to not be written, got %s", v)
+	}
+}
+
+func TestGetPendingAfterEviction(t *testing.T) {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	// Cache that never retains values
+	cache, err := mock.Dial(mock.Config{
+		GetFunc: func(_ string) ([]byte, error) { return nil, hord.ErrNil },
+		SetFunc: func(_ string, _ []byte) error { return nil },
+		DeleteFunc: func(_ string) error {
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock cache - %s", err)
+	}
+
+	db, err := Dial(Config{Database: database, Cache: cache, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+	defer db.Close()
+
+	_ = db.Set("key", []byte("pending"))
+
+	data, err := db.Get("key")
+	if err != nil || string(data) != "pending" {
+		t.Errorf("Get() returned %s - %v, expected pending", data, err)
+	}
+
+	_ = db.Delete("key")
+
+	_, err = db.Get("key")
+	if !errors.Is(err, hord.ErrNil) {
+		t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+	}
+}
+
+func TestGetDuringFlush(t *testing.T) {
+	db, r := setupCache(t, Config{FlushInterval: time.Hour})
+	defer db.Close()
+
+	_ = db.Set("key", []byte("v"))
+	_ = db.Set("other", []byte("v"))
+	if err := db.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %s", err)
+	}
+
+	_ = db.Delete("key")
+	_ = db.Set("new", []byte("v"))
+
+	// Block the database writes of the next flush
+	gate := make(chan struct{})
+	r.Lock()
+	r.gate = gate
+	r.Unlock()
+
+	flushed := make(chan error, 1)
+	go func() { flushed <- db.Flush() }()
+	waitFor(t, func() bool {
+		db.Lock()
+		defer db.Unlock()
+		return len(db.flushing) > 0
+	})
+
+	// Changes being flushed remain visible and are not replaced by the database values
+	if _, err := db.Get("key"); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("Get() during flush returned error: %v, expected %s", err, hord.ErrNil)
+	}
+	if data, err := db.GetCache().Get("key"); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("Expected the deleted value to not be cached, got %s - %v", data, err)
+	}
+
+	keys, err := db.Keys()
+	sort.Strings(keys)
+	if err != nil || len(keys) != 2 || keys[0] != "new" || keys[1] != "other" {
+		t.Errorf("Keys() during flush returned %v - %v, expected [new other]", keys, err)
+	}
+
+	if stats := db.Stats(); stats.Queued != 2 {
+		t.Errorf("Expected changes being flushed to be queued - %+v", stats)
+	}
+
+	close(gate)
+	if err := <-flushed; err != nil {
+		t.Fatalf("Flush() returned error: %s", err)
+	}
+
+	if _, err := db.Get("key"); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("Get() after flush returned error: %v, expected %s", err, hord.ErrNil)
+	}
+	if stats := db.Stats(); stats.Queued != 0 {
+		t.Errorf("Expected no queued changes after flush - %+v", stats)
+	}
+}
+
+func TestGetRacingWrite(t *testing.T) {
+	unitTests := map[string]struct {
+		flush bool
+	}{
+		"Queued":  {},
+		"Flushed": {flush: true},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			cache, err := hashmap.Dial(hashmap.Config{})
+			if err != nil {
+				t.Fatalf("Failed to connect to cache - %s", err)
+			}
+
+			// Database reads block until released, returning the value read before the key was written
+			reading := make(chan struct{}, 1)
+			release := make(chan struct{})
+			database, err := mock.Dial(mock.Config{
+				GetFunc: func(_ string) ([]byte, error) {
+					reading <- struct{}{}
+					<-release
+					return []byte("old"), nil
+				},
+				SetFunc: func(_ string, _ []byte) error { return nil },
+			})
+			if err != nil {
+				t.Fatalf("Failed to create mock database - %s", err)
+			}
+
+			db, err := Dial(Config{Database: database, Cache: cache, FlushInterval: time.Hour})
+			if err != nil {
+				t.Fatalf("Failed to connect to database - %s", err)
+			}
+			defer db.Close()
+
+			read := make(chan error, 1)
+			go func() {
+				_, err := db.Get("key")
+				read <- err
+			}()
+			<-reading
+
+			if err := db.Set("key", []byte("new")); err != nil {
+				t.Fatalf("Set() returned error: %s", err)
+			}
+			if test.flush {
+				if err := db.Flush(); err != nil {
+					t.Fatalf("Flush() returned error: %s", err)
+				}
+			}
+
+			close(release)
+			if err := <-read; err != nil {
+				t.Fatalf("Get() returned error: %s", err)
+			}
+
+			if data, err := cache.Get("key"); err != nil || string(data) != "new" {
+				t.Errorf("Expected the written value to remain cached, got %s - %v", data, err)
+			}
+		})
+	}
+}
+
+func TestCacheErrors(t *testing.T) {
+	cache, err := mock.Dial(mock.Config{
+		SetFunc:    func(_ string, _ []byte) error { return ErrCacheTest },
+		DeleteFunc: func(_ string) error { return ErrCacheTest },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock cache - %s", err)
+	}
+
+	db, err := Dial(Config{Database: &mock.Database{}, Cache: cache})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+	defer db.Close()
+
+	if err := db.Set("key", []byte("data")); !errors.Is(err, ErrCacheTest) {
+		t.Errorf("Set() returned error: %v, expected %s", err, ErrCacheTest)
+	}
+	if err := db.Delete("key"); !errors.Is(err, ErrCacheTest) {
+		t.Errorf("Delete() returned error: %v, expected %s", err, ErrCacheTest)
+	}
+	if stats := db.Stats(); stats.Queued != 0 {
+		t.Errorf("Expected failed cache writes to not be queued - %+v", stats)
+	}
+}
@@ -0,0 +1,393 @@
+/*
+Package writethrough provides a Hord database driver for a write-through cache. To use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/cache/writethrough"
+	)
+
+With a write-through cache, every Set writes the database and then the cache, keeping both in sync. The FailurePolicy
+controls what happens when the database write succeeds but the cache write fails:
+
+  - Invalidate (default) removes the key from the cache so subsequent reads fetch the persisted value.
+  - Retry retries the cache write and invalidates the key if all attempts fail.
+  - Ignore leaves the cache as-is and counts the failure, which is reported by Stats().
+
+Enabling DeleteBeforeWrite removes the key from the cache before the database is written, so the previous value is not
+left cached if the write fails partway, for example with the Ignore policy. It does not stop a concurrent Get from
+caching the previous value read from the database before the write lands, which the cache write then replaces.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the cache.
+
+	// Handle database connection
+	var database hord.Database
+	...
+
+	// Handle cache connection
+	var cache hord.Database
+	...
+
+	var db hord.Database
+	db, err := writethrough.Dial(writethrough.Config{
+		Database:          database,
+		Cache:             cache,
+		FailurePolicy:     writethrough.Retry,
+		DeleteBeforeWrite: true,
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the cache, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Set a value
+	err = db.Set("key", []byte("value"))
+	if err != nil {
+	    // Handle error
+	}
+
+	// Retrieve a value
+	value, err := db.Get("key")
+	if err != nil {
+	    // Handle error
+	}
+*/
+package writethrough
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// FailurePolicy defines how cache write failures are handled once the database write has succeeded.
+type FailurePolicy string
+
+const (
+	// Invalidate removes the key from the cache when the cache write fails.
+	Invalidate FailurePolicy = "invalidate"
+
+	// Retry retries the cache write, invalidating the key if all attempts fail.
+	Retry FailurePolicy = "retry"
+
+	// Ignore leaves the cache as-is when the cache write fails. Failures are counted within Stats.
+	Ignore FailurePolicy = "ignore"
+)
+
+const (
+	// DefaultRetries is the default number of cache write retries used by the Retry policy.
+	DefaultRetries = 3
+
+	// DefaultRetryInterval is the default time between cache write retries used by the Retry policy.
+	DefaultRetryInterval = 10 * time.Millisecond
+)
+
+// Config provides the configuration options for the WriteThrough driver.
+type Config struct {
+	Database hord.Database
+	Cache    hord.Database
+
+	// FailurePolicy defines how cache write failures are handled. Default is Invalidate.
+	FailurePolicy FailurePolicy
+
+	// Retries is the number of times a failed cache write is retried with the Retry policy. Default is 3.
+	Retries int
+
+	// RetryInterval is the time between retries with the Retry policy. Default is 10 milliseconds.
+	RetryInterval time.Duration
+
+	// DeleteBeforeWrite removes the key from the cache before writing to the database, so the previous value is not
+	// left cached when the cache write fails.
+	DeleteBeforeWrite bool
+}
+
+// WriteThrough is used to store data in a write-through caching pattern. It also satisfies the Hord database interface.
+type WriteThrough struct {
+	data              hord.Database
+	cache             hord.Database
+	policy            FailurePolicy
+	retries           int
+	retryInterval     time.Duration
+	deleteBeforeWrite bool
+
+	cacheFailures atomic.Uint64
+	retried       atomic.Uint64
+	invalidations atomic.Uint64
+	ignored       atomic.Uint64
+}
+
+// Stats provides counters describing how cache write failures were handled.
+type Stats struct {
+	// CacheFailures is the number of failed cache writes, including failed retries.
+	CacheFailures uint64
+
+	// Retries is the number of cache write retries.
+	Retries uint64
+
+	// Invalidations is the number of keys removed from the cache after a failed cache write.
+	Invalidations uint64
+
+	// Ignored is the number of failed cache writes ignored by the Ignore policy.
+	Ignored uint64
+}
+
+var (
+	// ErrInvalidPolicy is returned by Dial when the FailurePolicy is not recognized.
+	ErrInvalidPolicy = errors.New("invalid cache failure policy")
+)
+
+// Dial will create a new WriteThrough driver using the provided Config. It will return an error if either the
+// Database or Cache values in Config are nil or if the FailurePolicy is invalid.
+func Dial(cfg Config) (*WriteThrough, error) {
+	if (cfg.Database == nil) || (cfg.Cache == nil) {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	db := &WriteThrough{
+		data:              cfg.Database,
+		cache:             cfg.Cache,
+		policy:            cfg.FailurePolicy,
+		retries:           cfg.Retries,
+		retryInterval:     cfg.RetryInterval,
+		deleteBeforeWrite: cfg.DeleteBeforeWrite,
+	}
+
+	switch db.policy {
+	case "":
+		db.policy = Invalidate
+	case Invalidate, Retry, Ignore:
+	default:
+		return nil, ErrInvalidPolicy
+	}
+
+	if db.retries <= 0 {
+		db.retries = DefaultRetries
+	}
+
+	if db.retryInterval <= 0 {
+		db.retryInterval = DefaultRetryInterval
+	}
+
+	return db, nil
+}
+
+// Setup will run the Setup function for both the database and the cache.
+func (db *WriteThrough) Setup() error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.data.Setup(); err != nil {
+		return err
+	}
+
+	return db.cache.Setup()
+}
+
+// HealthCheck will run the HealthCheck function for both the database and the cache.
+func (db *WriteThrough) HealthCheck() error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	dataErr := db.data.HealthCheck()
+	cacheErr := db.cache.HealthCheck()
+
+	if dataErr != nil {
+		return errors.Join(hord.ErrHealthCheckFailure, dataErr)
+	} else if cacheErr != nil {
+		return errors.Join(hord.ErrHealthCheckFailure, cacheErr)
+	}
+
+	return nil
+}
+
+// Get will get the data from the cache database. If not found, the data is fetched from the database and stored in
+// the cache. Failures to store the data in the cache return the data along with an error wrapping hord.ErrCacheError,
+// unless the Ignore policy is used.
+func (db *WriteThrough) Get(key string) ([]byte, error) {
+	if db == nil || db.data == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := hord.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	// Check the cache first
+	data, err := db.cache.Get(key)
+	if (err != nil) && !errors.Is(err, hord.ErrNil) {
+		return nil, err
+	} else if !errors.Is(err, hord.ErrNil) {
+		return data, nil
+	}
+
+	// Check the data database
+	data, err = db.data.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the cache
+	err = db.cache.Set(key, data)
+	if err != nil {
+		db.cacheFailures.Add(1)
+		if db.policy == Ignore {
+			db.ignored.Add(1)
+			return data, nil
+		}
+		return data, fmt.Errorf("%w: %w", hord.ErrCacheError, err)
+	}
+
+	return data, nil
+}
+
+// Set will set the data in the database and then the cache. If the database write succeeds but the cache write fails,
+// the FailurePolicy is applied. An error wrapping hord.ErrCacheError is returned only if the cache could be left
+// holding a stale value.
+func (db *WriteThrough) Set(key string, data []byte) error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := hord.ValidKey(key); err != nil {
+		return err
+	}
+
+	if err := hord.ValidData(data); err != nil {
+		return err
+	}
+
+	if db.deleteBeforeWrite {
+		err := db.cache.Delete(key)
+		if err != nil {
+			return fmt.Errorf("%w: %w", hord.ErrCacheError, err)
+		}
+	}
+
+	err := db.data.Set(key, data)
+	if err != nil {
+		return err
+	}
+
+	// Update cache only if database Set was successful
+	err = db.cache.Set(key, data)
+	if err == nil {
+		return nil
+	}
+	db.cacheFailures.Add(1)
+
+	switch db.policy {
+	case Ignore:
+		db.ignored.Add(1)
+		return nil
+	case Retry:
+		for i := 0; i < db.retries; i++ {
+			<-time.After(db.retryInterval)
+			db.retried.Add(1)
+			err = db.cache.Set(key, data)
+			if err == nil {
+				return nil
+			}
+			db.cacheFailures.Add(1)
+		}
+	}
+
+	return db.invalidate(key, err)
+}
+
+// Delete will delete the data from both the data and cache databases.
+func (db *WriteThrough) Delete(key string) error {
+	if db == nil || db.data == nil || db.cache == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := hord.ValidKey(key); err != nil {
+		return err
+	}
+
+	dataErr := db.data.Delete(key)
+	cacheErr := db.cache.Delete(key)
+
+	if dataErr != nil {
+		return dataErr
+	} else if cacheErr != nil {
+		return cacheErr
+	}
+
+	return nil
+}
+
+// Keys will return the keys from the data database.
+func (db *WriteThrough) Keys() ([]string, error) {
+	if db == nil || db.data == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.data.Keys()
+}
+
+// CacheKeys will return the keys from the cache database.
+func (db *WriteThrough) CacheKeys() ([]string, error) {
+	if db == nil || db.data == nil || db.cache == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	return db.cache.Keys()
+}
+
+// Stats returns a snapshot of the cache write failure counters.
+func (db *WriteThrough) Stats() Stats {
+	return Stats{
+		CacheFailures: db.cacheFailures.Load(),
+		Retries:       db.retried.Load(),
+		Invalidations: db.invalidations.Load(),
+		Ignored:       db.ignored.Load(),
+	}
+}
+
+// GetCache will return the cache database.
+func (db *WriteThrough) GetCache() hord.Database {
+	return db.cache
+}
+
+// GetDatabase will return the data database.
+func (db *WriteThrough) GetDatabase() hord.Database {
+	return db.data
+}
+
+// Close will close the connections to both the database and the cache.
+func (db *WriteThrough) Close() {
+	if db != nil && db.data != nil && db.cache != nil {
+		db.data.Close()
+		db.cache.Close()
+	}
+}
+
+// invalidate will remove the key from the cache after a failed cache write. If the key cannot be removed, an error
+// wrapping hord.ErrCacheError and the original cache error is returned.
+func (db *WriteThrough) invalidate(key string, cause error) error {
+	err := db.cache.Delete(key)
+	if err != nil {
+		return fmt.Errorf("%w: %w", hord.ErrCacheError, errors.Join(cause, err))
+	}
+
+	db.invalidations.Add(1)
+	return nil
+}
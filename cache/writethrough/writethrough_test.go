@@ -0,0 +1,337 @@
+package writethrough
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// Test Errors used for testing purposes
+var (
+	ErrDatabaseTest = errors.New("database error")
+	ErrCacheTest    = errors.New("cache error")
+)
+
+// setupCache is a helper function to create a new Cache driver using the provided database and cache Config.
+func setupCache(cfg Config, cacheConfig mock.Config, databaseConfig mock.Config) (*WriteThrough, error) {
+	database, err := mock.Dial(databaseConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := mock.Dial(cacheConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Database = database
+	cfg.Cache = cache
+	return Dial(cfg)
+}
+
+func TestDial(t *testing.T) {
+	unitTests := map[string]struct {
+		config        Config
+		expectedError error
+	}{
+		"No Config": {
+			config:        Config{},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"No Database": {
+			config: Config{
+				Cache: &mock.Database{},
+			},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"No Cache": {
+			config: Config{
+				Database: &mock.Database{},
+			},
+			expectedError: hord.ErrInvalidDatabase,
+		},
+		"Invalid Policy": {
+			config: Config{
+				Database:      &mock.Database{},
+				Cache:         &mock.Database{},
+				FailurePolicy: "invalid",
+			},
+			expectedError: ErrInvalidPolicy,
+		},
+		"Happy Path": {
+			config: Config{
+				Database: &mock.Database{},
+				Cache:    &mock.Database{},
+			},
+			expectedError: nil,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Dial(test.config)
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Dial(%v) returned error: %s, expected %s", test.config, err, test.expectedError)
+			}
+		})
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		db, err := Dial(Config{Database: &mock.Database{}, Cache: &mock.Database{}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+		if db.policy != Invalidate || db.retries != DefaultRetries || db.retryInterval != DefaultRetryInterval {
+			t.Errorf("Dial() did not set defaults - %+v", db)
+		}
+	})
+}
+
+func TestSet(t *testing.T) {
+	unitTests := map[string]struct {
+		config             Config
+		cacheSetFailures   int
+		cacheDeleteError   error
+		databaseError      error
+		expectedError      error
+		expectedCacheSets  int
+		expectedCacheValue string
+		expectedStats      Stats
+	}{
+		"Happy Path": {
+			expectedCacheSets:  1,
+			expectedCacheValue: "data",
+		},
+		"Database Error": {
+			databaseError: ErrDatabaseTest,
+			expectedError: ErrDatabaseTest,
+		},
+		"Invalidate": {
+			config:            Config{FailurePolicy: Invalidate},
+			cacheSetFailures:  1,
+			expectedCacheSets: 1,
+			expectedStats:     Stats{CacheFailures: 1, Invalidations: 1},
+		},
+		"Invalidate Failure": {
+			config:            Config{FailurePolicy: Invalidate},
+			cacheSetFailures:  1,
+			cacheDeleteError:  ErrCacheTest,
+			expectedError:     hord.ErrCacheError,
+			expectedCacheSets: 1,
+			expectedStats:     Stats{CacheFailures: 1},
+		},
+		"Retry Succeeds": {
+			config:             Config{FailurePolicy: Retry, Retries: 3},
+			cacheSetFailures:   2,
+			expectedCacheSets:  3,
+			expectedCacheValue: "data",
+			expectedStats:      Stats{CacheFailures: 2, Retries: 2},
+		},
+		"Retry Exhausted": {
+			config:            Config{FailurePolicy: Retry, Retries: 2},
+			cacheSetFailures:  5,
+			expectedCacheSets: 3,
+			expectedStats:     Stats{CacheFailures: 3, Retries: 2, Invalidations: 1},
+		},
+		"Ignore": {
+			config:            Config{FailurePolicy: Ignore},
+			cacheSetFailures:  1,
+			expectedCacheSets: 1,
+			expectedStats:     Stats{CacheFailures: 1, Ignored: 1},
+		},
+		"Delete Before Write": {
+			config:             Config{DeleteBeforeWrite: true},
+			expectedCacheSets:  1,
+			expectedCacheValue: "data",
+		},
+		"Delete Before Write Failure": {
+			config:           Config{DeleteBeforeWrite: true},
+			cacheDeleteError: ErrCacheTest,
+			expectedError:    hord.ErrCacheError,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			cacheSets := 0
+			cacheValue := ""
+			databaseWritten := false
+
+			cacheConfig := mock.Config{
+				SetFunc: func(_ string, data []byte) error {
+					cacheSets++
+					if cacheSets <= test.cacheSetFailures {
+						return ErrCacheTest
+					}
+					cacheValue = string(data)
+					return nil
+				},
+				DeleteFunc: func(_ string) error {
+					if !databaseWritten && !test.config.DeleteBeforeWrite {
+						t.Errorf("Cache deleted before database write")
+					}
+					return test.cacheDeleteError
+				},
+			}
+			databaseConfig := mock.Config{
+				SetFunc: func(_ string, _ []byte) error {
+					if test.databaseError != nil {
+						return test.databaseError
+					}
+					databaseWritten = true
+					return nil
+				},
+			}
+
+			db, err := setupCache(test.config, cacheConfig, databaseConfig)
+			if err != nil {
+				t.Fatalf("Failed to connect to database - %s", err)
+			}
+
+			err = db.Set("key", []byte("data"))
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Set() returned error: %s, expected %s", err, test.expectedError)
+			}
+			if cacheSets != test.expectedCacheSets {
+				t.Errorf("Unexpected number of cache writes - got %d, expected %d", cacheSets, test.expectedCacheSets)
+			}
+			if cacheValue != test.expectedCacheValue {
+				t.Errorf("Unexpected cache value - got %s, expected %s", cacheValue, test.expectedCacheValue)
+			}
+			if stats := db.Stats(); stats != test.expectedStats {
+				t.Errorf("Unexpected stats - got %+v, expected %+v", stats, test.expectedStats)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	cacheConfig := mock.Config{
+		GetFunc: func(key string) ([]byte, error) {
+			switch key {
+			case "cache-hit":
+				return []byte("cache-data"), nil
+			case "cache-error":
+				return nil, ErrCacheTest
+			}
+			return nil, hord.ErrNil
+		},
+		SetFunc: func(key string, _ []byte) error {
+			if key == "cache-write-error" {
+				return ErrCacheTest
+			}
+			return nil
+		},
+	}
+	databaseConfig := mock.Config{
+		GetFunc: func(key string) ([]byte, error) {
+			if key == "database-error" {
+				return nil, ErrDatabaseTest
+			}
+			return []byte("database-data"), nil
+		},
+	}
+
+	unitTests := map[string]struct {
+		key           string
+		policy        FailurePolicy
+		expectedError error
+		expectedData  []byte
+	}{
+		"Cache Hit": {
+			key:          "cache-hit",
+			expectedData: []byte("cache-data"),
+		},
+		"Cache Miss": {
+			key:          "cache-miss",
+			expectedData: []byte("database-data"),
+		},
+		"Cache Error": {
+			key:           "cache-error",
+			expectedError: ErrCacheTest,
+		},
+		"Cache Write Error": {
+			key:           "cache-write-error",
+			expectedError: hord.ErrCacheError,
+			expectedData:  []byte("database-data"),
+		},
+		"Cache Write Error Ignored": {
+			key:          "cache-write-error",
+			policy:       Ignore,
+			expectedData: []byte("database-data"),
+		},
+		"Database Error": {
+			key:           "database-error",
+			expectedError: ErrDatabaseTest,
+		},
+		"Invalid Key": {
+			key:           "",
+			expectedError: hord.ErrInvalidKey,
+		},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, err := setupCache(Config{FailurePolicy: test.policy}, cacheConfig, databaseConfig)
+			if err != nil {
+				t.Fatalf("Failed to connect to database - %s", err)
+			}
+
+			data, err := db.Get(test.key)
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Get(%s) returned error: %s, expected %s", test.key, err, test.expectedError)
+			}
+			if string(data) != string(test.expectedData) {
+				t.Errorf("Get(%s) returned data: %s, expected %s", test.key, data, test.expectedData)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	unitTests := map[string]struct {
+		databaseError error
+		cacheError    error
+		expectedError error
+	}{
+		"Database Error": {
+			databaseError: ErrDatabaseTest,
+			expectedError: ErrDatabaseTest,
+		},
+		"Cache Error": {
+			cacheError:    ErrCacheTest,
+			expectedError: ErrCacheTest,
+		},
+		"Happy Path": {},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, err := setupCache(Config{},
+				mock.Config{DeleteFunc: func(_ string) error { return test.cacheError }},
+				mock.Config{DeleteFunc: func(_ string) error { return test.databaseError }},
+			)
+			if err != nil {
+				t.Fatalf("Failed to connect to database - %s", err)
+			}
+
+			err = db.Delete("key")
+			if !errors.Is(err, test.expectedError) {
+				t.Errorf("Delete() returned error: %s, expected %s", err, test.expectedError)
+			}
+		})
+	}
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		db, err := setupCache(Config{}, mock.Config{}, mock.Config{})
+		if err != nil {
+			t.Fatalf("Failed to connect to database - %s", err)
+		}
+
+		if err := db.Delete(""); !errors.Is(err, hord.ErrInvalidKey) {
+			t.Errorf("Delete() returned error: %s, expected %s", err, hord.ErrInvalidKey)
+		}
+	})
+}
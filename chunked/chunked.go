@@ -0,0 +1,456 @@
+/*
+Package chunked provides a Hord database driver that splits large values into multiple chunks. To use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/chunked"
+	)
+
+Values larger than the configured threshold are split into numbered chunk keys and a manifest is stored under the
+original key. Get reassembles chunked values, Delete removes all chunks, and Keys hides chunk keys from callers.
+
+Chunks are written before the manifest and each write uses a new chunk generation, so a failed or interrupted Set
+never replaces a previously stored value with a partially written one.
+
+The Chunked driver also implements hord.Streamer, reading and writing values one chunk at a time. DialStreamer()
+returns a native hord.Streamer when the underlying database supports streaming and falls back to chunked streaming
+for databases that only support byte slices.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the chunked driver.
+
+	// Handle database connection
+	var database hord.Database
+	...
+
+	var db hord.Database
+	db, err := chunked.Dial(chunked.Config{
+		Database:  database,
+		Threshold: 512 * 1024,
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the chunked driver, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Handle database connection
+	var database hord.Database
+	database, err := nats.Dial(nats.Config{})
+	if err != nil {
+		// Handle connection error
+	}
+
+	// Wrap the database with the chunked driver
+	db, err := chunked.Dial(chunked.Config{
+		Database: database,
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+	// Set a large value
+	err = db.Set("key", largeValue)
+	if err != nil {
+	    // Handle error
+	}
+
+	// Retrieve a large value
+	value, err := db.Get("key")
+	if err != nil {
+	    // Handle error
+	}
+*/
+package chunked
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// Config provides the configuration options for the Chunked driver.
+type Config struct {
+	// Database is the underlying database used to store manifests and chunks.
+	Database hord.Database
+
+	// Threshold is the size, in bytes, above which values are split into chunks. Default is 512 KB.
+	Threshold int
+
+	// ChunkSize is the maximum size, in bytes, of each chunk. Default is the value of Threshold.
+	ChunkSize int
+
+	// Prefix is the key prefix used for chunk keys. Keys using this prefix are hidden from Keys() and cannot be
+	// written by callers. Default is "_chunk.".
+	Prefix string
+}
+
+// Chunked is used to store large values as multiple chunks. It also satisfies the Hord database interface.
+type Chunked struct {
+	data      hord.Database
+	threshold int
+	chunkSize int
+	prefix    string
+}
+
+// Manifest describes a chunked value. Manifests are stored under the original key in place of the value.
+type Manifest struct {
+	// Generation uniquely identifies the set of chunks written for this value.
+	Generation string `json:"generation"`
+
+	// Chunks is the number of chunks that make up the value.
+	Chunks int `json:"chunks"`
+
+	// Size is the total size of the value in bytes.
+	Size int `json:"size"`
+
+	// Checksum is the hex encoded SHA-256 checksum of the value.
+	Checksum string `json:"checksum"`
+}
+
+const (
+	// DefaultThreshold is the default size, in bytes, above which values are chunked.
+	DefaultThreshold = 512 * 1024
+
+	// DefaultPrefix is the default key prefix used for chunk keys.
+	DefaultPrefix = "_chunk."
+)
+
+// manifestHeader identifies values stored as manifests.
+var manifestHeader = []byte("\x00hord-chunked-manifest\x00")
+
+var (
+	// ErrInvalidManifest is returned when a stored manifest cannot be decoded.
+	ErrInvalidManifest = errors.New("invalid chunk manifest")
+
+	// ErrMissingChunk is returned when a chunk referenced by a manifest does not exist.
+	ErrMissingChunk = errors.New("chunk referenced by manifest is missing")
+
+	// ErrChecksumMismatch is returned when reassembled chunks do not match the manifest checksum.
+	ErrChecksumMismatch = errors.New("chunk checksum mismatch")
+)
+
+// Dial will create a new Chunked driver using the provided Config. It will return an error if the Database is nil.
+func Dial(cfg Config) (*Chunked, error) {
+	if cfg.Database == nil {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	db := &Chunked{
+		data:      cfg.Database,
+		threshold: cfg.Threshold,
+		chunkSize: cfg.ChunkSize,
+		prefix:    cfg.Prefix,
+	}
+
+	if db.threshold <= 0 {
+		db.threshold = DefaultThreshold
+	}
+
+	if db.chunkSize <= 0 {
+		db.chunkSize = db.threshold
+	}
+
+	if db.prefix == "" {
+		db.prefix = DefaultPrefix
+	}
+
+	return db, nil
+}
+
+// Setup will run the Setup function for the underlying database.
+func (db *Chunked) Setup() error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.Setup()
+}
+
+// HealthCheck will run the HealthCheck function for the underlying database.
+func (db *Chunked) HealthCheck() error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.HealthCheck()
+}
+
+// Get will fetch the value for the specified key, reassembling chunks if the value was chunked.
+func (db *Chunked) Get(key string) ([]byte, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.validKey(key); err != nil {
+		return nil, err
+	}
+
+	data, err := db.data.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isManifest(data) {
+		return data, nil
+	}
+
+	m, err := decodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.assemble(key, m)
+}
+
+// Set will store the value for the specified key. Values larger than the threshold are split into chunks and a
+// manifest is stored under the key once all chunks are written.
+func (db *Chunked) Set(key string, data []byte) error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.validKey(key); err != nil {
+		return err
+	}
+
+	if err := hord.ValidData(data); err != nil {
+		return err
+	}
+
+	// Look up any existing manifest so old chunks can be removed after the write
+	previous, err := db.manifest(key)
+	if err != nil && !errors.Is(err, hord.ErrNil) && !errors.Is(err, ErrInvalidManifest) {
+		return err
+	}
+
+	// Small values are stored as-is, unless they could be mistaken for a manifest
+	if len(data) <= db.threshold && !isManifest(data) {
+		err = db.data.Set(key, data)
+		if err != nil {
+			return err
+		}
+		db.removeChunks(key, previous)
+		return nil
+	}
+
+	sum := sha256.Sum256(data)
+	m := Manifest{
+		Generation: strconv.FormatInt(time.Now().UnixNano(), 36),
+		Chunks:     (len(data) + db.chunkSize - 1) / db.chunkSize,
+		Size:       len(data),
+		Checksum:   hex.EncodeToString(sum[:]),
+	}
+
+	// Write chunks before the manifest to avoid exposing partially written values
+	for i := 0; i < m.Chunks; i++ {
+		end := (i + 1) * db.chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		err := db.data.Set(db.chunkKey(key, m.Generation, i), data[i*db.chunkSize:end])
+		if err != nil {
+			db.removeChunks(key, &Manifest{Generation: m.Generation, Chunks: i})
+			return fmt.Errorf("unable to write chunk %d: %w", i, err)
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		db.removeChunks(key, &m)
+		return fmt.Errorf("unable to encode manifest: %w", err)
+	}
+
+	err = db.data.Set(key, append(append([]byte{}, manifestHeader...), b...))
+	if err != nil {
+		db.removeChunks(key, &m)
+		return fmt.Errorf("unable to write manifest: %w", err)
+	}
+
+	db.removeChunks(key, previous)
+	return nil
+}
+
+// Delete will delete the value for the specified key, including any chunks.
+func (db *Chunked) Delete(key string) error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.validKey(key); err != nil {
+		return err
+	}
+
+	m, err := db.manifest(key)
+	if err != nil && !errors.Is(err, hord.ErrNil) && !errors.Is(err, ErrInvalidManifest) {
+		return err
+	}
+
+	// Delete the manifest first so readers never see a manifest with missing chunks
+	err = db.data.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	if m == nil {
+		return nil
+	}
+
+	var errs []error
+	for i := 0; i < m.Chunks; i++ {
+		if err := db.data.Delete(db.chunkKey(key, m.Generation, i)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Keys will return the keys from the underlying database, excluding chunk keys.
+func (db *Chunked) Keys() ([]string, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	keys, err := db.data.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []string
+	for _, k := range keys {
+		if !strings.HasPrefix(k, db.prefix) {
+			filtered = append(filtered, k)
+		}
+	}
+
+	return filtered, nil
+}
+
+// Close will close the underlying database.
+func (db *Chunked) Close() {
+	if db != nil && db.data != nil {
+		db.data.Close()
+	}
+}
+
+// GetDatabase will return the underlying database.
+func (db *Chunked) GetDatabase() hord.Database {
+	return db.data
+}
+
+// validKey will validate the key and reject keys using the chunk prefix.
+func (db *Chunked) validKey(key string) error {
+	if err := hord.ValidKey(key); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(key, db.prefix) {
+		return hord.ErrKeyReserved
+	}
+
+	return nil
+}
+
+// chunkKey returns the key used to store a single chunk.
+func (db *Chunked) chunkKey(key, generation string, index int) string {
+	return fmt.Sprintf("%s%s.%s.%d", db.prefix, key, generation, index)
+}
+
+// manifest will fetch the manifest stored under key. A nil Manifest is returned if the key does not hold a manifest.
+func (db *Chunked) manifest(key string) (*Manifest, error) {
+	data, err := db.data.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isManifest(data) {
+		return nil, nil
+	}
+
+	m, err := decodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// assemble will fetch and join all chunks described by the manifest, verifying the result against the manifest.
+func (db *Chunked) assemble(key string, m Manifest) ([]byte, error) {
+	data := make([]byte, 0, m.Size)
+	for i := 0; i < m.Chunks; i++ {
+		chunk, err := db.data.Get(db.chunkKey(key, m.Generation, i))
+		if errors.Is(err, hord.ErrNil) {
+			return nil, fmt.Errorf("%w: chunk %d", ErrMissingChunk, i)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch chunk %d: %w", i, err)
+		}
+		data = append(data, chunk...)
+	}
+
+	sum := sha256.Sum256(data)
+	if len(data) != m.Size || hex.EncodeToString(sum[:]) != m.Checksum {
+		return nil, ErrChecksumMismatch
+	}
+
+	return data, nil
+}
+
+// removeChunks will delete, on a best-effort basis, the chunks described by the manifest.
+func (db *Chunked) removeChunks(key string, m *Manifest) {
+	if m == nil {
+		return
+	}
+
+	for i := 0; i < m.Chunks; i++ {
+		_ = db.data.Delete(db.chunkKey(key, m.Generation, i))
+	}
+}
+
+// isManifest returns true if the data holds an encoded manifest.
+func isManifest(data []byte) bool {
+	return bytes.HasPrefix(data, manifestHeader)
+}
+
+// decodeManifest will decode and validate an encoded manifest.
+func decodeManifest(data []byte) (Manifest, error) {
+	var m Manifest
+	err := json.Unmarshal(bytes.TrimPrefix(data, manifestHeader), &m)
+	if err != nil {
+		return m, fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+
+	if m.Generation == "" || m.Chunks < 1 || m.Size < 1 || m.Checksum == "" {
+		return m, ErrInvalidManifest
+	}
+
+	return m, nil
+}
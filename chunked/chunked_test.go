@@ -0,0 +1,259 @@
+package chunked
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// ErrDatabaseTest is used for testing purposes
+var ErrDatabaseTest = errors.New("database error")
+
+// setupChunked is a helper function to create a new Chunked driver backed by a hashmap database.
+func setupChunked(t *testing.T) (*Chunked, *hashmap.Database) {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	db, err := Dial(Config{
+		Database:  database,
+		Threshold: 8,
+		ChunkSize: 4,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create chunked driver - %s", err)
+	}
+
+	return db, database
+}
+
+// chunkKeys returns the chunk keys stored within the database.
+func chunkKeys(t *testing.T, database hord.Database) []string {
+	keys, err := database.Keys()
+	if err != nil {
+		t.Fatalf("Unexpected error fetching keys - %s", err)
+	}
+
+	var chunks []string
+	for _, k := range keys {
+		if strings.HasPrefix(k, DefaultPrefix) {
+			chunks = append(chunks, k)
+		}
+	}
+	return chunks
+}
+
+func TestDial(t *testing.T) {
+	t.Run("No Database", func(t *testing.T) {
+		_, err := Dial(Config{})
+		if !errors.Is(err, hord.ErrInvalidDatabase) {
+			t.Errorf("Dial() returned error: %s, expected %s", err, hord.ErrInvalidDatabase)
+		}
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		db, err := Dial(Config{Database: &mock.Database{}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+		if db.threshold != DefaultThreshold || db.chunkSize != DefaultThreshold || db.prefix != DefaultPrefix {
+			t.Errorf("Dial() did not set defaults - %+v", db)
+		}
+	})
+}
+
+func TestChunking(t *testing.T) {
+	db, database := setupChunked(t)
+	value := []byte("this value is larger than the threshold")
+
+	t.Run("Set Large Value", func(t *testing.T) {
+		err := db.Set("large", value)
+		if err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		if n := len(chunkKeys(t, database)); n != 10 {
+			t.Errorf("Unexpected number of chunks - got %d, expected 10", n)
+		}
+	})
+
+	t.Run("Get Large Value", func(t *testing.T) {
+		data, err := db.Get("large")
+		if err != nil {
+			t.Fatalf("Get() returned error: %s", err)
+		}
+		if !bytes.Equal(data, value) {
+			t.Errorf("Get() returned data: %s, expected %s", data, value)
+		}
+	})
+
+	t.Run("Keys Hides Chunks", func(t *testing.T) {
+		keys, err := db.Keys()
+		if err != nil {
+			t.Fatalf("Keys() returned error: %s", err)
+		}
+		if len(keys) != 1 || keys[0] != "large" {
+			t.Errorf("Keys() returned: %v, expected [large]", keys)
+		}
+	})
+
+	t.Run("Overwrite Removes Old Chunks", func(t *testing.T) {
+		err := db.Set("large", []byte("small"))
+		if err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		if n := len(chunkKeys(t, database)); n != 0 {
+			t.Errorf("Unexpected number of chunks - got %d, expected 0", n)
+		}
+	})
+
+	t.Run("Delete Removes Chunks", func(t *testing.T) {
+		err := db.Set("large", value)
+		if err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		err = db.Delete("large")
+		if err != nil {
+			t.Fatalf("Delete() returned error: %s", err)
+		}
+
+		keys, err := database.Keys()
+		if err != nil {
+			t.Fatalf("Keys() returned error: %s", err)
+		}
+		if len(keys) != 0 {
+			t.Errorf("Unexpected keys after Delete() - %v", keys)
+		}
+	})
+
+	t.Run("Small Value Resembling Manifest", func(t *testing.T) {
+		v := append(append([]byte{}, manifestHeader...), '{', '}')
+		err := db.Set("manifest", v)
+		if err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		data, err := db.Get("manifest")
+		if err != nil {
+			t.Fatalf("Get() returned error: %s", err)
+		}
+		if !bytes.Equal(data, v) {
+			t.Errorf("Get() returned data: %v, expected %v", data, v)
+		}
+	})
+
+	t.Run("Reserved Key", func(t *testing.T) {
+		err := db.Set(DefaultPrefix+"key", []byte("data"))
+		if !errors.Is(err, hord.ErrKeyReserved) {
+			t.Errorf("Set() returned error: %s, expected %s", err, hord.ErrKeyReserved)
+		}
+	})
+}
+
+func TestCorruption(t *testing.T) {
+	value := []byte("this value is larger than the threshold")
+
+	t.Run("Missing Chunk", func(t *testing.T) {
+		db, database := setupChunked(t)
+		if err := db.Set("large", value); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		if err := database.Delete(chunkKeys(t, database)[0]); err != nil {
+			t.Fatalf("Unexpected error deleting chunk - %s", err)
+		}
+
+		_, err := db.Get("large")
+		if !errors.Is(err, ErrMissingChunk) {
+			t.Errorf("Get() returned error: %s, expected %s", err, ErrMissingChunk)
+		}
+	})
+
+	t.Run("Checksum Mismatch", func(t *testing.T) {
+		db, database := setupChunked(t)
+		if err := db.Set("large", value); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		if err := database.Set(chunkKeys(t, database)[0], []byte("xxxx")); err != nil {
+			t.Fatalf("Unexpected error modifying chunk - %s", err)
+		}
+
+		_, err := db.Get("large")
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("Get() returned error: %s, expected %s", err, ErrChecksumMismatch)
+		}
+	})
+
+	t.Run("Invalid Manifest", func(t *testing.T) {
+		db, database := setupChunked(t)
+		if err := database.Set("large", append(append([]byte{}, manifestHeader...), []byte("not json")...)); err != nil {
+			t.Fatalf("Unexpected error writing manifest - %s", err)
+		}
+
+		_, err := db.Get("large")
+		if !errors.Is(err, ErrInvalidManifest) {
+			t.Errorf("Get() returned error: %s, expected %s", err, ErrInvalidManifest)
+		}
+	})
+
+	t.Run("Partial Write", func(t *testing.T) {
+		database, err := hashmap.Dial(hashmap.Config{})
+		if err != nil {
+			t.Fatalf("Failed to connect to database - %s", err)
+		}
+
+		writes := 0
+		failing, err := mock.Dial(mock.Config{
+			GetFunc:    database.Get,
+			DeleteFunc: database.Delete,
+			KeysFunc:   database.Keys,
+			SetFunc: func(key string, data []byte) error {
+				writes++
+				if writes > 12 {
+					return ErrDatabaseTest
+				}
+				return database.Set(key, data)
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create mock database - %s", err)
+		}
+
+		db, err := Dial(Config{Database: failing, Threshold: 8, ChunkSize: 4})
+		if err != nil {
+			t.Fatalf("Failed to create chunked driver - %s", err)
+		}
+
+		// First write stores 10 chunks and a manifest
+		if err := db.Set("large", value); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		// Second write fails part way through writing chunks
+		err = db.Set("large", bytes.ToUpper(value))
+		if !errors.Is(err, ErrDatabaseTest) {
+			t.Errorf("Set() returned error: %s, expected %s", err, ErrDatabaseTest)
+		}
+
+		data, err := db.Get("large")
+		if err != nil {
+			t.Fatalf("Get() returned error: %s", err)
+		}
+		if !bytes.Equal(data, value) {
+			t.Errorf("Get() returned data: %s, expected %s", data, value)
+		}
+
+		if n := len(chunkKeys(t, database)); n != 10 {
+			t.Errorf("Unexpected number of chunks - got %d, expected 10", n)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package chunked
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// DialStreamer returns a hord.Streamer for the Database within the provided Config. Databases that natively
+// implement hord.Streamer are returned as-is, while other databases are wrapped with a Chunked driver so that values
+// are streamed one chunk at a time.
+func DialStreamer(cfg Config) (hord.Streamer, error) {
+	if cfg.Database == nil {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	if s, ok := cfg.Database.(hord.Streamer); ok {
+		return s, nil
+	}
+
+	return Dial(cfg)
+}
+
+// OpenReader opens a reader for the value of the specified key. Chunked values are fetched one chunk at a time as
+// the reader is consumed, and the checksum is verified once the final chunk has been read.
+func (db *Chunked) OpenReader(key string) (io.ReadCloser, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.validKey(key); err != nil {
+		return nil, err
+	}
+
+	data, err := db.data.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isManifest(data) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	m, err := decodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reader{db: db, key: key, manifest: m, hash: sha256.New()}, nil
+}
+
+// OpenWriter opens a writer for the value of the specified key. Data is written to chunk keys as each chunk fills
+// and the manifest is written when the writer is closed, so the value is never held entirely in memory.
+func (db *Chunked) OpenWriter(key string) (io.WriteCloser, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.validKey(key); err != nil {
+		return nil, err
+	}
+
+	return &writer{
+		db:         db,
+		key:        key,
+		generation: strconv.FormatInt(time.Now().UnixNano(), 36),
+		hash:       sha256.New(),
+	}, nil
+}
+
+// reader reads a chunked value one chunk at a time.
+type reader struct {
+	db       *Chunked
+	key      string
+	manifest Manifest
+	hash     hash.Hash
+	buf      []byte
+	next     int
+	size     int
+	err      error
+}
+
+// Read reads data from the current chunk, fetching the next chunk once the current chunk is consumed.
+func (r *reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	for len(r.buf) == 0 {
+		if r.next >= r.manifest.Chunks {
+			r.err = r.verify()
+			return 0, r.err
+		}
+
+		chunk, err := r.db.data.Get(r.db.chunkKey(r.key, r.manifest.Generation, r.next))
+		if errors.Is(err, hord.ErrNil) {
+			r.err = fmt.Errorf("%w: chunk %d", ErrMissingChunk, r.next)
+			return 0, r.err
+		}
+		if err != nil {
+			r.err = fmt.Errorf("unable to fetch chunk %d: %w", r.next, err)
+			return 0, r.err
+		}
+
+		r.next++
+		r.size += len(chunk)
+		r.hash.Write(chunk)
+		r.buf = chunk
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Close closes the reader.
+func (r *reader) Close() error {
+	r.err = io.ErrClosedPipe
+	r.buf = nil
+	return nil
+}
+
+// verify checks the read value against the manifest, returning io.EOF if the value is valid.
+func (r *reader) verify() error {
+	if r.size != r.manifest.Size || hex.EncodeToString(r.hash.Sum(nil)) != r.manifest.Checksum {
+		return ErrChecksumMismatch
+	}
+	return io.EOF
+}
+
+// writer writes a value as a series of chunks followed by a manifest.
+type writer struct {
+	db         *Chunked
+	key        string
+	generation string
+	hash       hash.Hash
+	buf        []byte
+	chunks     int
+	size       int
+	err        error
+	closed     bool
+}
+
+// Write buffers data and writes a chunk each time the buffer reaches the chunk size.
+func (w *writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := w.db.chunkSize - len(w.buf)
+		if n > len(p) {
+			n = len(p)
+		}
+
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		written += n
+
+		if len(w.buf) == w.db.chunkSize {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// Close writes any remaining data and the manifest. Values that fit within the threshold are stored as-is.
+func (w *writer) Close() error {
+	if w.closed {
+		return io.ErrClosedPipe
+	}
+	w.closed = true
+
+	if w.err != nil {
+		return w.err
+	}
+
+	// Values that never filled a chunk are stored using the standard Set logic
+	if w.chunks == 0 {
+		return w.db.Set(w.key, w.buf)
+	}
+
+	if len(w.buf) > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+
+	previous, err := w.db.manifest(w.key)
+	if err != nil && !errors.Is(err, hord.ErrNil) && !errors.Is(err, ErrInvalidManifest) {
+		w.abort()
+		return err
+	}
+
+	m := Manifest{
+		Generation: w.generation,
+		Chunks:     w.chunks,
+		Size:       w.size,
+		Checksum:   hex.EncodeToString(w.hash.Sum(nil)),
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		w.abort()
+		return fmt.Errorf("unable to encode manifest: %w", err)
+	}
+
+	err = w.db.data.Set(w.key, append(append([]byte{}, manifestHeader...), b...))
+	if err != nil {
+		w.abort()
+		return fmt.Errorf("unable to write manifest: %w", err)
+	}
+
+	w.db.removeChunks(w.key, previous)
+	return nil
+}
+
+// flush writes the buffered data as the next chunk.
+func (w *writer) flush() error {
+	err := w.db.data.Set(w.db.chunkKey(w.key, w.generation, w.chunks), w.buf)
+	if err != nil {
+		w.err = fmt.Errorf("unable to write chunk %d: %w", w.chunks, err)
+		w.abort()
+		return w.err
+	}
+
+	w.hash.Write(w.buf)
+	w.size += len(w.buf)
+	w.chunks++
+	w.buf = nil
+	return nil
+}
+
+// abort removes any chunks written by the writer.
+func (w *writer) abort() {
+	w.db.removeChunks(w.key, &Manifest{Generation: w.generation, Chunks: w.chunks})
+}
@@ -0,0 +1,213 @@
+package chunked
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// nativeStreamer is a database that natively implements hord.Streamer for testing purposes.
+type nativeStreamer struct {
+	mock.Database
+}
+
+func (s *nativeStreamer) OpenReader(_ string) (io.ReadCloser, error) {
+	return nil, hord.ErrNil
+}
+
+func (s *nativeStreamer) OpenWriter(_ string) (io.WriteCloser, error) {
+	return nil, hord.ErrNoDial
+}
+
+func TestDialStreamer(t *testing.T) {
+	t.Run("No Database", func(t *testing.T) {
+		_, err := DialStreamer(Config{})
+		if !errors.Is(err, hord.ErrInvalidDatabase) {
+			t.Errorf("DialStreamer() returned error: %s, expected %s", err, hord.ErrInvalidDatabase)
+		}
+	})
+
+	t.Run("Native Streamer", func(t *testing.T) {
+		native := &nativeStreamer{}
+		s, err := DialStreamer(Config{Database: native})
+		if err != nil {
+			t.Fatalf("DialStreamer() returned error: %s", err)
+		}
+		if s != native {
+			t.Errorf("DialStreamer() did not return the native streamer")
+		}
+	})
+
+	t.Run("Chunked Fallback", func(t *testing.T) {
+		s, err := DialStreamer(Config{Database: &mock.Database{}})
+		if err != nil {
+			t.Fatalf("DialStreamer() returned error: %s", err)
+		}
+		if _, ok := s.(*Chunked); !ok {
+			t.Errorf("DialStreamer() returned %T, expected *Chunked", s)
+		}
+	})
+}
+
+func TestStreaming(t *testing.T) {
+	db, database := setupChunked(t)
+	value := []byte("this value is streamed in multiple chunks")
+
+	t.Run("Write Stream", func(t *testing.T) {
+		w, err := db.OpenWriter("stream")
+		if err != nil {
+			t.Fatalf("OpenWriter() returned error: %s", err)
+		}
+
+		for _, part := range bytes.SplitAfter(value, []byte(" ")) {
+			if _, err := w.Write(part); err != nil {
+				t.Fatalf("Write() returned error: %s", err)
+			}
+		}
+
+		// Manifest should not exist until the writer is closed
+		if _, err := database.Get("stream"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected ErrNil before writer is closed, got %v", err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() returned error: %s", err)
+		}
+
+		if n := len(chunkKeys(t, database)); n != 11 {
+			t.Errorf("Unexpected number of chunks - got %d, expected 11", n)
+		}
+	})
+
+	t.Run("Read Stream", func(t *testing.T) {
+		r, err := db.OpenReader("stream")
+		if err != nil {
+			t.Fatalf("OpenReader() returned error: %s", err)
+		}
+		defer r.Close() // nolint:errcheck
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("ReadAll() returned error: %s", err)
+		}
+		if !bytes.Equal(data, value) {
+			t.Errorf("Data mismatch - got %s, expected %s", data, value)
+		}
+	})
+
+	t.Run("Get Streamed Value", func(t *testing.T) {
+		data, err := db.Get("stream")
+		if err != nil {
+			t.Fatalf("Get() returned error: %s", err)
+		}
+		if !bytes.Equal(data, value) {
+			t.Errorf("Data mismatch - got %s, expected %s", data, value)
+		}
+	})
+
+	t.Run("Small Stream", func(t *testing.T) {
+		w, err := db.OpenWriter("small")
+		if err != nil {
+			t.Fatalf("OpenWriter() returned error: %s", err)
+		}
+		_, _ = w.Write([]byte("abc"))
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() returned error: %s", err)
+		}
+
+		data, err := database.Get("small")
+		if err != nil || string(data) != "abc" {
+			t.Errorf("Expected small value to be stored as-is, got %s - %v", data, err)
+		}
+	})
+
+	t.Run("Empty Stream", func(t *testing.T) {
+		w, err := db.OpenWriter("empty")
+		if err != nil {
+			t.Fatalf("OpenWriter() returned error: %s", err)
+		}
+		if err := w.Close(); !errors.Is(err, hord.ErrInvalidData) {
+			t.Errorf("Close() returned error: %v, expected %s", err, hord.ErrInvalidData)
+		}
+	})
+
+	t.Run("Read Missing Key", func(t *testing.T) {
+		_, err := db.OpenReader("missing")
+		if !errors.Is(err, hord.ErrNil) {
+			t.Errorf("OpenReader() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+	})
+
+	t.Run("Corrupted Stream", func(t *testing.T) {
+		if err := database.Set(chunkKeys(t, database)[0], []byte("xxxx")); err != nil {
+			t.Fatalf("Unexpected error modifying chunk - %s", err)
+		}
+
+		r, err := db.OpenReader("stream")
+		if err != nil {
+			t.Fatalf("OpenReader() returned error: %s", err)
+		}
+		defer r.Close() // nolint:errcheck
+
+		_, err = io.ReadAll(r)
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("ReadAll() returned error: %v, expected %s", err, ErrChecksumMismatch)
+		}
+	})
+}
+
+func TestStreamingWriteFailure(t *testing.T) {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	writes := 0
+	failing, err := mock.Dial(mock.Config{
+		GetFunc:    database.Get,
+		DeleteFunc: database.Delete,
+		KeysFunc:   database.Keys,
+		SetFunc: func(key string, data []byte) error {
+			writes++
+			if writes > 2 {
+				return ErrDatabaseTest
+			}
+			return database.Set(key, data)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock database - %s", err)
+	}
+
+	db, err := Dial(Config{Database: failing, Threshold: 8, ChunkSize: 4})
+	if err != nil {
+		t.Fatalf("Failed to create chunked driver - %s", err)
+	}
+
+	w, err := db.OpenWriter("stream")
+	if err != nil {
+		t.Fatalf("OpenWriter() returned error: %s", err)
+	}
+
+	_, err = w.Write([]byte("this write fails on the third chunk"))
+	if !errors.Is(err, ErrDatabaseTest) {
+		t.Errorf("Write() returned error: %v, expected %s", err, ErrDatabaseTest)
+	}
+
+	if err := w.Close(); !errors.Is(err, ErrDatabaseTest) {
+		t.Errorf("Close() returned error: %v, expected %s", err, ErrDatabaseTest)
+	}
+
+	keys, err := database.Keys()
+	if err != nil {
+		t.Fatalf("Keys() returned error: %s", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Expected written chunks to be removed, found %v", keys)
+	}
+}
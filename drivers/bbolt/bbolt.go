@@ -53,13 +53,36 @@ Hord provides a simple abstraction for working with BoltDB, with easy-to-use met
 	if err != nil {
 	    // Handle error
 	}
+
+# Streaming
+
+Values can be read and written as streams using the OpenReader() and OpenWriter() methods. Streamed values are stored
+as chunks of ChunkSize bytes within a nested bucket named after the key, with each chunk written in its own
+transaction. Chunks are staged until the writer is closed, when they replace the stored value within a single
+transaction. Get() returns streamed values in full, and Set() and Delete() replace or remove them like any other value.
+
+	w, err := db.OpenWriter("key")
+	if err != nil {
+	    // Handle error
+	}
+	_, err = io.Copy(w, file)
+	if err != nil {
+	    // Handle error
+	}
+	err = w.Close()
+	if err != nil {
+	    // Handle error
+	}
 */
 package bbolt
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/tarmac-project/hord"
@@ -80,8 +103,25 @@ type Config struct {
 	// Timeout specifies the timeout duration for opening obtaining a file lock on the database file.
 	// Default value is 5 Seconds, a value of 0 is invalid.
 	Timeout time.Duration
+
+	// Validation defines the rules applied to keys and values before they are stored. By default, only empty keys
+	// and values are rejected.
+	Validation hord.ValidationPolicy
+
+	// ChunkSize is the number of bytes of a streamed value stored within each chunk, and held in memory by a writer
+	// before being written. Default is 256 KiB.
+	ChunkSize int
 }
 
+// DefaultChunkSize is the default number of bytes stored within each chunk of a streamed value.
+const DefaultChunkSize = 256 * 1024
+
+// stagingSuffix is appended to the bucket name to name the bucket holding streamed values until their writer is closed.
+const stagingSuffix = ".staging"
+
+// ErrValueChanged is returned by a reader when the streamed value is replaced or deleted while being read.
+var ErrValueChanged = errors.New("value changed while being read")
+
 // Database is an bbolt implementation of the hord.Database interface.
 type Database struct {
 	// cfg provides a reference to the dial configuration.
@@ -116,6 +156,11 @@ func Dial(cfg Config) (*Database, error) {
 		cfg.Timeout = time.Duration(5 * time.Second)
 	}
 
+	// Set Default Chunk Size
+	if db.cfg.ChunkSize <= 0 {
+		db.cfg.ChunkSize = DefaultChunkSize
+	}
+
 	// Open database
 	db.db, err = bbolt.Open(cfg.Filename, cfg.Permissions, &bbolt.Options{Timeout: cfg.Timeout})
 	if err != nil {
@@ -139,6 +184,11 @@ func (db *Database) Setup() error {
 		if err != nil {
 			return fmt.Errorf("unable to open bucket - %s", err)
 		}
+
+		_, err = tx.CreateBucketIfNotExists(db.stagingName())
+		if err != nil {
+			return fmt.Errorf("unable to open staging bucket - %s", err)
+		}
 		return nil
 	})
 	if err != nil {
@@ -151,15 +201,15 @@ func (db *Database) Setup() error {
 // Get retrieves data from the bbolt database based on the provided key.
 // It returns the data associated with the key or an error if the key is invalid or the data does not exist.
 func (db *Database) Get(key string) ([]byte, error) {
-	if err := hord.ValidKey(key); err != nil {
-		return nil, err
-	}
-
 	// Verify DB is connected
 	if db == nil || db.db == nil {
 		return nil, hord.ErrNoDial
 	}
 
+	if err := db.cfg.Validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
 	var data []byte
 	err := db.db.View(func(tx *bbolt.Tx) error {
 		// Open Bucket for this Tx
@@ -168,7 +218,14 @@ func (db *Database) Get(key string) ([]byte, error) {
 			return fmt.Errorf("bucket does not exist")
 		}
 
-		// Fetch Data from Bucket
+		// Fetch Data from Bucket, joining the chunks of streamed values
+		if chunks := bucket.Bucket([]byte(key)); chunks != nil {
+			return chunks.ForEach(func(_, c []byte) error {
+				data = append(data, c...)
+				return nil
+			})
+		}
+
 		d := bucket.Get([]byte(key))
 		if d != nil {
 			// Copy results into data as d will only be valid for the lifetime of this Tx
@@ -190,17 +247,17 @@ func (db *Database) Get(key string) ([]byte, error) {
 // Set inserts or updates data in the bbolt database based on the provided key.
 // It returns an error if the key or data is invalid.
 func (db *Database) Set(key string, data []byte) error {
-	if err := hord.ValidKey(key); err != nil {
-		return err
+	// Verify DB is connected
+	if db == nil || db.db == nil {
+		return hord.ErrNoDial
 	}
 
-	if err := hord.ValidData(data); err != nil {
+	if err := db.cfg.Validation.ValidKey(key); err != nil {
 		return err
 	}
 
-	// Verify DB is connected
-	if db == nil || db.db == nil {
-		return hord.ErrNoDial
+	if err := db.cfg.Validation.ValidData(data); err != nil {
+		return err
 	}
 
 	err := db.db.Update(func(tx *bbolt.Tx) error {
@@ -210,8 +267,13 @@ func (db *Database) Set(key string, data []byte) error {
 			return fmt.Errorf("bucket does not exist")
 		}
 
-		// Store Data into Bucket
-		err := bucket.Put([]byte(key), data)
+		// Remove any streamed value before storing Data into Bucket
+		err := deleteValue(bucket, []byte(key))
+		if err != nil {
+			return fmt.Errorf("error while executing Set - %s", err)
+		}
+
+		err = bucket.Put([]byte(key), data)
 		if err != nil {
 			return fmt.Errorf("error while executing Set - %s", err)
 		}
@@ -227,15 +289,15 @@ func (db *Database) Set(key string, data []byte) error {
 // Delete removes data from the bbolt database based on the provided key.
 // It returns an error if the key is invalid.
 func (db *Database) Delete(key string) error {
-	if err := hord.ValidKey(key); err != nil {
-		return err
-	}
-
 	// Verify DB is connected
 	if db == nil || db.db == nil {
 		return hord.ErrNoDial
 	}
 
+	if err := db.cfg.Validation.ValidKey(key); err != nil {
+		return err
+	}
+
 	err := db.db.Update(func(tx *bbolt.Tx) error {
 		// Open Bucket for this Tx
 		bucket := tx.Bucket([]byte(db.cfg.Bucketname))
@@ -244,7 +306,7 @@ func (db *Database) Delete(key string) error {
 		}
 
 		// Delete Key
-		err := bucket.Delete([]byte(key))
+		err := deleteValue(bucket, []byte(key))
 		if err != nil {
 			return fmt.Errorf("error while executing Delete - %s", err)
 		}
@@ -324,3 +386,313 @@ func (db *Database) Close() {
 		return
 	}
 }
+
+// deleteValue removes the value of the key from the bucket, whether stored as a single value or streamed as chunks.
+func deleteValue(bucket *bbolt.Bucket, key []byte) error {
+	if bucket.Bucket(key) != nil {
+		return bucket.DeleteBucket(key)
+	}
+	return bucket.Delete(key)
+}
+
+// stagingName returns the name of the bucket holding streamed values until their writer is closed.
+func (db *Database) stagingName() []byte {
+	return []byte(db.cfg.Bucketname + stagingSuffix)
+}
+
+// OpenReader opens a reader for the value of the specified key. Streamed values are read one chunk at a time, each
+// within its own read-only transaction, and the reader returns ErrValueChanged if the value is replaced while being
+// read. Callers must close the returned reader.
+func (db *Database) OpenReader(key string) (io.ReadCloser, error) {
+	// Verify DB is connected
+	if db == nil || db.db == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.cfg.Validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	r := &reader{db: db, key: []byte(key)}
+	err := db.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(db.cfg.Bucketname))
+		if bucket == nil {
+			return fmt.Errorf("bucket does not exist")
+		}
+
+		if chunks := bucket.Bucket(r.key); chunks != nil {
+			r.streamed = true
+			r.id = chunks.Sequence()
+			return nil
+		}
+
+		// Copy values that were not streamed as they will only be valid for the lifetime of this Tx
+		if d := bucket.Get(r.key); d != nil {
+			r.buf = append(r.buf, d...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while executing OpenReader - %s", err)
+	}
+
+	if !r.streamed && len(r.buf) == 0 {
+		return nil, hord.ErrNil
+	}
+
+	return r, nil
+}
+
+// reader reads a value, fetching the chunks of streamed values as they are needed.
+type reader struct {
+	db  *Database
+	key []byte
+
+	// streamed is true when the value is stored as chunks, identified by id.
+	streamed bool
+	id       uint64
+
+	next   uint64
+	buf    []byte
+	closed bool
+}
+
+// Read reads the value, fetching the next chunk once the current chunk has been read.
+func (r *reader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	if len(r.buf) == 0 && r.streamed {
+		if err := r.fetch(); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(r.buf) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// fetch reads the next chunk of a streamed value, leaving the buffer empty once every chunk has been read.
+func (r *reader) fetch() error {
+	return r.db.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(r.db.cfg.Bucketname))
+		if bucket == nil {
+			return fmt.Errorf("bucket does not exist")
+		}
+
+		chunks := bucket.Bucket(r.key)
+		if chunks == nil || chunks.Sequence() != r.id {
+			return ErrValueChanged
+		}
+
+		c := chunks.Get(chunkKey(r.next))
+		if c == nil {
+			r.streamed = false
+			return nil
+		}
+
+		r.buf = append(r.buf[:0], c...)
+		r.next++
+		return nil
+	})
+}
+
+// Close closes the reader.
+func (r *reader) Close() error {
+	if r.closed {
+		return os.ErrClosed
+	}
+	r.closed = true
+	r.buf = nil
+	return nil
+}
+
+// OpenWriter opens a writer for the value of the specified key. Data is written to a staging bucket in chunks of
+// ChunkSize bytes, each within its own transaction, and replaces the stored value when the writer is closed.
+func (db *Database) OpenWriter(key string) (io.WriteCloser, error) {
+	// Verify DB is connected
+	if db == nil || db.db == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.cfg.Validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	w := &writer{db: db, key: []byte(key)}
+	err := db.db.Update(func(tx *bbolt.Tx) error {
+		staging := tx.Bucket(db.stagingName())
+		if staging == nil {
+			return fmt.Errorf("bucket does not exist")
+		}
+
+		// Stage the chunks within a bucket unique to this writer, so writers of the same key do not collide
+		id, err := staging.NextSequence()
+		if err != nil {
+			return err
+		}
+		w.id = id
+
+		parent, err := staging.CreateBucket(w.stage())
+		if err != nil {
+			return err
+		}
+
+		chunks, err := parent.CreateBucket(w.key)
+		if err != nil {
+			return err
+		}
+		return chunks.SetSequence(id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while executing OpenWriter - %s", err)
+	}
+
+	return w, nil
+}
+
+// writer buffers a chunk of a value at a time, writing full chunks to the staging bucket and moving the value into
+// place on Close.
+type writer struct {
+	db  *Database
+	key []byte
+	id  uint64
+
+	buf    []byte
+	next   uint64
+	size   int
+	closed bool
+}
+
+// Write appends data to the value, writing every full chunk to the staging bucket.
+func (w *writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	w.size += len(p)
+	if limit := w.db.cfg.Validation.MaxValueSize; limit > 0 && w.size > limit {
+		return 0, hord.ErrDataTooLarge
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		c := min(w.db.cfg.ChunkSize-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:c]...)
+		p = p[c:]
+
+		if len(w.buf) == w.db.cfg.ChunkSize {
+			err := w.db.db.Update(func(tx *bbolt.Tx) error {
+				return w.flush(tx)
+			})
+			if err != nil {
+				return n - len(p), fmt.Errorf("error while writing chunk - %s", err)
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// Close writes the remaining data and replaces the stored value with the streamed value within a single
+// transaction. If the value is empty or invalid, the staged chunks are removed and an error is returned.
+func (w *writer) Close() error {
+	if w.closed {
+		return os.ErrClosed
+	}
+	w.closed = true
+
+	if w.size == 0 {
+		w.abort()
+		return hord.ErrInvalidData
+	}
+
+	if limit := w.db.cfg.Validation.MaxValueSize; limit > 0 && w.size > limit {
+		w.abort()
+		return hord.ErrDataTooLarge
+	}
+
+	// The final chunk is written before the move, as moving a bucket does not carry changes made within the same Tx
+	err := w.db.db.Update(w.flush)
+	if err != nil {
+		w.abort()
+		return fmt.Errorf("error while writing chunk - %s", err)
+	}
+
+	err = w.db.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(w.db.cfg.Bucketname))
+		staging := tx.Bucket(w.db.stagingName())
+		if bucket == nil || staging == nil {
+			return fmt.Errorf("bucket does not exist")
+		}
+
+		if err := deleteValue(bucket, w.key); err != nil {
+			return err
+		}
+
+		parent := staging.Bucket(w.stage())
+		if err := parent.MoveBucket(w.key, bucket); err != nil {
+			return err
+		}
+		return staging.DeleteBucket(w.stage())
+	})
+	if err != nil {
+		w.abort()
+		return fmt.Errorf("error while executing OpenWriter transaction - %s", err)
+	}
+
+	return nil
+}
+
+// flush writes the buffered data as the next chunk of the staged value.
+func (w *writer) flush(tx *bbolt.Tx) error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	staging := tx.Bucket(w.db.stagingName())
+	if staging == nil {
+		return fmt.Errorf("bucket does not exist")
+	}
+
+	parent := staging.Bucket(w.stage())
+	if parent == nil {
+		return fmt.Errorf("staged value does not exist")
+	}
+
+	if err := parent.Bucket(w.key).Put(chunkKey(w.next), w.buf); err != nil {
+		return err
+	}
+
+	w.next++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// abort removes the staged chunks of the value.
+func (w *writer) abort() {
+	w.buf = nil
+	_ = w.db.db.Update(func(tx *bbolt.Tx) error {
+		staging := tx.Bucket(w.db.stagingName())
+		if staging == nil {
+			return nil
+		}
+		return staging.DeleteBucket(w.stage())
+	})
+}
+
+// stage returns the name of the bucket within the staging bucket holding the chunks of this writer.
+func (w *writer) stage() []byte {
+	return []byte(strconv.FormatUint(w.id, 10))
+}
+
+// chunkKey returns the key of the nth chunk of a streamed value, ordered so chunks are iterated in sequence.
+func chunkKey(n uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, n)
+}
@@ -1,10 +1,16 @@
 package bbolt
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"testing"
 	"time"
+
+	"github.com/tarmac-project/hord"
+	"go.etcd.io/bbolt"
 )
 
 type TestCase struct {
@@ -116,3 +122,232 @@ func TestBBoltConnectivity(t *testing.T) {
 		})
 	}
 }
+
+func TestValidationPolicy(t *testing.T) {
+	// Create Directory for Test Execution
+	tmpDir := "/tmp/" + TmpFn()
+	err := os.Mkdir(tmpDir, 0750)
+	if err != nil {
+		t.Fatalf("Unable to create test directory - %s", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Logf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	db, err := Dial(Config{
+		Bucketname: "test",
+		Filename:   tmpDir + "/" + TmpFn() + "validation",
+		Validation: hord.PortableValidationPolicy,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error dialing database - %s", err)
+	}
+	defer db.Close()
+
+	err = db.Setup()
+	if err != nil {
+		t.Fatalf("Unexpected error setting up database - %s", err)
+	}
+
+	if err := db.Set("invalid key", []byte("data")); !errors.Is(err, hord.ErrKeyInvalidCharacters) {
+		t.Errorf("Expected ErrKeyInvalidCharacters, got %v", err)
+	}
+	if _, err := db.Get("invalid key"); !errors.Is(err, hord.ErrKeyInvalidCharacters) {
+		t.Errorf("Expected ErrKeyInvalidCharacters, got %v", err)
+	}
+	if err := db.Delete("invalid key"); !errors.Is(err, hord.ErrKeyInvalidCharacters) {
+		t.Errorf("Expected ErrKeyInvalidCharacters, got %v", err)
+	}
+	if err := db.Set("valid.key", []byte("data")); err != nil {
+		t.Errorf("Unexpected error setting key - %s", err)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	// Create Directory for Test Execution
+	tmpDir := "/tmp/" + TmpFn()
+	err := os.Mkdir(tmpDir, 0750)
+	if err != nil {
+		t.Fatalf("Unable to create test directory - %s", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Logf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	db, err := Dial(Config{
+		Bucketname: "test",
+		Filename:   tmpDir + "/" + TmpFn() + "streaming",
+		ChunkSize:  4,
+		Validation: hord.ValidationPolicy{MaxValueSize: 64},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error dialing database - %s", err)
+	}
+	defer db.Close()
+
+	err = db.Setup()
+	if err != nil {
+		t.Fatalf("Unexpected error setting up database - %s", err)
+	}
+
+	var s hord.Streamer = db
+
+	// write is a helper function streaming the data to the key in the provided pieces.
+	write := func(key string, pieces ...string) error {
+		w, err := s.OpenWriter(key)
+		if err != nil {
+			return err
+		}
+		for _, p := range pieces {
+			if _, err := w.Write([]byte(p)); err != nil {
+				_ = w.Close()
+				return err
+			}
+		}
+		return w.Close()
+	}
+
+	// read is a helper function reading the streamed value of the key.
+	read := func(key string) (string, error) {
+		r, err := s.OpenReader(key)
+		if err != nil {
+			return "", err
+		}
+		defer r.Close() // nolint:errcheck
+
+		data, err := io.ReadAll(r)
+		return string(data), err
+	}
+
+	t.Run("Write and Read", func(t *testing.T) {
+		if err := write("stream", "streamed ", "va", "lue"); err != nil {
+			t.Fatalf("Unexpected error streaming value - %s", err)
+		}
+
+		if data, err := read("stream"); err != nil || data != "streamed value" {
+			t.Errorf("OpenReader() returned %q - %v, expected streamed value", data, err)
+		}
+
+		// Streamed values are stored as chunks and returned in full by Get
+		if data, err := db.Get("stream"); err != nil || string(data) != "streamed value" {
+			t.Errorf("Get() returned %q - %v, expected streamed value", data, err)
+		}
+
+		keys, err := db.Keys()
+		if err != nil || len(keys) != 1 || keys[0] != "stream" {
+			t.Errorf("Keys() returned %v - %v, expected [stream]", keys, err)
+		}
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		if err := write("replaced", "first value"); err != nil {
+			t.Fatalf("Unexpected error streaming value - %s", err)
+		}
+		if err := write("replaced", "second"); err != nil {
+			t.Fatalf("Unexpected error streaming value - %s", err)
+		}
+		if data, err := read("replaced"); err != nil || data != "second" {
+			t.Errorf("OpenReader() returned %q - %v, expected second", data, err)
+		}
+
+		// Set and Delete replace and remove streamed values
+		if err := db.Set("replaced", []byte("set")); err != nil {
+			t.Fatalf("Unexpected error setting value - %s", err)
+		}
+		if data, err := read("replaced"); err != nil || data != "set" {
+			t.Errorf("OpenReader() returned %q - %v, expected set", data, err)
+		}
+
+		if err := write("replaced", "streamed again"); err != nil {
+			t.Fatalf("Unexpected error streaming value - %s", err)
+		}
+		if err := db.Delete("replaced"); err != nil {
+			t.Fatalf("Unexpected error deleting value - %s", err)
+		}
+		if _, err := db.Get("replaced"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected ErrNil, got %v", err)
+		}
+	})
+
+	t.Run("Not Visible Until Closed", func(t *testing.T) {
+		w, err := s.OpenWriter("pending")
+		if err != nil {
+			t.Fatalf("Unexpected error opening writer - %s", err)
+		}
+		_, _ = w.Write([]byte("pending value"))
+
+		if _, err := db.Get("pending"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected ErrNil before the writer is closed, got %v", err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatalf("Unexpected error closing writer - %s", err)
+		}
+		if data, err := db.Get("pending"); err != nil || string(data) != "pending value" {
+			t.Errorf("Get() returned %q - %v, expected pending value", data, err)
+		}
+	})
+
+	t.Run("Changed While Reading", func(t *testing.T) {
+		if err := write("changed", "original value"); err != nil {
+			t.Fatalf("Unexpected error streaming value - %s", err)
+		}
+
+		r, err := s.OpenReader("changed")
+		if err != nil {
+			t.Fatalf("Unexpected error opening reader - %s", err)
+		}
+		defer r.Close() // nolint:errcheck
+
+		buf := make([]byte, 4)
+		if _, err := r.Read(buf); err != nil {
+			t.Fatalf("Unexpected error reading stream - %s", err)
+		}
+
+		if err := write("changed", "replacement"); err != nil {
+			t.Fatalf("Unexpected error streaming value - %s", err)
+		}
+
+		if _, err := io.ReadAll(r); !errors.Is(err, ErrValueChanged) {
+			t.Errorf("Expected ErrValueChanged, got %v", err)
+		}
+	})
+
+	t.Run("Invalid Values", func(t *testing.T) {
+		if _, err := s.OpenReader("missing"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected ErrNil, got %v", err)
+		}
+
+		if err := write("empty"); !errors.Is(err, hord.ErrInvalidData) {
+			t.Errorf("Expected ErrInvalidData, got %v", err)
+		}
+
+		if err := write("large", string(make([]byte, 65))); !errors.Is(err, hord.ErrDataTooLarge) {
+			t.Errorf("Expected ErrDataTooLarge, got %v", err)
+		}
+
+		// Aborted values leave nothing staged
+		err := db.db.View(func(tx *bbolt.Tx) error {
+			return tx.Bucket(db.stagingName()).ForEach(func(k, _ []byte) error {
+				return fmt.Errorf("unexpected staged value %s", k)
+			})
+		})
+		if err != nil {
+			t.Errorf("Unexpected staging state - %s", err)
+		}
+	})
+
+	t.Run("Not Dialed", func(t *testing.T) {
+		var db *Database
+		if _, err := db.OpenReader("key"); !errors.Is(err, hord.ErrNoDial) {
+			t.Errorf("Expected ErrNoDial, got %v", err)
+		}
+		if _, err := db.OpenWriter("key"); !errors.Is(err, hord.ErrNoDial) {
+			t.Errorf("Expected ErrNoDial, got %v", err)
+		}
+	})
+}
@@ -3,8 +3,11 @@ module github.com/tarmac-project/hord/drivers/bbolt
 go 1.23.0
 
 require (
-	github.com/tarmac-project/hord v0.8.2
+	github.com/tarmac-project/hord v0.9.0
 	go.etcd.io/bbolt v1.4.0
 )
 
 require golang.org/x/sys v0.31.0 // indirect
+
+// Build against the hord module within this repository until v0.9.0 is released.
+replace github.com/tarmac-project/hord => ../..
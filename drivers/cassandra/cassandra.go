@@ -93,6 +93,10 @@ type Config struct {
 
 	// Replicas is used to define the default number of replicas for data. Default is 1.
 	Replicas int
+
+	// Validation defines the rules applied to keys and values before they are stored. By default, only empty keys
+	// and values are rejected.
+	Validation hord.ValidationPolicy
 }
 
 // Database is used to interface with Cassandra. It also satisfies the Hord Database interface.
@@ -209,7 +213,7 @@ func (db *Database) Get(key string) ([]byte, error) {
 		return data, hord.ErrNoDial
 	}
 
-	if err := hord.ValidKey(key); err != nil {
+	if err := db.config.Validation.ValidKey(key); err != nil {
 		return data, err
 	}
 
@@ -231,11 +235,11 @@ func (db *Database) Set(key string, data []byte) error {
 		return hord.ErrNoDial
 	}
 
-	if err := hord.ValidKey(key); err != nil {
+	if err := db.config.Validation.ValidKey(key); err != nil {
 		return err
 	}
 
-	if err := hord.ValidData(data); err != nil {
+	if err := db.config.Validation.ValidData(data); err != nil {
 		return err
 	}
 
@@ -250,7 +254,7 @@ func (db *Database) Delete(key string) error {
 		return hord.ErrNoDial
 	}
 
-	if err := hord.ValidKey(key); err != nil {
+	if err := db.config.Validation.ValidKey(key); err != nil {
 		return err
 	}
 
@@ -4,7 +4,7 @@ go 1.20
 
 require (
 	github.com/gocql/gocql v1.7.0
-	github.com/tarmac-project/hord v0.8.2
+	github.com/tarmac-project/hord v0.9.0
 )
 
 require (
@@ -12,3 +12,6 @@ require (
 	github.com/hailocab/go-hostpool v0.0.0-20160125115350-e80d13ce29ed // indirect
 	gopkg.in/inf.v0 v0.9.1 // indirect
 )
+
+// Build against the hord module within this repository until v0.9.0 is released.
+replace github.com/tarmac-project/hord => ../..
@@ -0,0 +1,415 @@
+/*
+Package filesystem provides a Hord database driver that stores each key as a file within a directory.
+
+The filesystem driver stores values on local disk, making it well suited for large, file-like payloads. Values can be
+read and written as streams using the OpenReader() and OpenWriter() methods. To use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/drivers/filesystem"
+	)
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the filesystem driver.
+
+	var db hord.Database
+	db, err := filesystem.Dial(filesystem.Config{
+		Directory: "/var/lib/hord",
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function creates the directory if it does not exist and is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the filesystem driver, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Connect to the filesystem database
+	db, err := filesystem.Dial(filesystem.Config{
+		Directory: "/var/lib/hord",
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+	// Set a value
+	err = db.Set("key", []byte("value"))
+	if err != nil {
+	    // Handle error
+	}
+
+	// Retrieve a value
+	value, err := db.Get("key")
+	if err != nil {
+	    // Handle error
+	}
+
+	// Stream a value
+	w, err := db.OpenWriter("key")
+	if err != nil {
+	    // Handle error
+	}
+	_, err = io.Copy(w, file)
+	if err != nil {
+	    // Handle error
+	}
+	err = w.Close()
+	if err != nil {
+	    // Handle error
+	}
+*/
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tarmac-project/hord"
+)
+
+// Config represents the configuration for the filesystem database.
+type Config struct {
+	// Directory specifies the directory used to store values. Each key is stored as a file within this directory.
+	Directory string
+
+	// Permissions specifies the file permissions for value files. Default is 0600.
+	Permissions os.FileMode
+
+	// Validation defines the rules applied to keys and values before they are stored. By default, only empty keys
+	// and values are rejected.
+	Validation hord.ValidationPolicy
+}
+
+// Database is a filesystem implementation of the hord.Database and hord.Streamer interfaces.
+type Database struct {
+	sync.RWMutex
+
+	// cfg provides a reference to the dial configuration.
+	cfg Config
+
+	// closed is set once Close has been called.
+	closed bool
+}
+
+// tmpPrefix is used for temporary files created while writing values. Escaped keys never start with a dot.
+const tmpPrefix = ".tmp-"
+
+// Dial initializes and returns a new filesystem database instance.
+func Dial(cfg Config) (*Database, error) {
+	db := &Database{cfg: cfg}
+
+	// Verify Directory is set
+	if cfg.Directory == "" {
+		return db, fmt.Errorf("directory must not be empty")
+	}
+
+	// Set Default Permissions
+	if db.cfg.Permissions == 0 {
+		db.cfg.Permissions = 0600
+	}
+
+	return db, nil
+}
+
+// Setup creates the storage directory if it does not exist.
+func (db *Database) Setup() error {
+	db.RLock()
+	defer db.RUnlock()
+	if db.closed || db.cfg.Directory == "" {
+		return hord.ErrNoDial
+	}
+
+	err := os.MkdirAll(db.cfg.Directory, 0750)
+	if err != nil {
+		return fmt.Errorf("unable to create directory %q: %w", db.cfg.Directory, err)
+	}
+
+	return nil
+}
+
+// Get retrieves data from the filesystem database based on the provided key.
+// It returns the data associated with the key or an error if the key is invalid or the data does not exist.
+func (db *Database) Get(key string) ([]byte, error) {
+	db.RLock()
+	defer db.RUnlock()
+	if db.closed || db.cfg.Directory == "" {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.cfg.Validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(db.filename(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, hord.ErrNil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read value: %w", err)
+	}
+
+	return data, nil
+}
+
+// Set inserts or updates data in the filesystem database based on the provided key.
+// Values are written to a temporary file and renamed into place, so readers never see partially written values.
+func (db *Database) Set(key string, data []byte) error {
+	db.RLock()
+	defer db.RUnlock()
+	if db.closed || db.cfg.Directory == "" {
+		return hord.ErrNoDial
+	}
+
+	if err := db.cfg.Validation.ValidKey(key); err != nil {
+		return err
+	}
+
+	if err := db.cfg.Validation.ValidData(data); err != nil {
+		return err
+	}
+
+	w, err := db.createTemp(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		w.abort()
+		return fmt.Errorf("unable to write value: %w", err)
+	}
+
+	return w.Close()
+}
+
+// Delete removes data from the filesystem database based on the provided key.
+// It returns an error if the key is invalid.
+func (db *Database) Delete(key string) error {
+	db.RLock()
+	defer db.RUnlock()
+	if db.closed || db.cfg.Directory == "" {
+		return hord.ErrNoDial
+	}
+
+	if err := db.cfg.Validation.ValidKey(key); err != nil {
+		return err
+	}
+
+	err := os.Remove(db.filename(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to remove value: %w", err)
+	}
+
+	return nil
+}
+
+// Keys retrieves a list of keys stored in the filesystem database.
+func (db *Database) Keys() ([]string, error) {
+	db.RLock()
+	defer db.RUnlock()
+	if db.closed || db.cfg.Directory == "" {
+		return nil, hord.ErrNoDial
+	}
+
+	entries, err := os.ReadDir(db.cfg.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read directory: %w", err)
+	}
+
+	var keys []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), tmpPrefix) {
+			continue
+		}
+
+		key, err := url.PathUnescape(e.Name())
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// HealthCheck performs a health check on the filesystem database by verifying the storage directory exists.
+func (db *Database) HealthCheck() error {
+	db.RLock()
+	defer db.RUnlock()
+	if db.closed || db.cfg.Directory == "" {
+		return hord.ErrNoDial
+	}
+
+	info, err := os.Stat(db.cfg.Directory)
+	if err != nil {
+		return errors.Join(hord.ErrHealthCheckFailure, err)
+	}
+
+	if !info.IsDir() {
+		return errors.Join(hord.ErrHealthCheckFailure, fmt.Errorf("%q is not a directory", db.cfg.Directory))
+	}
+
+	return nil
+}
+
+// Close closes the filesystem database. Stored files remain on disk.
+func (db *Database) Close() {
+	db.Lock()
+	defer db.Unlock()
+	db.closed = true
+}
+
+// OpenReader opens a reader for the value of the specified key. Callers must close the returned reader.
+func (db *Database) OpenReader(key string) (io.ReadCloser, error) {
+	db.RLock()
+	defer db.RUnlock()
+	if db.closed || db.cfg.Directory == "" {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.cfg.Validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(db.filename(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, hord.ErrNil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open value: %w", err)
+	}
+
+	return f, nil
+}
+
+// OpenWriter opens a writer for the value of the specified key. Data is written to a temporary file which replaces
+// the stored value when the writer is closed.
+func (db *Database) OpenWriter(key string) (io.WriteCloser, error) {
+	db.RLock()
+	defer db.RUnlock()
+	if db.closed || db.cfg.Directory == "" {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.cfg.Validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	return db.createTemp(key)
+}
+
+// filename returns the path of the file used to store the specified key.
+func (db *Database) filename(key string) string {
+	name := url.PathEscape(key)
+
+	// Escape leading dots to avoid hidden files and relative path names
+	if strings.HasPrefix(name, ".") {
+		name = "%2E" + name[1:]
+	}
+
+	return filepath.Join(db.cfg.Directory, name)
+}
+
+// createTemp creates a temporary file used to write the value of the specified key.
+func (db *Database) createTemp(key string) (*fileWriter, error) {
+	f, err := os.CreateTemp(db.cfg.Directory, tmpPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create file: %w", err)
+	}
+
+	return &fileWriter{
+		file:        f,
+		filename:    db.filename(key),
+		permissions: db.cfg.Permissions,
+		validation:  db.cfg.Validation,
+	}, nil
+}
+
+// fileWriter writes a value to a temporary file and renames it into place on Close.
+type fileWriter struct {
+	file        *os.File
+	filename    string
+	permissions os.FileMode
+	validation  hord.ValidationPolicy
+	size        int
+	closed      bool
+}
+
+// Write writes data to the temporary file.
+func (w *fileWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	n, err := w.file.Write(p)
+	w.size += n
+	if w.validation.MaxValueSize > 0 && w.size > w.validation.MaxValueSize {
+		return n, hord.ErrDataTooLarge
+	}
+	return n, err
+}
+
+// Close stores the written value. If the value is empty or invalid, the temporary file is removed and an error is
+// returned.
+func (w *fileWriter) Close() error {
+	if w.closed {
+		return os.ErrClosed
+	}
+
+	if w.size == 0 {
+		w.abort()
+		return hord.ErrInvalidData
+	}
+
+	if w.validation.MaxValueSize > 0 && w.size > w.validation.MaxValueSize {
+		w.abort()
+		return hord.ErrDataTooLarge
+	}
+
+	w.closed = true
+	if err := w.file.Chmod(w.permissions); err != nil {
+		_ = w.file.Close()
+		_ = os.Remove(w.file.Name())
+		return fmt.Errorf("unable to set file permissions: %w", err)
+	}
+
+	if err := w.file.Close(); err != nil {
+		_ = os.Remove(w.file.Name())
+		return fmt.Errorf("unable to close file: %w", err)
+	}
+
+	if err := os.Rename(w.file.Name(), w.filename); err != nil {
+		_ = os.Remove(w.file.Name())
+		return fmt.Errorf("unable to store value: %w", err)
+	}
+
+	return nil
+}
+
+// abort closes and removes the temporary file without storing the value.
+func (w *fileWriter) abort() {
+	w.closed = true
+	_ = w.file.Close()
+	_ = os.Remove(w.file.Name())
+}
@@ -0,0 +1,184 @@
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+func TmpFn() string {
+	// Snagged from ioutil.TempFile
+	r := uint32(time.Now().UnixNano() + int64(os.Getpid()))
+	r = r*1664525 + 1013904223
+	return strconv.Itoa(int(1e9 + r%1e9))[1:]
+}
+
+// setupDatabase is a helper function to create a filesystem database within a temporary directory.
+func setupDatabase(t *testing.T, cfg Config) *Database {
+	cfg.Directory = t.TempDir() + "/" + TmpFn()
+
+	db, err := Dial(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error dialing database - %s", err)
+	}
+
+	err = db.Setup()
+	if err != nil {
+		t.Fatalf("Unexpected error setting up database - %s", err)
+	}
+
+	return db
+}
+
+func TestDial(t *testing.T) {
+	t.Run("No Directory", func(t *testing.T) {
+		_, err := Dial(Config{})
+		if err == nil {
+			t.Errorf("Expected error when dialing without a directory")
+		}
+	})
+
+	t.Run("Default Permissions", func(t *testing.T) {
+		db, err := Dial(Config{Directory: "/tmp/" + TmpFn()})
+		if err != nil {
+			t.Fatalf("Unexpected error dialing database - %s", err)
+		}
+		if db.cfg.Permissions != 0600 {
+			t.Errorf("Unexpected default permissions - got %o, expected 600", db.cfg.Permissions)
+		}
+	})
+}
+
+func TestKeyEscaping(t *testing.T) {
+	db := setupDatabase(t, Config{})
+	defer db.Close()
+
+	keys := []string{"simple", "with/slash", "..", ".hidden", "spaces and %percent", "unicode-ключ"}
+	for _, key := range keys {
+		if err := db.Set(key, []byte(key)); err != nil {
+			t.Fatalf("Unexpected error setting key %q - %s", key, err)
+		}
+	}
+
+	stored, err := db.Keys()
+	if err != nil {
+		t.Fatalf("Unexpected error fetching keys - %s", err)
+	}
+	if len(stored) != len(keys) {
+		t.Fatalf("Unexpected keys returned - got %v, expected %v", stored, keys)
+	}
+
+	for _, key := range keys {
+		data, err := db.Get(key)
+		if err != nil {
+			t.Fatalf("Unexpected error getting key %q - %s", key, err)
+		}
+		if string(data) != key {
+			t.Errorf("Data mismatch for key %q - got %s", key, data)
+		}
+	}
+
+	entries, err := os.ReadDir(db.cfg.Directory)
+	if err != nil {
+		t.Fatalf("Unexpected error reading directory - %s", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("Unexpected file created within directory - %s", e.Name())
+		}
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	db := setupDatabase(t, Config{Validation: hord.ValidationPolicy{MaxValueSize: 64}})
+	defer db.Close()
+
+	var s hord.Streamer = db
+	value := []byte("streamed value written in multiple parts")
+
+	t.Run("Write Stream", func(t *testing.T) {
+		w, err := s.OpenWriter("stream")
+		if err != nil {
+			t.Fatalf("Unexpected error opening writer - %s", err)
+		}
+
+		for _, part := range bytes.SplitAfter(value, []byte(" ")) {
+			if _, err := w.Write(part); err != nil {
+				t.Fatalf("Unexpected error writing stream - %s", err)
+			}
+		}
+
+		// Value should not be visible until the writer is closed
+		if _, err := db.Get("stream"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected ErrNil before writer is closed, got %v", err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatalf("Unexpected error closing writer - %s", err)
+		}
+	})
+
+	t.Run("Read Stream", func(t *testing.T) {
+		r, err := s.OpenReader("stream")
+		if err != nil {
+			t.Fatalf("Unexpected error opening reader - %s", err)
+		}
+		defer r.Close() // nolint:errcheck
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Unexpected error reading stream - %s", err)
+		}
+		if !bytes.Equal(data, value) {
+			t.Errorf("Data mismatch - got %s, expected %s", data, value)
+		}
+	})
+
+	t.Run("Read Missing Key", func(t *testing.T) {
+		_, err := s.OpenReader("missing")
+		if !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected ErrNil, got %v", err)
+		}
+	})
+
+	t.Run("Empty Stream", func(t *testing.T) {
+		w, err := s.OpenWriter("empty")
+		if err != nil {
+			t.Fatalf("Unexpected error opening writer - %s", err)
+		}
+		if err := w.Close(); !errors.Is(err, hord.ErrInvalidData) {
+			t.Errorf("Expected ErrInvalidData, got %v", err)
+		}
+	})
+
+	t.Run("Stream Too Large", func(t *testing.T) {
+		w, err := s.OpenWriter("large")
+		if err != nil {
+			t.Fatalf("Unexpected error opening writer - %s", err)
+		}
+		_, _ = w.Write(bytes.Repeat([]byte("a"), 65))
+		if err := w.Close(); !errors.Is(err, hord.ErrDataTooLarge) {
+			t.Errorf("Expected ErrDataTooLarge, got %v", err)
+		}
+		if _, err := db.Get("large"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Expected ErrNil for aborted stream, got %v", err)
+		}
+	})
+
+	t.Run("Closed Database", func(t *testing.T) {
+		db.Close()
+		if _, err := s.OpenReader("stream"); !errors.Is(err, hord.ErrNoDial) {
+			t.Errorf("Expected ErrNoDial, got %v", err)
+		}
+		if _, err := s.OpenWriter("stream"); !errors.Is(err, hord.ErrNoDial) {
+			t.Errorf("Expected ErrNoDial, got %v", err)
+		}
+	})
+}
@@ -3,6 +3,9 @@ module github.com/tarmac-project/hord/drivers/hashmap
 go 1.23.0
 
 require (
-	github.com/tarmac-project/hord v0.8.2
+	github.com/tarmac-project/hord v0.9.0
 	gopkg.in/yaml.v3 v3.0.1
 )
+
+// Build against the hord module within this repository until v0.9.0 is released.
+replace github.com/tarmac-project/hord => ../..
@@ -73,6 +73,10 @@ import (
 type Config struct {
 	// Filename is an optional parameter that accepts the path to a YAML or JSON file to read/write data
 	Filename string
+
+	// Validation defines the rules applied to keys and values before they are stored. By default, only empty keys
+	// and values are rejected.
+	Validation hord.ValidationPolicy
 }
 
 // Database is an in-memory hashmap implementation of the hord.Database interface.
@@ -140,7 +144,7 @@ func (db *Database) Setup() error {
 // Get retrieves data from the hashmap database based on the provided key.
 // It returns the data associated with the key or an error if the key is invalid or the data does not exist.
 func (db *Database) Get(key string) ([]byte, error) {
-	if err := hord.ValidKey(key); err != nil {
+	if err := db.config.Validation.ValidKey(key); err != nil {
 		return []byte(""), err
 	}
 
@@ -160,11 +164,11 @@ func (db *Database) Get(key string) ([]byte, error) {
 // Set inserts or updates data in the hashmap database based on the provided key.
 // It returns an error if the key or data is invalid.
 func (db *Database) Set(key string, data []byte) error {
-	if err := hord.ValidKey(key); err != nil {
+	if err := db.config.Validation.ValidKey(key); err != nil {
 		return err
 	}
 
-	if err := hord.ValidData(data); err != nil {
+	if err := db.config.Validation.ValidData(data); err != nil {
 		return err
 	}
 
@@ -181,7 +185,7 @@ func (db *Database) Set(key string, data []byte) error {
 // Delete removes data from the hashmap database based on the provided key.
 // It returns an error if the key is invalid.
 func (db *Database) Delete(key string) error {
-	if err := hord.ValidKey(key); err != nil {
+	if err := db.config.Validation.ValidKey(key); err != nil {
 		return err
 	}
 
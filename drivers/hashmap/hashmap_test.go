@@ -2,9 +2,11 @@ package hashmap
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"testing"
 
+	"github.com/tarmac-project/hord"
 	"gopkg.in/yaml.v3"
 )
 
@@ -267,3 +269,35 @@ func readFile(filename string, unmarshal func([]byte, interface{}) error) (map[s
 
 	return parsedData, nil
 }
+
+func TestValidationPolicy(t *testing.T) {
+	db, err := Dial(Config{
+		Validation: hord.ValidationPolicy{
+			MaxKeyLength:     8,
+			MaxValueSize:     4,
+			ReservedPrefixes: []string{"_"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Set("too_long_key", []byte("data")); !errors.Is(err, hord.ErrKeyTooLong) {
+		t.Errorf("expected ErrKeyTooLong, got %v", err)
+	}
+	if err := db.Set("_key", []byte("data")); !errors.Is(err, hord.ErrKeyReserved) {
+		t.Errorf("expected ErrKeyReserved, got %v", err)
+	}
+	if err := db.Set("key", []byte("large")); !errors.Is(err, hord.ErrDataTooLarge) {
+		t.Errorf("expected ErrDataTooLarge, got %v", err)
+	}
+	if _, err := db.Get("too_long_key"); !errors.Is(err, hord.ErrKeyTooLong) {
+		t.Errorf("expected ErrKeyTooLong, got %v", err)
+	}
+	if err := db.Delete("too_long_key"); !errors.Is(err, hord.ErrKeyTooLong) {
+		t.Errorf("expected ErrKeyTooLong, got %v", err)
+	}
+	if err := db.Set("key", []byte("data")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
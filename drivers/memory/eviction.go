@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+// policy tracks key usage and selects keys to evict. Implementations are not safe for concurrent use.
+type policy interface {
+	// add records a newly stored key.
+	add(key string)
+
+	// access records a read or overwrite of a stored key.
+	access(key string)
+
+	// remove stops tracking a key that was deleted or expired.
+	remove(key string)
+
+	// evict selects a key to evict and stops tracking it, returning false if no keys are tracked.
+	evict() (string, bool)
+}
+
+// newPolicy returns the eviction policy implementation for p.
+func newPolicy(p Policy, capacity int) policy {
+	switch p {
+	case LFU:
+		return newLFU()
+	case ARC:
+		return newARC(capacity)
+	default:
+		return newLRU()
+	}
+}
+
+// lru evicts the least recently used key.
+type lru struct {
+	order *list.List
+	items map[string]*list.Element
+}
+
+func newLRU() *lru {
+	return &lru{order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (p *lru) add(key string) {
+	p.items[key] = p.order.PushFront(key)
+}
+
+func (p *lru) access(key string) {
+	if e, ok := p.items[key]; ok {
+		p.order.MoveToFront(e)
+	}
+}
+
+func (p *lru) remove(key string) {
+	if e, ok := p.items[key]; ok {
+		p.order.Remove(e)
+		delete(p.items, key)
+	}
+}
+
+func (p *lru) evict() (string, bool) {
+	e := p.order.Back()
+	if e == nil {
+		return "", false
+	}
+
+	key := e.Value.(string)
+	p.remove(key)
+	return key, true
+}
+
+// lfuEntry is a key tracked by the lfu policy.
+type lfuEntry struct {
+	key   string
+	freq  uint64
+	seq   uint64
+	index int
+}
+
+// lfuHeap orders entries by access frequency, then by least recent access.
+type lfuHeap []*lfuEntry
+
+func (h lfuHeap) Len() int { return len(h) }
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].freq < h[j].freq
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x any) {
+	e := x.(*lfuEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
+
+// lfu evicts the least frequently used key, breaking ties by least recent access.
+type lfu struct {
+	heap  lfuHeap
+	items map[string]*lfuEntry
+	seq   uint64
+}
+
+func newLFU() *lfu {
+	return &lfu{items: make(map[string]*lfuEntry)}
+}
+
+func (p *lfu) add(key string) {
+	p.seq++
+	e := &lfuEntry{key: key, freq: 1, seq: p.seq}
+	p.items[key] = e
+	heap.Push(&p.heap, e)
+}
+
+func (p *lfu) access(key string) {
+	if e, ok := p.items[key]; ok {
+		p.seq++
+		e.freq++
+		e.seq = p.seq
+		heap.Fix(&p.heap, e.index)
+	}
+}
+
+func (p *lfu) remove(key string) {
+	if e, ok := p.items[key]; ok {
+		heap.Remove(&p.heap, e.index)
+		delete(p.items, key)
+	}
+}
+
+func (p *lfu) evict() (string, bool) {
+	if len(p.heap) == 0 {
+		return "", false
+	}
+
+	e := heap.Pop(&p.heap).(*lfuEntry)
+	delete(p.items, e.key)
+	return e.key, true
+}
+
+// arc evicts keys using the Adaptive Replacement Cache algorithm, balancing between recently and frequently used keys
+// based on hits against recently evicted keys.
+type arc struct {
+	// capacity is the target number of entries, zero when only bounded by bytes.
+	capacity int
+
+	// target is the preferred size of t1.
+	target int
+
+	// t1 holds keys seen once recently and t2 keys seen at least twice. b1 and b2 hold keys recently evicted from t1
+	// and t2.
+	t1, t2, b1, b2 *lru
+}
+
+func newARC(capacity int) *arc {
+	return &arc{capacity: capacity, t1: newLRU(), t2: newLRU(), b1: newLRU(), b2: newLRU()}
+}
+
+// size returns the capacity used to bound the adaptive target and ghost lists.
+func (p *arc) size() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return max(p.t1.order.Len()+p.t2.order.Len(), 1)
+}
+
+func (p *arc) add(key string) {
+	c := p.size()
+
+	switch {
+	case p.b1.items[key] != nil:
+		// Recently evicted after a single use, favor recency
+		p.target = min(c, p.target+max(p.b2.order.Len()/p.b1.order.Len(), 1))
+		p.b1.remove(key)
+		p.t2.add(key)
+	case p.b2.items[key] != nil:
+		// Recently evicted after repeated use, favor frequency
+		p.target = max(0, p.target-max(p.b1.order.Len()/p.b2.order.Len(), 1))
+		p.b2.remove(key)
+		p.t2.add(key)
+	default:
+		p.t1.add(key)
+	}
+}
+
+func (p *arc) access(key string) {
+	if p.t1.items[key] != nil {
+		p.t1.remove(key)
+		p.t2.add(key)
+		return
+	}
+	p.t2.access(key)
+}
+
+func (p *arc) remove(key string) {
+	p.t1.remove(key)
+	p.t2.remove(key)
+}
+
+func (p *arc) evict() (string, bool) {
+	var key string
+	var ok bool
+
+	if n := p.t1.order.Len(); n > 0 && (n > p.target || p.t2.order.Len() == 0) {
+		key, ok = p.t1.evict()
+		p.ghost(p.b1, key)
+	} else {
+		key, ok = p.t2.evict()
+		if ok {
+			p.ghost(p.b2, key)
+		}
+	}
+
+	return key, ok
+}
+
+// ghost will record an evicted key within the ghost list, trimming it to capacity.
+func (p *arc) ghost(b *lru, key string) {
+	b.add(key)
+	for b.order.Len() > p.size() {
+		_, _ = b.evict()
+	}
+}
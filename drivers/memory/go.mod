@@ -0,0 +1,8 @@
+module github.com/tarmac-project/hord/drivers/memory
+
+go 1.23.0
+
+require github.com/tarmac-project/hord v0.9.0
+
+// Build against the hord module within this repository until v0.9.0 is released.
+replace github.com/tarmac-project/hord => ../..
@@ -0,0 +1,495 @@
+/*
+Package memory provides a Hord database driver for a bounded in-memory store.
+
+Unlike the hashmap driver, the memory driver limits the number of entries and bytes it holds, evicting entries once a
+limit is reached, which makes it safe to use as the Cache of a cache implementation. To use this driver, import it as
+follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/drivers/memory"
+	)
+
+Entries are evicted according to the configured Policy:
+
+  - LRU (default) evicts the least recently used entry.
+  - LFU evicts the least frequently used entry, breaking ties by least recent use.
+  - ARC uses the Adaptive Replacement Cache algorithm, balancing between recently and frequently used entries.
+
+Entries can expire after a TTL, configured for all entries or per entry using SetWithTTL(). Expired entries are removed
+when accessed or by a periodic cleanup when a CleanupInterval is configured. The OnEvict callback is called for every
+entry removed due to capacity or expiry.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the memory driver.
+
+	var db hord.Database
+	db, err := memory.Dial(memory.Config{
+		MaxEntries: 10000,
+		MaxBytes:   64 << 20,
+		Policy:     memory.LFU,
+		TTL:        5 * time.Minute,
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the memory driver, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Set a value
+	err = db.Set("key", []byte("value"))
+	if err != nil {
+	    // Handle error
+	}
+
+	// Retrieve a value
+	value, err := db.Get("key")
+	if err != nil {
+	    // Handle error
+	}
+*/
+package memory
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// Policy is the eviction policy used to select entries to evict once a limit is reached.
+type Policy string
+
+const (
+	// LRU evicts the least recently used entry.
+	LRU Policy = "lru"
+
+	// LFU evicts the least frequently used entry.
+	LFU Policy = "lfu"
+
+	// ARC evicts entries using the Adaptive Replacement Cache algorithm.
+	ARC Policy = "arc"
+)
+
+// EvictionReason describes why an entry was evicted.
+type EvictionReason string
+
+const (
+	// Capacity indicates the entry was evicted to stay within MaxEntries or MaxBytes.
+	Capacity EvictionReason = "capacity"
+
+	// Expired indicates the entry was evicted after its TTL elapsed.
+	Expired EvictionReason = "expired"
+)
+
+const (
+	// DefaultMaxEntries is the default maximum number of entries used when neither MaxEntries nor MaxBytes are set.
+	DefaultMaxEntries = 10000
+)
+
+// Config represents the configuration for the memory database.
+type Config struct {
+	// MaxEntries is the maximum number of entries held. Default is 10000 when MaxBytes is not set, otherwise
+	// unlimited.
+	MaxEntries int
+
+	// MaxBytes is the maximum combined size of keys and values held. Default is unlimited.
+	MaxBytes int64
+
+	// Policy is the eviction policy. Default is LRU.
+	Policy Policy
+
+	// TTL is the time after which entries expire, unless set with SetWithTTL(). Default is 0, which disables expiry.
+	TTL time.Duration
+
+	// CleanupInterval is the time between removals of expired entries. Default is 0, which only removes expired
+	// entries when they are accessed or evicted.
+	CleanupInterval time.Duration
+
+	// OnEvict is called for every entry evicted due to capacity or expiry. It is not called for entries removed by
+	// Delete() or replaced by Set(). The callback is called without locks held and may access the database.
+	OnEvict func(key string, data []byte, reason EvictionReason)
+
+	// Validation defines the rules applied to keys and values before they are stored. By default, only empty keys
+	// and values are rejected.
+	Validation hord.ValidationPolicy
+}
+
+// Database is a bounded in-memory implementation of the hord.Database interface.
+type Database struct {
+	sync.Mutex
+
+	config Config
+
+	// data holds the stored entries, nil once closed.
+	data   map[string]*entry
+	bytes  int64
+	policy policy
+
+	// done is closed to stop the periodic cleanup.
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+}
+
+// entry is a stored value.
+type entry struct {
+	data    []byte
+	expires time.Time
+}
+
+// eviction is an evicted entry awaiting the OnEvict callback.
+type eviction struct {
+	key    string
+	data   []byte
+	reason EvictionReason
+}
+
+// Stats provides counters describing the memory database.
+type Stats struct {
+	// Hits is the number of Get calls that found a value.
+	Hits uint64
+
+	// Misses is the number of Get calls that did not find a value, including expired values.
+	Misses uint64
+
+	// Evictions is the number of entries evicted due to capacity.
+	Evictions uint64
+
+	// Expirations is the number of entries removed after their TTL elapsed.
+	Expirations uint64
+
+	// Entries is the number of entries held.
+	Entries int
+
+	// Bytes is the combined size of keys and values held.
+	Bytes int64
+}
+
+var (
+	// ErrInvalidPolicy is returned by Dial when the Policy is not recognized.
+	ErrInvalidPolicy = errors.New("invalid eviction policy")
+)
+
+// Dial initializes and returns a new memory database instance.
+func Dial(conf Config) (*Database, error) {
+	switch conf.Policy {
+	case "":
+		conf.Policy = LRU
+	case LRU, LFU, ARC:
+	default:
+		return nil, ErrInvalidPolicy
+	}
+
+	if conf.MaxEntries <= 0 && conf.MaxBytes <= 0 {
+		conf.MaxEntries = DefaultMaxEntries
+	}
+
+	db := &Database{
+		config: conf,
+		data:   make(map[string]*entry),
+		policy: newPolicy(conf.Policy, conf.MaxEntries),
+		done:   make(chan struct{}),
+	}
+
+	if conf.CleanupInterval > 0 {
+		db.wg.Add(1)
+		go db.cleanup()
+	}
+
+	return db, nil
+}
+
+// Setup sets up the memory database. As the memory database requires no setup, it always returns nil.
+func (db *Database) Setup() error {
+	return nil
+}
+
+// Get retrieves data from the memory database based on the provided key.
+// It returns the data associated with the key or an error if the key is invalid or the data does not exist.
+func (db *Database) Get(key string) ([]byte, error) {
+	if err := db.config.Validation.ValidKey(key); err != nil {
+		return []byte(""), err
+	}
+
+	db.Lock()
+	if db.data == nil {
+		db.Unlock()
+		return []byte(""), hord.ErrNoDial
+	}
+
+	e, ok := db.data[key]
+	if !ok {
+		db.misses++
+		db.Unlock()
+		return []byte(""), hord.ErrNil
+	}
+
+	if e.expired(time.Now()) {
+		db.misses++
+		db.expirations++
+		db.policy.remove(key)
+		db.drop(key, e)
+		db.Unlock()
+		db.notify([]eviction{{key: key, data: e.data, reason: Expired}})
+		return []byte(""), hord.ErrNil
+	}
+
+	db.hits++
+	db.policy.access(key)
+	db.Unlock()
+
+	return e.data, nil
+}
+
+// Set inserts or updates data in the memory database based on the provided key, using the configured TTL.
+// It returns an error if the key or data is invalid or too large to store.
+func (db *Database) Set(key string, data []byte) error {
+	return db.SetWithTTL(key, data, db.config.TTL)
+}
+
+// SetWithTTL inserts or updates data in the memory database based on the provided key, expiring it after the TTL.
+// A TTL of 0 disables expiry for the entry. It returns hord.ErrDataTooLarge if the key and data are larger than
+// MaxBytes.
+func (db *Database) SetWithTTL(key string, data []byte, ttl time.Duration) error {
+	if err := db.config.Validation.ValidKey(key); err != nil {
+		return err
+	}
+
+	if err := db.config.Validation.ValidData(data); err != nil {
+		return err
+	}
+
+	if db.config.MaxBytes > 0 && size(key, data) > db.config.MaxBytes {
+		return hord.ErrDataTooLarge
+	}
+
+	e := &entry{data: data}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+
+	db.Lock()
+	if db.data == nil {
+		db.Unlock()
+		return hord.ErrNoDial
+	}
+
+	var evicted []eviction
+	if old, ok := db.data[key]; ok && !db.full(0, size(key, data)-size(key, old.data)) {
+		db.bytes += size(key, data) - size(key, old.data)
+		db.data[key] = e
+		db.policy.access(key)
+	} else if ok {
+		// Stop tracking larger values while making room for them so they are not selected for eviction themselves
+		db.policy.remove(key)
+		db.drop(key, old)
+		evicted = db.shrink(1, size(key, data))
+		db.policy.add(key)
+		db.policy.access(key)
+		db.data[key] = e
+		db.bytes += size(key, data)
+	} else {
+		// Make room before adding new keys so they are not selected for eviction themselves
+		evicted = db.shrink(1, size(key, data))
+		db.policy.add(key)
+		db.data[key] = e
+		db.bytes += size(key, data)
+	}
+	db.Unlock()
+
+	db.notify(evicted)
+	return nil
+}
+
+// Delete removes data from the memory database based on the provided key.
+// It returns an error if the key is invalid.
+func (db *Database) Delete(key string) error {
+	if err := db.config.Validation.ValidKey(key); err != nil {
+		return err
+	}
+
+	db.Lock()
+	defer db.Unlock()
+	if db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	if e, ok := db.data[key]; ok {
+		db.policy.remove(key)
+		db.drop(key, e)
+	}
+	return nil
+}
+
+// Keys retrieves a list of unexpired keys stored in the memory database.
+func (db *Database) Keys() ([]string, error) {
+	db.Lock()
+	defer db.Unlock()
+	if db.data == nil {
+		return []string{}, hord.ErrNoDial
+	}
+
+	now := time.Now()
+	var keys []string
+	for k, e := range db.data {
+		if !e.expired(now) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// HealthCheck performs a health check on the memory database.
+// Since the memory database is an in-memory implementation, it only fails once closed.
+func (db *Database) HealthCheck() error {
+	db.Lock()
+	defer db.Unlock()
+	if db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return nil
+}
+
+// Stats returns a snapshot of the memory database counters.
+func (db *Database) Stats() Stats {
+	db.Lock()
+	defer db.Unlock()
+
+	return Stats{
+		Hits:        db.hits,
+		Misses:      db.misses,
+		Evictions:   db.evictions,
+		Expirations: db.expirations,
+		Entries:     len(db.data),
+		Bytes:       db.bytes,
+	}
+}
+
+// Close stops the periodic cleanup and clears all stored data from memory. Eviction callbacks are not called for the
+// cleared data.
+func (db *Database) Close() {
+	db.Lock()
+	if db.data == nil {
+		db.Unlock()
+		return
+	}
+	db.data = nil
+	db.bytes = 0
+	db.policy = newPolicy(db.config.Policy, db.config.MaxEntries)
+	close(db.done)
+	db.Unlock()
+
+	db.wg.Wait()
+}
+
+// shrink will evict entries until the database has room for the additional entries and bytes. It should only be used
+// after acquiring the lock.
+func (db *Database) shrink(entries int, bytes int64) []eviction {
+	var evicted []eviction
+	for db.full(entries, bytes) {
+		key, ok := db.policy.evict()
+		if !ok {
+			break
+		}
+
+		e := db.data[key]
+		db.drop(key, e)
+		db.evictions++
+		evicted = append(evicted, eviction{key: key, data: e.data, reason: Capacity})
+	}
+
+	return evicted
+}
+
+// full returns true if the database would exceed MaxEntries or MaxBytes with the additional entries and bytes. It
+// should only be used after acquiring the lock.
+func (db *Database) full(entries int, bytes int64) bool {
+	return (db.config.MaxEntries > 0 && len(db.data)+entries > db.config.MaxEntries) ||
+		(db.config.MaxBytes > 0 && db.bytes+bytes > db.config.MaxBytes)
+}
+
+// expire will remove every expired entry. It should only be used after acquiring the lock.
+func (db *Database) expire(now time.Time) []eviction {
+	var evicted []eviction
+	for key, e := range db.data {
+		if e.expired(now) {
+			db.policy.remove(key)
+			db.drop(key, e)
+			db.expirations++
+			evicted = append(evicted, eviction{key: key, data: e.data, reason: Expired})
+		}
+	}
+
+	return evicted
+}
+
+// drop will remove the entry from the stored data. It should only be used after acquiring the lock.
+func (db *Database) drop(key string, e *entry) {
+	delete(db.data, key)
+	db.bytes -= size(key, e.data)
+}
+
+// notify will call the OnEvict callback for each evicted entry.
+func (db *Database) notify(evicted []eviction) {
+	if db.config.OnEvict == nil {
+		return
+	}
+
+	for _, e := range evicted {
+		db.config.OnEvict(e.key, e.data, e.reason)
+	}
+}
+
+// cleanup will periodically remove expired entries until the database is closed.
+func (db *Database) cleanup() {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(db.config.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.done:
+			return
+		case <-ticker.C:
+			db.Lock()
+			if db.data == nil {
+				db.Unlock()
+				return
+			}
+			evicted := db.expire(time.Now())
+			db.Unlock()
+
+			db.notify(evicted)
+		}
+	}
+}
+
+// expired returns true if the entry has expired at now.
+func (e *entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// size returns the number of bytes counted against MaxBytes for the key and data.
+func size(key string, data []byte) int64 {
+	return int64(len(key) + len(data))
+}
@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// sortedKeys is a helper function returning the sorted keys within the database.
+func sortedKeys(t *testing.T, db *Database) []string {
+	keys, err := db.Keys()
+	if err != nil {
+		t.Fatalf("Keys() returned error: %s", err)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestDial(t *testing.T) {
+	unitTests := map[string]struct {
+		config        Config
+		expectedError error
+	}{
+		"Default":        {config: Config{}},
+		"LRU":            {config: Config{Policy: LRU}},
+		"LFU":            {config: Config{Policy: LFU}},
+		"ARC":            {config: Config{Policy: ARC}},
+		"Invalid Policy": {config: Config{Policy: "invalid"}, expectedError: ErrInvalidPolicy},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			db, err := Dial(test.config)
+			if !errors.Is(err, test.expectedError) {
+				t.Fatalf("Dial(%v) returned error: %s, expected %s", test.config, err, test.expectedError)
+			}
+			if err == nil {
+				db.Close()
+			}
+		})
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		db, err := Dial(Config{})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+		defer db.Close()
+
+		if db.config.Policy != LRU || db.config.MaxEntries != DefaultMaxEntries {
+			t.Errorf("Dial() did not set defaults - %+v", db.config)
+		}
+	})
+}
+
+func TestEviction(t *testing.T) {
+	unitTests := map[string]struct {
+		policy  Policy
+		evicted string
+	}{
+		"LRU": {policy: LRU, evicted: "a"},
+		"LFU": {policy: LFU, evicted: "b"},
+		"ARC": {policy: ARC, evicted: "a"},
+	}
+
+	for name, test := range unitTests {
+		t.Run(name, func(t *testing.T) {
+			var evicted []string
+			db, err := Dial(Config{
+				MaxEntries: 2,
+				Policy:     test.policy,
+				OnEvict: func(key string, _ []byte, reason EvictionReason) {
+					if reason != Capacity {
+						t.Errorf("Unexpected eviction reason - %s", reason)
+					}
+					evicted = append(evicted, key)
+				},
+			})
+			if err != nil {
+				t.Fatalf("Dial() returned error: %s", err)
+			}
+			defer db.Close()
+
+			// a is used most often, b most recently
+			_ = db.Set("a", []byte("data"))
+			_ = db.Set("b", []byte("data"))
+			_, _ = db.Get("a")
+			_, _ = db.Get("a")
+			_, _ = db.Get("b")
+
+			if err := db.Set("c", []byte("data")); err != nil {
+				t.Fatalf("Set() returned error: %s", err)
+			}
+
+			if len(evicted) != 1 || evicted[0] != test.evicted {
+				t.Errorf("Unexpected evicted keys - got %v, expected [%s]", evicted, test.evicted)
+			}
+
+			if _, err := db.Get(test.evicted); !errors.Is(err, hord.ErrNil) {
+				t.Errorf("Expected evicted key to be missing - %v", err)
+			}
+
+			if s := db.Stats(); s.Entries != 2 || s.Evictions != 1 {
+				t.Errorf("Unexpected stats - %+v", s)
+			}
+		})
+	}
+
+	t.Run("ARC Ghost Hit", func(t *testing.T) {
+		db, err := Dial(Config{MaxEntries: 2, Policy: ARC})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+		defer db.Close()
+
+		_ = db.Set("a", []byte("data"))
+		_ = db.Set("b", []byte("data"))
+		_, _ = db.Get("b")
+		_ = db.Set("c", []byte("data"))
+
+		// a was evicted after a single use, so re-adding it favors frequently used keys over c
+		_ = db.Set("a", []byte("data"))
+
+		keys := sortedKeys(t, db)
+		if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+			t.Errorf("Unexpected keys - got %v, expected [a b]", keys)
+		}
+	})
+
+	t.Run("Overwrite Does Not Evict", func(t *testing.T) {
+		db, err := Dial(Config{MaxEntries: 2})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+		defer db.Close()
+
+		_ = db.Set("a", []byte("data"))
+		_ = db.Set("b", []byte("data"))
+		_ = db.Set("a", []byte("new-data"))
+
+		if n := db.Stats().Evictions; n != 0 {
+			t.Errorf("Unexpected evictions - %d", n)
+		}
+	})
+}
+
+func TestMaxBytes(t *testing.T) {
+	db, err := Dial(Config{MaxBytes: 20})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	defer db.Close()
+
+	t.Run("Too Large", func(t *testing.T) {
+		if err := db.Set("key", make([]byte, 20)); !errors.Is(err, hord.ErrDataTooLarge) {
+			t.Errorf("Set() returned error: %v, expected %s", err, hord.ErrDataTooLarge)
+		}
+	})
+
+	t.Run("Evicts To Fit", func(t *testing.T) {
+		_ = db.Set("a", make([]byte, 9))
+		_ = db.Set("b", make([]byte, 9))
+		_ = db.Set("c", make([]byte, 9))
+
+		keys := sortedKeys(t, db)
+		if len(keys) != 2 || keys[0] != "b" || keys[1] != "c" {
+			t.Errorf("Unexpected keys - got %v, expected [b c]", keys)
+		}
+
+		if n := db.Stats().Bytes; n != 20 {
+			t.Errorf("Unexpected byte count - got %d, expected 20", n)
+		}
+	})
+
+	t.Run("Growing Overwrite", func(t *testing.T) {
+		_ = db.Set("c", make([]byte, 14))
+
+		keys := sortedKeys(t, db)
+		if len(keys) != 1 || keys[0] != "c" {
+			t.Errorf("Unexpected keys - got %v, expected [c]", keys)
+		}
+	})
+}
+
+func TestGrowingOverwrite(t *testing.T) {
+	for _, policy := range []Policy{LRU, LFU, ARC} {
+		t.Run(string(policy), func(t *testing.T) {
+			db, err := Dial(Config{MaxBytes: 20, Policy: policy})
+			if err != nil {
+				t.Fatalf("Dial() returned error: %s", err)
+			}
+			defer db.Close()
+
+			_ = db.Set("a", make([]byte, 9))
+			_ = db.Set("b", make([]byte, 9))
+
+			// Frequently used keys are kept over the key being written under LFU
+			for i := 0; i < 3; i++ {
+				_, _ = db.Get("b")
+			}
+
+			if err := db.Set("a", make([]byte, 14)); err != nil {
+				t.Fatalf("Set() returned error: %s", err)
+			}
+
+			data, err := db.Get("a")
+			if err != nil || len(data) != 14 {
+				t.Errorf("Get() returned %d bytes - %v, expected the overwritten value", len(data), err)
+			}
+
+			if s := db.Stats(); s.Entries != 1 || s.Bytes != 15 || s.Evictions != 1 {
+				t.Errorf("Unexpected stats - %+v", s)
+			}
+		})
+	}
+}
+
+func TestTTL(t *testing.T) {
+	var lock sync.Mutex
+	expired := make(map[string]EvictionReason)
+
+	db, err := Dial(Config{
+		TTL:             20 * time.Millisecond,
+		CleanupInterval: 10 * time.Millisecond,
+		OnEvict: func(key string, _ []byte, reason EvictionReason) {
+			lock.Lock()
+			defer lock.Unlock()
+			expired[key] = reason
+		},
+	})
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	defer db.Close()
+
+	_ = db.Set("default", []byte("data"))
+	_ = db.SetWithTTL("long", []byte("data"), time.Minute)
+	_ = db.SetWithTTL("forever", []byte("data"), 0)
+	_ = db.SetWithTTL("accessed", []byte("data"), time.Millisecond)
+
+	<-time.After(5 * time.Millisecond)
+
+	t.Run("Expired On Access", func(t *testing.T) {
+		if _, err := db.Get("accessed"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+	})
+
+	t.Run("Expired By Cleanup", func(t *testing.T) {
+		deadline := time.Now().Add(5 * time.Second)
+		for db.Stats().Expirations < 2 {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for cleanup")
+			}
+			<-time.After(time.Millisecond)
+		}
+
+		keys := sortedKeys(t, db)
+		if len(keys) != 2 || keys[0] != "forever" || keys[1] != "long" {
+			t.Errorf("Unexpected keys - got %v, expected [forever long]", keys)
+		}
+	})
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(expired) != 2 || expired["default"] != Expired || expired["accessed"] != Expired {
+		t.Errorf("Unexpected eviction callbacks - %v", expired)
+	}
+}
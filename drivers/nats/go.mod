@@ -6,13 +6,16 @@ toolchain go1.24.1
 
 require (
 	github.com/nats-io/nats.go v1.42.0
-	github.com/tarmac-project/hord v0.8.2
+	github.com/nats-io/nuid v1.0.1
+	github.com/tarmac-project/hord v0.9.0
 )
 
 require (
 	github.com/klauspost/compress v1.18.0 // indirect
 	github.com/nats-io/nkeys v0.4.11 // indirect
-	github.com/nats-io/nuid v1.0.1 // indirect
 	golang.org/x/crypto v0.37.0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
 )
+
+// Build against the hord module within this repository until v0.9.0 is released.
+replace github.com/tarmac-project/hord => ../..
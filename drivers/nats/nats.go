@@ -55,17 +55,38 @@ Here are some examples demonstrating common usage patterns for the NATS driver.
 	if err != nil {
 	    // Handle error
 	}
+
+# Near Cache
+
+DialNearCache() mirrors a bucket within process memory, kept up to date by a NATS watcher. Reads are served from memory
+without a round trip to NATS, while writes are sent to NATS and applied locally once acknowledged. The mirror is fully
+resynced after reconnecting or when the watcher fails, and optionally every ResyncInterval. NearCache.Stats() reports
+the lag between changes being written and applied, measured against the NATS server clock, along with the number of
+resyncs.
+
+	db, err := nats.Dial(nats.Config{URL: "nats", Bucket: "config"})
+	if err != nil {
+	    // Handle connection error
+	}
+
+	nc, err := nats.DialNearCache(nats.NearCacheConfig{Database: db})
+	if err != nil {
+	    // Handle sync error
+	}
 */
 package nats
 
 import (
+	"bytes"
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"regexp"
 	"sync"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
 	"github.com/tarmac-project/hord"
 )
 
@@ -80,6 +101,17 @@ type Config struct {
 	// to the `^[a-zA-Z0-9_-]+$` regex.
 	Bucket string
 
+	// History is the number of historical values kept for each key, which can be accessed with History() and
+	// GetAt(). Default is 1, which keeps only the current value. The maximum is 64.
+	History uint8
+
+	// ObjectBucket is an optional bucket name for the NATS Object Store. When set, values can be streamed with
+	// OpenReader() and OpenWriter(). Streamed values are stored within the Object Store, while the key-value store
+	// keeps a pointer to the object, so streamed values are returned by Get(), listed by Keys() and removed by
+	// Delete() like any other value. Objects are removed once their key is overwritten or deleted, so GetAt() returns
+	// hord.ErrNil for earlier streamed revisions. ObjectBucket names must adhere to the same rules as Bucket.
+	ObjectBucket string
+
 	// Servers enables connectivity to a cluster of NATS servers. Each entry must follow the NATS URL format.
 	Servers []string
 
@@ -94,6 +126,10 @@ type Config struct {
 	// Options extend the connection options available within NATS. NATS has many advanced configuration options;
 	// use Options to modify those options.
 	Options nats.Options
+
+	// Validation defines the rules applied to keys and values before they are stored. By default, only empty keys
+	// and values are rejected.
+	Validation hord.ValidationPolicy
 }
 
 // Database is a NATS implementation of the hord.Database interface.
@@ -105,6 +141,12 @@ type Database struct {
 
 	// kv provides a NATS key-value store
 	kv nats.KeyValue
+
+	// obs provides a NATS object store, used for streaming values
+	obs nats.ObjectStore
+
+	// validation defines the rules applied to keys and values
+	validation hord.ValidationPolicy
 }
 
 // reBucket is used to validate bucket names
@@ -129,18 +171,40 @@ var (
 
 	// ErrKVStoreUnhealthy is returned when the key-value store health check fails
 	ErrKVStoreUnhealthy = fmt.Errorf("kv store unhealthy")
+
+	// ErrObjectStoreFailed is returned when creating an object store fails
+	ErrObjectStoreFailed = fmt.Errorf("unable to open object store")
+
+	// ErrObjectStoreDisabled is returned when streaming values without an ObjectBucket configured
+	ErrObjectStoreDisabled = fmt.Errorf("object store is not configured")
 )
 
+// objectPointer prefixes key-value store values pointing to a streamed value, followed by the name of the object.
+const objectPointer = "\x00hord.object:"
+
+// objectName returns the name of the object if the value is a pointer to a streamed value.
+func objectName(value []byte) (string, bool) {
+	if !bytes.HasPrefix(value, []byte(objectPointer)) {
+		return "", false
+	}
+	return string(value[len(objectPointer):]), true
+}
+
 // Dial initializes and returns a new NATS database instance.
 func Dial(cfg Config) (*Database, error) {
 	var err error
-	db := &Database{}
+	db := &Database{validation: cfg.Validation}
 
 	// Validate Bucket
 	if cfg.Bucket == "" || !reBucket.MatchString(cfg.Bucket) {
 		return db, ErrBucketNameInvalid
 	}
 
+	// Validate Object Bucket
+	if cfg.ObjectBucket != "" && !reBucket.MatchString(cfg.ObjectBucket) {
+		return db, ErrBucketNameInvalid
+	}
+
 	// Build URL for cluster of servers
 	if cfg.URL == "" && len(cfg.Servers) < 1 {
 		return db, ErrEmptyConnection
@@ -167,11 +231,19 @@ func Dial(cfg Config) (*Database, error) {
 	}
 
 	// Create a key-value store within JetStream
-	db.kv, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: cfg.Bucket})
+	db.kv, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: cfg.Bucket, History: cfg.History})
 	if err != nil {
 		return db, errors.Join(ErrKVStoreFailed, err)
 	}
 
+	// Create an object store within JetStream if enabled
+	if cfg.ObjectBucket != "" {
+		db.obs, err = js.CreateObjectStore(&nats.ObjectStoreConfig{Bucket: cfg.ObjectBucket})
+		if err != nil {
+			return db, errors.Join(ErrObjectStoreFailed, err)
+		}
+	}
+
 	return db, nil
 }
 
@@ -188,7 +260,7 @@ func (db *Database) Setup() error {
 // It returns the data associated with the key or an error if the key is invalid or the data does not exist.
 func (db *Database) Get(key string) ([]byte, error) {
 	// Validate the key
-	if err := hord.ValidKey(key); err != nil {
+	if err := db.validation.ValidKey(key); err != nil {
 		return []byte(""), err
 	}
 
@@ -211,22 +283,87 @@ func (db *Database) Get(key string) ([]byte, error) {
 		return []byte(""), fmt.Errorf("unable to fetch key: %w", err)
 	}
 
+	// Read streamed values from the NATS object store
+	if name, ok := objectName(r.Value()); ok {
+		return db.getObject(name)
+	}
+
 	return r.Value(), nil
 }
 
+// getObject reads the streamed value stored within the named object. The lock must be held by the caller.
+func (db *Database) getObject(name string) ([]byte, error) {
+	if db.obs == nil {
+		return []byte(""), ErrObjectStoreDisabled
+	}
+
+	data, err := db.obs.GetBytes(name)
+	if err != nil {
+		if errors.Is(err, nats.ErrObjectNotFound) {
+			return []byte(""), hord.ErrNil
+		}
+		return []byte(""), fmt.Errorf("unable to fetch object: %w", err)
+	}
+
+	return data, nil
+}
+
+// put writes the value to the NATS key-value store and removes the object streamed to the key before, if any. The
+// write lock must be held by the caller.
+func (db *Database) put(key string, data []byte) error {
+	previous := db.previousObject(key)
+
+	_, err := db.kv.Put(key, data)
+	if err != nil {
+		return fmt.Errorf("unable to set key: %w", err)
+	}
+
+	db.removeObject(previous)
+	return nil
+}
+
+// previousObject returns the name of the object currently streamed to the key, empty if there is none. The lock must
+// be held by the caller.
+func (db *Database) previousObject(key string) string {
+	if db.obs == nil {
+		return ""
+	}
+
+	r, err := db.kv.Get(key)
+	if err != nil {
+		return ""
+	}
+
+	name, _ := objectName(r.Value())
+	return name
+}
+
+// removeObject removes the named object once nothing points to it. Failures leave the object behind, unreachable.
+func (db *Database) removeObject(name string) {
+	if name == "" || db.obs == nil {
+		return
+	}
+	_ = db.obs.Delete(name)
+}
+
 // Set inserts or updates data in the NATS database based on the provided key.
 // It returns an error if the key or data is invalid.
 func (db *Database) Set(key string, data []byte) error {
 	// Validate the key
-	if err := hord.ValidKey(key); err != nil {
+	if err := db.validation.ValidKey(key); err != nil {
 		return err
 	}
 
 	// Validate the data
-	if err := hord.ValidData(data); err != nil {
+	if err := db.validation.ValidData(data); err != nil {
 		return err
 	}
 
+	// Reject values that would be read as pointers to streamed values
+	if _, ok := objectName(data); ok {
+		return hord.ErrInvalidData
+	}
+
 	// Acquire a write lock to ensure data consistency during insertion/update
 	db.Lock()
 	defer db.Unlock()
@@ -237,19 +374,14 @@ func (db *Database) Set(key string, data []byte) error {
 	}
 
 	// Insert or update the key-value pair in the NATS key-value store
-	_, err := db.kv.Put(key, data)
-	if err != nil {
-		return fmt.Errorf("unable to set key: %w", err)
-	}
-
-	return nil
+	return db.put(key, data)
 }
 
 // Delete removes data from the NATS database based on the provided key.
 // It returns an error if the key is invalid.
 func (db *Database) Delete(key string) error {
 	// Validate the key
-	if err := hord.ValidKey(key); err != nil {
+	if err := db.validation.ValidKey(key); err != nil {
 		return err
 	}
 
@@ -262,11 +394,13 @@ func (db *Database) Delete(key string) error {
 		return hord.ErrNoDial
 	}
 
-	// Delete the key from the NATS key-value store
+	// Delete the key from the NATS key-value store, along with any streamed value
+	previous := db.previousObject(key)
 	err := db.kv.Delete(key)
 	if err != nil {
 		return fmt.Errorf("unable to remove key: %w", err)
 	}
+	db.removeObject(previous)
 
 	return nil
 }
@@ -331,4 +465,250 @@ func (db *Database) Close() {
 	if err != nil {
 		db.conn.Close()
 	}
+
+	// Requests may still succeed while the connection drains, so stop using the stores now
+	db.kv = nil
+	db.obs = nil
+}
+
+// History returns the revisions of the specified key retained by the NATS key-value store, ordered from oldest to
+// newest. The number of revisions retained is controlled by the History configuration. Streamed values are read from
+// the NATS Object Store, while the objects of replaced streamed values are removed, leaving their revisions empty.
+func (db *Database) History(key string) ([]hord.Revision, error) {
+	// Validate the key
+	if err := db.validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	// Acquire a read lock to ensure data consistency during retrieval
+	db.RLock()
+	defer db.RUnlock()
+
+	// Check if the NATS key-value store is initialized
+	if db.kv == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	// Retrieve the history from the NATS key-value store
+	entries, err := db.kv.History(key)
+	if err != nil {
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			return nil, hord.ErrNil
+		}
+		return nil, fmt.Errorf("unable to fetch history: %w", err)
+	}
+
+	revisions := make([]hord.Revision, 0, len(entries))
+	for _, e := range entries {
+		value := e.Value()
+
+		// Read streamed values from the NATS object store, objects of replaced values have been removed
+		if name, ok := objectName(value); ok {
+			value, err = db.getObject(name)
+			if err != nil && !errors.Is(err, hord.ErrNil) {
+				return nil, err
+			}
+		}
+
+		revisions = append(revisions, hord.Revision{
+			Revision:  e.Revision(),
+			Value:     value,
+			Timestamp: e.Created(),
+			Deleted:   e.Operation() != nats.KeyValuePut,
+		})
+	}
+
+	return revisions, nil
+}
+
+// GetAt returns the value of the specified key at the specified revision from the NATS key-value store.
+func (db *Database) GetAt(key string, revision uint64) ([]byte, error) {
+	// Validate the key
+	if err := db.validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	// Acquire a read lock to ensure data consistency during retrieval
+	db.RLock()
+	defer db.RUnlock()
+
+	// Check if the NATS key-value store is initialized
+	if db.kv == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	// Retrieve the revision from the NATS key-value store
+	e, err := db.kv.GetRevision(key, revision)
+	if err != nil {
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			return nil, hord.ErrNil
+		}
+		return nil, fmt.Errorf("unable to fetch revision: %w", err)
+	}
+
+	if name, ok := objectName(e.Value()); ok {
+		return db.getObject(name)
+	}
+
+	return e.Value(), nil
+}
+
+// OpenReader opens a reader for the value of the specified key. Streamed values are read from the NATS Object Store,
+// while values stored with Set() are read from the key-value store. Callers must close the returned reader.
+func (db *Database) OpenReader(key string) (io.ReadCloser, error) {
+	// Validate the key
+	if err := db.validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	// Acquire a read lock to ensure data consistency during retrieval
+	db.RLock()
+	defer db.RUnlock()
+
+	// Check if the NATS key-value store is initialized
+	if db.kv == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	// Check if the NATS object store is enabled
+	if db.obs == nil {
+		return nil, ErrObjectStoreDisabled
+	}
+
+	// Look up the value within the NATS key-value store
+	e, err := db.kv.Get(key)
+	if err != nil {
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			return nil, hord.ErrNil
+		}
+		return nil, fmt.Errorf("unable to fetch key: %w", err)
+	}
+
+	name, ok := objectName(e.Value())
+	if !ok {
+		return io.NopCloser(bytes.NewReader(e.Value())), nil
+	}
+
+	// Open the object from the NATS object store
+	r, err := db.obs.Get(name)
+	if err != nil {
+		if errors.Is(err, nats.ErrObjectNotFound) {
+			return nil, hord.ErrNil
+		}
+		return nil, fmt.Errorf("unable to fetch object: %w", err)
+	}
+
+	return r, nil
+}
+
+// OpenWriter opens a writer for the value of the specified key within the NATS Object Store. Data is streamed to
+// NATS as it is written. When the writer is closed, the object is committed and the key is pointed to it, replacing
+// the previous value of the key.
+func (db *Database) OpenWriter(key string) (io.WriteCloser, error) {
+	// Validate the key
+	if err := db.validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	// Acquire a read lock to ensure data consistency during insertion/update
+	db.RLock()
+	defer db.RUnlock()
+
+	// Check if the NATS key-value store is initialized
+	if db.kv == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	// Check if the NATS object store is enabled
+	if db.obs == nil {
+		return nil, ErrObjectStoreDisabled
+	}
+
+	// Every write streams to a new object, so readers of the previous value are not disturbed
+	name := key + "." + nuid.Next()
+
+	pr, pw := io.Pipe()
+	w := &objectWriter{
+		pw:         pw,
+		validation: db.validation,
+		done:       make(chan error, 1),
+		commit: func() error {
+			db.Lock()
+			defer db.Unlock()
+
+			if db.kv == nil {
+				db.removeObject(name)
+				return hord.ErrNoDial
+			}
+
+			if err := db.put(key, []byte(objectPointer+name)); err != nil {
+				db.removeObject(name)
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Put consumes the pipe until the writer is closed
+	obs := db.obs
+	go func() {
+		_, err := obs.Put(&nats.ObjectMeta{Name: name}, pr)
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+
+	return w, nil
+}
+
+// objectWriter streams data to the NATS Object Store through a pipe.
+type objectWriter struct {
+	pw         *io.PipeWriter
+	validation hord.ValidationPolicy
+	size       int
+	done       chan error
+	closed     bool
+
+	// commit points the key to the object once it is stored.
+	commit func() error
+}
+
+// Write streams data to the NATS Object Store.
+func (w *objectWriter) Write(p []byte) (int, error) {
+	n, err := w.pw.Write(p)
+	w.size += n
+	if err == nil && w.validation.MaxValueSize > 0 && w.size > w.validation.MaxValueSize {
+		return n, hord.ErrDataTooLarge
+	}
+	return n, err
+}
+
+// Close commits the object, waits for NATS to acknowledge it and points the key to it. Empty or invalid values are
+// discarded.
+func (w *objectWriter) Close() error {
+	if w.closed {
+		return io.ErrClosedPipe
+	}
+	w.closed = true
+
+	// Validate the data before committing the object
+	var verr error
+	if w.size == 0 {
+		verr = hord.ErrInvalidData
+	} else if w.validation.MaxValueSize > 0 && w.size > w.validation.MaxValueSize {
+		verr = hord.ErrDataTooLarge
+	}
+
+	if verr != nil {
+		_ = w.pw.CloseWithError(verr)
+		<-w.done
+		return verr
+	}
+
+	_ = w.pw.Close()
+	err := <-w.done
+	if err != nil {
+		return fmt.Errorf("unable to store object: %w", err)
+	}
+
+	return w.commit()
 }
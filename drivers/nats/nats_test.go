@@ -1,11 +1,18 @@
 package nats
 
 import (
+	"bytes"
 	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/tarmac-project/hord"
 )
 
 type TestCase struct {
@@ -109,3 +116,222 @@ func TestNATSConnectivity(t *testing.T) {
 		})
 	}
 }
+
+func TestStreaming(t *testing.T) {
+	t.Run("Object Store Disabled", func(t *testing.T) {
+		db, err := Dial(Config{URL: "nats", Bucket: "test"})
+		if err != nil {
+			t.Fatalf("unexpected failure while Dialing database - %s", err)
+		}
+		defer db.Close()
+
+		if _, err := db.OpenReader("stream"); !errors.Is(err, ErrObjectStoreDisabled) {
+			t.Errorf("expected ErrObjectStoreDisabled, got %v", err)
+		}
+		if _, err := db.OpenWriter("stream"); !errors.Is(err, ErrObjectStoreDisabled) {
+			t.Errorf("expected ErrObjectStoreDisabled, got %v", err)
+		}
+	})
+
+	t.Run("Invalid Object Bucket", func(t *testing.T) {
+		_, err := Dial(Config{URL: "nats", Bucket: "test", ObjectBucket: "invalid bucket"})
+		if !errors.Is(err, ErrBucketNameInvalid) {
+			t.Errorf("expected ErrBucketNameInvalid, got %v", err)
+		}
+	})
+
+	t.Run("Object Store", func(t *testing.T) {
+		db, err := Dial(Config{URL: "nats", Bucket: "test", ObjectBucket: "teststream"})
+		if err != nil {
+			t.Fatalf("unexpected failure while Dialing database - %s", err)
+		}
+		defer db.Close()
+
+		var s hord.Streamer = db
+		value := bytes.Repeat([]byte("streamed value "), 100000)
+
+		w, err := s.OpenWriter("stream")
+		if err != nil {
+			t.Fatalf("unexpected error opening writer - %s", err)
+		}
+		if _, err := io.Copy(w, bytes.NewReader(value)); err != nil {
+			t.Fatalf("unexpected error writing stream - %s", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("unexpected error closing writer - %s", err)
+		}
+
+		r, err := s.OpenReader("stream")
+		if err != nil {
+			t.Fatalf("unexpected error opening reader - %s", err)
+		}
+		defer r.Close() // nolint:errcheck
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("unexpected error reading stream - %s", err)
+		}
+		if !bytes.Equal(data, value) {
+			t.Errorf("data mismatch - got %d bytes, expected %d bytes", len(data), len(value))
+		}
+
+		if _, err := s.OpenReader("missing"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("expected ErrNil, got %v", err)
+		}
+
+		// Streamed values are stored under the key like any other value
+		got, err := db.Get("stream")
+		if err != nil || !bytes.Equal(got, value) {
+			t.Errorf("unexpected Get result for streamed value - %d bytes, %v", len(got), err)
+		}
+
+		keys, err := db.Keys()
+		if err != nil || !slices.Contains(keys, "stream") {
+			t.Errorf("expected streamed key within Keys, got %v - %v", keys, err)
+		}
+
+		// Values stored with Set replace streamed values and can be read as streams
+		if err := db.Set("stream", []byte("small value")); err != nil {
+			t.Fatalf("unexpected error setting value - %s", err)
+		}
+
+		r, err = s.OpenReader("stream")
+		if err != nil {
+			t.Fatalf("unexpected error opening reader - %s", err)
+		}
+		data, err = io.ReadAll(r)
+		_ = r.Close()
+		if err != nil || string(data) != "small value" {
+			t.Errorf("unexpected stream of value stored with Set - %s, %v", data, err)
+		}
+
+		objects, _ := db.obs.List()
+		for _, o := range objects {
+			if strings.HasPrefix(o.Name, "stream.") {
+				t.Errorf("expected replaced object %s to be removed", o.Name)
+			}
+		}
+
+		// Deleting the key removes streamed values
+		w, err = s.OpenWriter("stream")
+		if err != nil {
+			t.Fatalf("unexpected error opening writer - %s", err)
+		}
+		_, _ = w.Write([]byte("streamed again"))
+		if err := w.Close(); err != nil {
+			t.Fatalf("unexpected error closing writer - %s", err)
+		}
+
+		if err := db.Delete("stream"); err != nil {
+			t.Fatalf("unexpected error deleting key - %s", err)
+		}
+		if _, err := s.OpenReader("stream"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("expected ErrNil after Delete, got %v", err)
+		}
+		if _, err := db.Get("stream"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("expected ErrNil after Delete, got %v", err)
+		}
+
+		// Values mimicking pointers to streamed values are rejected
+		if err := db.Set("pointer", []byte(objectPointer+"other")); !errors.Is(err, hord.ErrInvalidData) {
+			t.Errorf("expected ErrInvalidData, got %v", err)
+		}
+
+		w, err = s.OpenWriter("empty")
+		if err != nil {
+			t.Fatalf("unexpected error opening writer - %s", err)
+		}
+		if err := w.Close(); !errors.Is(err, hord.ErrInvalidData) {
+			t.Errorf("expected ErrInvalidData, got %v", err)
+		}
+		if _, err := s.OpenReader("empty"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("expected ErrNil for discarded stream, got %v", err)
+		}
+	})
+}
+
+func TestHistory(t *testing.T) {
+	db, err := Dial(Config{URL: "nats", Bucket: "testhistory", History: 5})
+	if err != nil {
+		t.Fatalf("unexpected failure while Dialing database - %s", err)
+	}
+	defer db.Close()
+
+	var h hord.Historian = db
+	key := fmt.Sprintf("config-%d", time.Now().UnixNano())
+
+	for _, v := range []string{"v1", "v2", "v3"} {
+		if err := db.Set(key, []byte(v)); err != nil {
+			t.Fatalf("unexpected error setting key - %s", err)
+		}
+	}
+	if err := db.Delete(key); err != nil {
+		t.Fatalf("unexpected error deleting key - %s", err)
+	}
+
+	revisions, err := h.History(key)
+	if err != nil {
+		t.Fatalf("unexpected error fetching history - %s", err)
+	}
+	if len(revisions) != 4 {
+		t.Fatalf("unexpected number of revisions - got %d, expected 4", len(revisions))
+	}
+	if !revisions[3].Deleted {
+		t.Errorf("expected latest revision to record a deletion")
+	}
+
+	data, err := h.GetAt(key, revisions[1].Revision)
+	if err != nil {
+		t.Fatalf("unexpected error fetching revision - %s", err)
+	}
+	if string(data) != "v2" {
+		t.Errorf("unexpected value at revision - got %s, expected v2", data)
+	}
+
+	if _, err := h.GetAt(key, revisions[3].Revision); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("expected ErrNil for deleted revision, got %v", err)
+	}
+
+	if _, err := h.History("missing"); !errors.Is(err, hord.ErrNil) {
+		t.Errorf("expected ErrNil for missing key, got %v", err)
+	}
+
+	t.Run("Streamed Values", func(t *testing.T) {
+		db, err := Dial(Config{URL: "nats", Bucket: "testhistory", History: 5, ObjectBucket: "testhistorystream"})
+		if err != nil {
+			t.Fatalf("unexpected failure while Dialing database - %s", err)
+		}
+		defer db.Close()
+
+		key := fmt.Sprintf("stream-%d", time.Now().UnixNano())
+		if err := db.Set(key, []byte("v1")); err != nil {
+			t.Fatalf("unexpected error setting key - %s", err)
+		}
+
+		w, err := db.OpenWriter(key)
+		if err != nil {
+			t.Fatalf("unexpected error opening writer - %s", err)
+		}
+		_, _ = w.Write([]byte("streamed value"))
+		if err := w.Close(); err != nil {
+			t.Fatalf("unexpected error closing writer - %s", err)
+		}
+
+		revisions, err := db.History(key)
+		if err != nil {
+			t.Fatalf("unexpected error fetching history - %s", err)
+		}
+		if len(revisions) != 2 {
+			t.Fatalf("unexpected number of revisions - got %d, expected 2", len(revisions))
+		}
+
+		// History and GetAt both return the streamed value rather than the pointer to it
+		if string(revisions[1].Value) != "streamed value" {
+			t.Errorf("unexpected value within history - got %q, expected streamed value", revisions[1].Value)
+		}
+		data, err := db.GetAt(key, revisions[1].Revision)
+		if err != nil || !bytes.Equal(data, revisions[1].Value) {
+			t.Errorf("unexpected value at revision - got %q - %v, expected %q", data, err, revisions[1].Value)
+		}
+	})
+}
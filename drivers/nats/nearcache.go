@@ -0,0 +1,456 @@
+package nats
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/tarmac-project/hord"
+)
+
+const (
+	// DefaultSyncTimeout is the default time DialNearCache waits for the initial sync.
+	DefaultSyncTimeout = 10 * time.Second
+
+	// watchRetryInterval is the time between attempts to restart a failed watcher.
+	watchRetryInterval = time.Second
+)
+
+// NearCacheConfig provides the configuration options for a NearCache.
+type NearCacheConfig struct {
+	// Database is the NATS database mirrored by the NearCache. The NearCache takes ownership of the Database and
+	// closes it on Close().
+	Database *Database
+
+	// ResyncInterval is the time between full resyncs, which replay the bucket and remove keys missed while the
+	// watcher was behind. Every resync reloads the whole bucket. Resyncs always happen when the connection is
+	// re-established or the watcher fails. Default is 0, which disables periodic resyncs.
+	ResyncInterval time.Duration
+
+	// SyncTimeout is the time DialNearCache waits for the initial sync to complete. Default is 10 seconds.
+	SyncTimeout time.Duration
+}
+
+// NearCache is an in-process mirror of a NATS key-value bucket, kept up to date by a watcher. Reads are served from
+// memory while writes are sent to NATS and applied locally once acknowledged, so writers read their own writes. Values
+// written by other clients are visible once delivered by the watcher. It satisfies the Hord database interface.
+type NearCache struct {
+	db *Database
+
+	lock     sync.RWMutex
+	entries  map[string]nearEntry
+	revision uint64
+	synced   bool
+
+	resyncInterval time.Duration
+	resync         chan struct{}
+	ready          chan struct{}
+	readyOnce      sync.Once
+	done           chan struct{}
+	closeOnce      sync.Once
+	wg             sync.WaitGroup
+
+	updates    atomic.Uint64
+	resyncs    atomic.Uint64
+	lastResync atomic.Int64
+	lag        atomic.Int64
+	maxLag     atomic.Int64
+}
+
+// nearEntry is a value held by a NearCache and the revision it was written at.
+type nearEntry struct {
+	data     []byte
+	revision uint64
+}
+
+// NearCacheStats provides metrics describing how closely a NearCache mirrors its bucket.
+type NearCacheStats struct {
+	// Entries is the number of keys held in memory.
+	Entries int
+
+	// Revision is the latest bucket revision applied.
+	Revision uint64
+
+	// Updates is the number of changes applied from the watcher after the initial sync.
+	Updates uint64
+
+	// Lag is the time between a change being written to the bucket and applied in memory, for the latest change. The
+	// write time is taken from the NATS server clock, so clock skew between the server and this host is included.
+	Lag time.Duration
+
+	// MaxLag is the largest Lag observed.
+	MaxLag time.Duration
+
+	// Resyncs is the number of completed syncs, including the initial sync.
+	Resyncs uint64
+
+	// LastResync is the time the latest sync completed.
+	LastResync time.Time
+
+	// Synced is false while the watcher is being restarted after a failure.
+	Synced bool
+}
+
+var (
+	// ErrNearCacheSync is returned when the NearCache is unable to complete the initial sync within the SyncTimeout.
+	ErrNearCacheSync = fmt.Errorf("unable to sync near cache")
+
+	// ErrNearCacheUnsynced is returned by HealthCheck while the watcher is being restarted.
+	ErrNearCacheUnsynced = fmt.Errorf("near cache is not synced")
+)
+
+// DialNearCache creates a NearCache mirroring the bucket of the provided Database. It returns once the bucket has been
+// loaded into memory, or with an error wrapping ErrNearCacheSync if the SyncTimeout elapses first.
+func DialNearCache(cfg NearCacheConfig) (*NearCache, error) {
+	if cfg.Database == nil || cfg.Database.kv == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if cfg.SyncTimeout <= 0 {
+		cfg.SyncTimeout = DefaultSyncTimeout
+	}
+
+	nc := &NearCache{
+		db:             cfg.Database,
+		entries:        make(map[string]nearEntry),
+		resyncInterval: cfg.ResyncInterval,
+		resync:         make(chan struct{}, 1),
+		ready:          make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+
+	// Resync after reconnecting, as changes may have been missed while disconnected
+	if conn := cfg.Database.conn; conn != nil {
+		prev := conn.ReconnectHandler()
+		conn.SetReconnectHandler(func(c *nats.Conn) {
+			nc.Resync()
+			if prev != nil {
+				prev(c)
+			}
+		})
+	}
+
+	nc.wg.Add(1)
+	go nc.run()
+
+	select {
+	case <-nc.ready:
+	case <-time.After(cfg.SyncTimeout):
+		nc.Close()
+		return nil, ErrNearCacheSync
+	}
+
+	return nc, nil
+}
+
+// run will watch the bucket, restarting the watcher on every resync or failure until the NearCache is closed.
+func (nc *NearCache) run() {
+	defer nc.wg.Done()
+
+	var tick <-chan time.Time
+	if nc.resyncInterval > 0 {
+		ticker := time.NewTicker(nc.resyncInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		nc.db.RLock()
+		kv := nc.db.kv
+		nc.db.RUnlock()
+		if kv == nil {
+			nc.setSynced(false)
+			return
+		}
+
+		w, err := kv.WatchAll()
+		if err == nil {
+			err = nc.watch(w, tick)
+			_ = w.Stop()
+		}
+
+		if err == nil {
+			return
+		}
+
+		// Restart the watcher, immediately when resyncing, otherwise after a delay
+		if !errors.Is(err, errResync) {
+			nc.setSynced(false)
+			select {
+			case <-nc.done:
+				return
+			case <-time.After(watchRetryInterval):
+			}
+		}
+	}
+}
+
+// errResync is returned by watch when the watcher should be restarted to resync the bucket.
+var errResync = errors.New("resync requested")
+
+// watch will apply the changes delivered by the watcher. It returns nil once closed, errResync when a resync is
+// requested and an error if the watcher stops.
+func (nc *NearCache) watch(w nats.KeyWatcher, tick <-chan time.Time) error {
+	seen := make(map[string]struct{})
+	initial := true
+
+	for {
+		select {
+		case <-nc.done:
+			return nil
+		case <-tick:
+			return errResync
+		case <-nc.resync:
+			return errResync
+		case entry, ok := <-w.Updates():
+			if !ok {
+				return fmt.Errorf("watcher stopped")
+			}
+
+			// A nil entry marks the end of the initial values
+			if entry == nil {
+				nc.sweep(seen)
+				initial = false
+				seen = nil
+				continue
+			}
+
+			if initial {
+				seen[entry.Key()] = struct{}{}
+			}
+			nc.apply(entry, initial)
+		}
+	}
+}
+
+// apply will update the in-memory entry for a change delivered by the watcher, ignoring changes older than the
+// revision already held.
+func (nc *NearCache) apply(entry nats.KeyValueEntry, initial bool) {
+	nc.lock.Lock()
+	if entry.Revision() > nc.revision {
+		nc.revision = entry.Revision()
+	}
+
+	if e, ok := nc.entries[entry.Key()]; !ok || entry.Revision() > e.revision {
+		switch entry.Operation() {
+		case nats.KeyValueDelete, nats.KeyValuePurge:
+			delete(nc.entries, entry.Key())
+		default:
+			nc.entries[entry.Key()] = nearEntry{data: entry.Value(), revision: entry.Revision()}
+		}
+	}
+	nc.lock.Unlock()
+
+	if initial {
+		return
+	}
+
+	nc.updates.Add(1)
+	lag := time.Since(entry.Created())
+	nc.lag.Store(int64(lag))
+	for {
+		m := nc.maxLag.Load()
+		if int64(lag) <= m || nc.maxLag.CompareAndSwap(m, int64(lag)) {
+			break
+		}
+	}
+}
+
+// sweep will remove entries not replayed by the initial values of the watcher, as they were deleted while the
+// watcher was behind. Entries written locally after the replay started are kept.
+func (nc *NearCache) sweep(seen map[string]struct{}) {
+	nc.lock.Lock()
+	for k, e := range nc.entries {
+		if _, ok := seen[k]; !ok && e.revision <= nc.revision {
+			delete(nc.entries, k)
+		}
+	}
+	nc.synced = true
+	nc.lock.Unlock()
+
+	nc.resyncs.Add(1)
+	nc.lastResync.Store(time.Now().UnixNano())
+	nc.readyOnce.Do(func() { close(nc.ready) })
+}
+
+// setSynced will record whether the watcher is running.
+func (nc *NearCache) setSynced(synced bool) {
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	nc.synced = synced
+}
+
+// Resync will replay the bucket in the background, applying missed changes and removing deleted keys.
+func (nc *NearCache) Resync() {
+	select {
+	case nc.resync <- struct{}{}:
+	default:
+	}
+}
+
+// Setup will run the Setup function of the Database.
+func (nc *NearCache) Setup() error {
+	if nc == nil || nc.db == nil {
+		return hord.ErrNoDial
+	}
+
+	return nc.db.Setup()
+}
+
+// HealthCheck will run the HealthCheck function of the Database, returning an error wrapping ErrNearCacheUnsynced
+// while the watcher is being restarted.
+func (nc *NearCache) HealthCheck() error {
+	if nc == nil || nc.db == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := nc.db.HealthCheck(); err != nil {
+		return err
+	}
+
+	nc.lock.RLock()
+	defer nc.lock.RUnlock()
+	if !nc.synced {
+		return errors.Join(hord.ErrHealthCheckFailure, ErrNearCacheUnsynced)
+	}
+
+	return nil
+}
+
+// Get will return the value of the key from memory.
+func (nc *NearCache) Get(key string) ([]byte, error) {
+	if nc == nil || nc.db == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := nc.db.validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	nc.lock.RLock()
+	defer nc.lock.RUnlock()
+
+	e, ok := nc.entries[key]
+	if !ok {
+		return nil, hord.ErrNil
+	}
+
+	// Streamed values are only mirrored as pointers and read from the NATS object store
+	if name, ok := objectName(e.data); ok {
+		nc.db.RLock()
+		defer nc.db.RUnlock()
+		return nc.db.getObject(name)
+	}
+
+	return append([]byte(nil), e.data...), nil
+}
+
+// Set will write the value to the bucket, applying it in memory once acknowledged.
+func (nc *NearCache) Set(key string, data []byte) error {
+	if nc == nil || nc.db == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := nc.db.validation.ValidKey(key); err != nil {
+		return err
+	}
+
+	if err := nc.db.validation.ValidData(data); err != nil {
+		return err
+	}
+
+	if _, ok := objectName(data); ok {
+		return hord.ErrInvalidData
+	}
+
+	nc.db.Lock()
+	if nc.db.kv == nil {
+		nc.db.Unlock()
+		return hord.ErrNoDial
+	}
+	previous := nc.db.previousObject(key)
+	revision, err := nc.db.kv.Put(key, data)
+	if err == nil {
+		nc.db.removeObject(previous)
+	}
+	nc.db.Unlock()
+	if err != nil {
+		return fmt.Errorf("unable to set key: %w", err)
+	}
+
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	if e, ok := nc.entries[key]; !ok || revision > e.revision {
+		nc.entries[key] = nearEntry{data: append([]byte(nil), data...), revision: revision}
+	}
+
+	return nil
+}
+
+// Delete will delete the key from the bucket and from memory. Changes to the key delivered by the watcher before the
+// deletion may briefly restore it.
+func (nc *NearCache) Delete(key string) error {
+	if nc == nil || nc.db == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := nc.db.Delete(key); err != nil {
+		return err
+	}
+
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	delete(nc.entries, key)
+
+	return nil
+}
+
+// Keys will return the keys held in memory.
+func (nc *NearCache) Keys() ([]string, error) {
+	if nc == nil || nc.db == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	nc.lock.RLock()
+	defer nc.lock.RUnlock()
+
+	keys := make([]string, 0, len(nc.entries))
+	for k := range nc.entries {
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// Stats returns a snapshot of the NearCache metrics.
+func (nc *NearCache) Stats() NearCacheStats {
+	nc.lock.RLock()
+	s := NearCacheStats{Entries: len(nc.entries), Revision: nc.revision, Synced: nc.synced}
+	nc.lock.RUnlock()
+
+	s.Updates = nc.updates.Load()
+	s.Lag = time.Duration(nc.lag.Load())
+	s.MaxLag = time.Duration(nc.maxLag.Load())
+	s.Resyncs = nc.resyncs.Load()
+	if t := nc.lastResync.Load(); t > 0 {
+		s.LastResync = time.Unix(0, t)
+	}
+
+	return s
+}
+
+// Close will stop the watcher and close the Database.
+func (nc *NearCache) Close() {
+	if nc == nil || nc.db == nil {
+		return
+	}
+
+	nc.closeOnce.Do(func() {
+		close(nc.done)
+		nc.wg.Wait()
+		nc.db.Close()
+	})
+}
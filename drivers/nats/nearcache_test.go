@@ -0,0 +1,182 @@
+package nats
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// waitFor is a helper function polling fn until it returns true or the deadline elapses.
+func waitFor(t *testing.T, fn func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the near cache")
+		}
+		<-time.After(10 * time.Millisecond)
+	}
+}
+
+func TestNearCache(t *testing.T) {
+	bucket := fmt.Sprintf("nearcache_%d", time.Now().UnixNano())
+
+	writer, err := Dial(Config{URL: "nats", Bucket: bucket})
+	if err != nil {
+		t.Fatalf("Failed to connect to NATS - %s", err)
+	}
+	defer writer.Close()
+
+	// Values written before the near cache is created are loaded during the initial sync
+	_ = writer.Set("existing", []byte("data"))
+	_ = writer.Set("removed", []byte("data"))
+
+	db, err := Dial(Config{URL: "nats", Bucket: bucket})
+	if err != nil {
+		t.Fatalf("Failed to connect to NATS - %s", err)
+	}
+
+	nc, err := DialNearCache(NearCacheConfig{Database: db})
+	if err != nil {
+		t.Fatalf("DialNearCache() returned error: %s", err)
+	}
+	defer nc.Close()
+
+	if err := nc.Setup(); err != nil {
+		t.Errorf("Setup() returned error: %s", err)
+	}
+
+	if err := nc.HealthCheck(); err != nil {
+		t.Errorf("HealthCheck() returned error: %s", err)
+	}
+
+	t.Run("Initial Sync", func(t *testing.T) {
+		data, err := nc.Get("existing")
+		if err != nil || string(data) != "data" {
+			t.Errorf("Get() returned %s - %v, expected data", data, err)
+		}
+
+		if s := nc.Stats(); s.Resyncs != 1 || s.Entries != 2 || !s.Synced || s.LastResync.IsZero() {
+			t.Errorf("Unexpected stats - %+v", s)
+		}
+	})
+
+	t.Run("Remote Changes", func(t *testing.T) {
+		_ = writer.Set("remote", []byte("value"))
+		_ = writer.Delete("removed")
+
+		waitFor(t, func() bool {
+			_, setErr := nc.Get("remote")
+			_, delErr := nc.Get("removed")
+			return setErr == nil && errors.Is(delErr, hord.ErrNil)
+		})
+
+		if s := nc.Stats(); s.Updates < 2 || s.Lag <= 0 || s.MaxLag < s.Lag || s.Revision < 4 {
+			t.Errorf("Unexpected stats - %+v", s)
+		}
+	})
+
+	t.Run("Local Changes", func(t *testing.T) {
+		if err := nc.Set("local", []byte("value")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		// Writers read their own writes without waiting for the watcher
+		data, err := nc.Get("local")
+		if err != nil || string(data) != "value" {
+			t.Errorf("Get() returned %s - %v, expected value", data, err)
+		}
+
+		if data, err := writer.Get("local"); err != nil || string(data) != "value" {
+			t.Errorf("Database returned %s - %v, expected value", data, err)
+		}
+
+		if err := nc.Delete("local"); err != nil {
+			t.Fatalf("Delete() returned error: %s", err)
+		}
+		if _, err := nc.Get("local"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+
+		keys, _ := nc.Keys()
+		sort.Strings(keys)
+		if fmt.Sprint(keys) != "[existing remote]" {
+			t.Errorf("Unexpected keys - %v", keys)
+		}
+	})
+
+	t.Run("Resync", func(t *testing.T) {
+		// Remove a key from memory only, simulating a missed change
+		nc.lock.Lock()
+		delete(nc.entries, "existing")
+		nc.lock.Unlock()
+
+		nc.Resync()
+		waitFor(t, func() bool { return nc.Stats().Resyncs >= 2 })
+
+		if data, err := nc.Get("existing"); err != nil || string(data) != "data" {
+			t.Errorf("Get() returned %s - %v, expected data", data, err)
+		}
+	})
+
+	t.Run("Invalid Keys", func(t *testing.T) {
+		if _, err := nc.Get(""); err == nil {
+			t.Errorf("Expected error when getting an empty key")
+		}
+		if err := nc.Set("key", nil); err == nil {
+			t.Errorf("Expected error when setting empty data")
+		}
+	})
+}
+
+func TestNearCacheResyncInterval(t *testing.T) {
+	db, err := Dial(Config{URL: "nats", Bucket: fmt.Sprintf("nearcache_%d", time.Now().UnixNano())})
+	if err != nil {
+		t.Fatalf("Failed to connect to NATS - %s", err)
+	}
+
+	nc, err := DialNearCache(NearCacheConfig{Database: db, ResyncInterval: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("DialNearCache() returned error: %s", err)
+	}
+	defer nc.Close()
+
+	waitFor(t, func() bool { return nc.Stats().Resyncs >= 3 })
+
+	t.Run("Disabled by Default", func(t *testing.T) {
+		db, err := Dial(Config{URL: "nats", Bucket: fmt.Sprintf("nearcache_%d", time.Now().UnixNano())})
+		if err != nil {
+			t.Fatalf("Failed to connect to NATS - %s", err)
+		}
+
+		nc, err := DialNearCache(NearCacheConfig{Database: db})
+		if err != nil {
+			t.Fatalf("DialNearCache() returned error: %s", err)
+		}
+		defer nc.Close()
+
+		// Only the initial sync replays the bucket
+		<-time.After(200 * time.Millisecond)
+		if s := nc.Stats(); s.Resyncs != 1 {
+			t.Errorf("Unexpected resyncs - %+v", s)
+		}
+	})
+}
+
+func TestNearCacheNotDialed(t *testing.T) {
+	if _, err := DialNearCache(NearCacheConfig{}); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("DialNearCache() returned error: %v, expected %s", err, hord.ErrNoDial)
+	}
+
+	var nc *NearCache
+	if _, err := nc.Get("key"); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNoDial)
+	}
+	if err := nc.HealthCheck(); !errors.Is(err, hord.ErrNoDial) {
+		t.Errorf("HealthCheck() returned error: %v, expected %s", err, hord.ErrNoDial)
+	}
+	nc.Close()
+}
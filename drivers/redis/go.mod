@@ -5,5 +5,8 @@ go 1.20
 require (
 	github.com/FZambia/sentinel v1.1.1
 	github.com/gomodule/redigo v1.9.2
-	github.com/tarmac-project/hord v0.8.2
+	github.com/tarmac-project/hord v0.9.0
 )
+
+// Build against the hord module within this repository until v0.9.0 is released.
+replace github.com/tarmac-project/hord => ../..
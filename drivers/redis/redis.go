@@ -113,6 +113,10 @@ type Config struct {
 	// and can be used to configure 2-way TLS for Redis and Redis Sentinel.
 	TLSConfig *tls.Config
 
+	// Validation defines the rules applied to keys and values before they are stored. By default, only empty keys
+	// and values are rejected.
+	Validation hord.ValidationPolicy
+
 	// WriteTimeout is used to specify a global write timeout for each Redis command.
 	WriteTimeout time.Duration
 }
@@ -240,14 +244,14 @@ func (db *Database) Setup() error {
 // Get is called to retrieve data from the database. This function will take in a key and return
 // the data or any errors received from querying the database.
 func (db *Database) Get(key string) ([]byte, error) {
-	if err := hord.ValidKey(key); err != nil {
-		return nil, err
-	}
-
 	if db == nil || db.pool == nil {
 		return nil, hord.ErrNoDial
 	}
 
+	if err := db.config.Validation.ValidKey(key); err != nil {
+		return nil, err
+	}
+
 	c := db.pool.Get()
 	defer c.Close() // nolint:errcheck
 
@@ -265,16 +269,16 @@ func (db *Database) Get(key string) ([]byte, error) {
 // Set is called when data within the database needs to be updated or inserted. This function will
 // take the data provided and create an entry within the database using the key as a lookup value.
 func (db *Database) Set(key string, data []byte) error {
-	if err := hord.ValidKey(key); err != nil {
-		return err
+	if db == nil || db.pool == nil {
+		return hord.ErrNoDial
 	}
 
-	if err := hord.ValidData(data); err != nil {
+	if err := db.config.Validation.ValidKey(key); err != nil {
 		return err
 	}
 
-	if db == nil || db.pool == nil {
-		return hord.ErrNoDial
+	if err := db.config.Validation.ValidData(data); err != nil {
+		return err
 	}
 
 	c := db.pool.Get()
@@ -291,14 +295,14 @@ func (db *Database) Set(key string, data []byte) error {
 // Delete is called when data within the database needs to be deleted. This function will delete
 // the data stored within the database for the specified key.
 func (db *Database) Delete(key string) error {
-	if err := hord.ValidKey(key); err != nil {
-		return err
-	}
-
 	if db == nil || db.pool == nil {
 		return hord.ErrNoDial
 	}
 
+	if err := db.config.Validation.ValidKey(key); err != nil {
+		return err
+	}
+
 	c := db.pool.Get()
 	defer c.Close() // nolint:errcheck
 
@@ -0,0 +1,40 @@
+package hord
+
+import (
+	"time"
+)
+
+// Revision is a historical value of a key.
+type Revision struct {
+	// Revision identifies this revision of the key. Revisions increase with each change to a key but are not
+	// guaranteed to be sequential.
+	Revision uint64
+
+	// Value is the value of the key at this revision. Value is empty when the revision records a deletion.
+	Value []byte
+
+	// Timestamp is the time this revision was created.
+	Timestamp time.Time
+
+	// Deleted is true when the revision records the deletion of the key.
+	Deleted bool
+}
+
+// Historian is an optional interface implemented by drivers that keep a history of values for each key. History
+// allows callers to audit changes and roll back to a previous value by writing it with Set.
+//
+// Callers should check whether a driver implements Historian with a type assertion.
+//
+//	if h, ok := db.(hord.Historian); ok {
+//	    revisions, err := h.History("key")
+//	    ...
+//	}
+type Historian interface {
+	// History returns the retained revisions of the specified key, ordered from oldest to newest. If the key has no
+	// history, ErrNil is returned.
+	History(key string) ([]Revision, error)
+
+	// GetAt returns the value of the specified key at the specified revision. If the revision does not exist or
+	// records a deletion, ErrNil is returned.
+	GetAt(key string, revision uint64) ([]byte, error)
+}
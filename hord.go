@@ -53,10 +53,42 @@ Once you have a database client, you can use it to perform various database oper
 
 Refer to the `hord.Database` interface documentation for a complete list of available methods.
 
+# Streaming
+
+Drivers that can read and write values as streams implement the optional `hord.Streamer` interface. Use a type assertion to check for streaming support.
+
+	if s, ok := db.(hord.Streamer); ok {
+	    w, err := s.OpenWriter("key")
+	    if err != nil {
+	        // Handle error
+	    }
+	    // Write to w, then Close it to store the value
+	}
+
+# History
+
+Drivers that retain previous values implement the optional `hord.Historian` interface. Databases without native history support can be wrapped with the versioned package.
+
+	if h, ok := db.(hord.Historian); ok {
+	    revisions, err := h.History("key")
+	    if err != nil {
+	        // Handle error
+	    }
+	    // Read a previous value with h.GetAt("key", revisions[0].Revision)
+	}
+
 # Error Handling
 
 Hord provides common error types and constants for consistent error handling across drivers. Refer to the `hord` package documentation for more information on error handling.
 
+# Validation
+
+Each driver accepts a `hord.ValidationPolicy` within its configuration. The policy defines limits such as the maximum key length, allowed key characters, maximum value size, and reserved key prefixes. Using the same policy across drivers ensures invalid keys and values fail with the same errors regardless of the underlying database.
+
+	db, err := redis.Dial(redis.Config{
+	    Validation: hord.PortableValidationPolicy,
+	})
+
 # Contributing
 
 Contributions to Hord are welcome! If you want to add support for a new database driver or improve the existing codebase, please refer to the contribution guidelines in the project's repository.
@@ -108,6 +140,7 @@ var (
 	ErrInvalidDatabase    = fmt.Errorf("database cannot be nil")
 	ErrCacheError         = fmt.Errorf("cache error")
 	ErrHealthCheckFailure = fmt.Errorf("health check failed")
+	ErrStale              = fmt.Errorf("stale data served from cache")
 )
 
 // ValidKey checks if a key is valid.
@@ -0,0 +1,24 @@
+package hord
+
+import (
+	"io"
+)
+
+// Streamer is an optional interface implemented by drivers that can read and write values as streams. Streaming
+// avoids holding an entire value in memory, which is useful for large, file-like payloads.
+//
+// Callers should check whether a driver implements Streamer with a type assertion.
+//
+//	if s, ok := db.(hord.Streamer); ok {
+//	    r, err := s.OpenReader("key")
+//	    ...
+//	}
+type Streamer interface {
+	// OpenReader opens a reader for the value of the specified key. If the key does not exist, ErrNil is returned.
+	// Callers must close the returned reader.
+	OpenReader(key string) (io.ReadCloser, error)
+
+	// OpenWriter opens a writer for the value of the specified key. The value is stored once the writer is closed
+	// and any error storing the value is returned from Close.
+	OpenWriter(key string) (io.WriteCloser, error)
+}
@@ -0,0 +1,84 @@
+package hord
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ValidationPolicy defines the rules applied to keys and values before they are sent to a database.
+//
+// Each database has different practical limits for keys and values. Applying the same ValidationPolicy to every
+// driver ensures that invalid keys and values fail with the same errors regardless of the underlying database.
+//
+// The zero value of ValidationPolicy only rejects empty keys and empty values, matching ValidKey and ValidData.
+type ValidationPolicy struct {
+	// MaxKeyLength is the maximum length, in bytes, of a key. A value of 0 disables the check.
+	MaxKeyLength int
+
+	// KeyPattern restricts the characters allowed within a key. Keys must match the provided regular expression,
+	// which should be anchored to validate the entire key. A nil KeyPattern allows any character.
+	KeyPattern *regexp.Regexp
+
+	// MaxValueSize is the maximum size, in bytes, of a value. A value of 0 disables the check.
+	MaxValueSize int
+
+	// ReservedPrefixes is a list of key prefixes that are reserved for internal use and cannot be used by callers.
+	ReservedPrefixes []string
+}
+
+// Validation Errors returned by ValidationPolicy. Key errors wrap ErrInvalidKey and data errors wrap ErrInvalidData.
+var (
+	ErrKeyTooLong           = fmt.Errorf("%w: key exceeds maximum length", ErrInvalidKey)
+	ErrKeyInvalidCharacters = fmt.Errorf("%w: key contains invalid characters", ErrInvalidKey)
+	ErrKeyReserved          = fmt.Errorf("%w: key uses a reserved prefix", ErrInvalidKey)
+	ErrDataTooLarge         = fmt.Errorf("%w: data exceeds maximum size", ErrInvalidData)
+)
+
+// PortableValidationPolicy is a ValidationPolicy that accepts only keys and values usable by every Hord driver.
+//
+// Keys are limited to the character set supported by NATS key-value stores and the key size supported by bbolt.
+// Values are limited to the default maximum payload size of a NATS server.
+var PortableValidationPolicy = ValidationPolicy{
+	MaxKeyLength: 32768,
+	KeyPattern:   regexp.MustCompile(`^[-/_=.a-zA-Z0-9]+$`),
+	MaxValueSize: 1024 * 1024,
+}
+
+// ValidKey checks if a key is valid according to the policy.
+// Returns nil if the key is valid, otherwise returns an error wrapping ErrInvalidKey.
+func (p ValidationPolicy) ValidKey(key string) error {
+	if err := ValidKey(key); err != nil {
+		return err
+	}
+
+	if p.MaxKeyLength > 0 && len(key) > p.MaxKeyLength {
+		return ErrKeyTooLong
+	}
+
+	if p.KeyPattern != nil && !p.KeyPattern.MatchString(key) {
+		return ErrKeyInvalidCharacters
+	}
+
+	for _, prefix := range p.ReservedPrefixes {
+		if prefix != "" && strings.HasPrefix(key, prefix) {
+			return ErrKeyReserved
+		}
+	}
+
+	return nil
+}
+
+// ValidData checks if data is valid according to the policy.
+// Returns nil if the data is valid, otherwise returns an error wrapping ErrInvalidData.
+func (p ValidationPolicy) ValidData(data []byte) error {
+	if err := ValidData(data); err != nil {
+		return err
+	}
+
+	if p.MaxValueSize > 0 && len(data) > p.MaxValueSize {
+		return ErrDataTooLarge
+	}
+
+	return nil
+}
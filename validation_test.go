@@ -0,0 +1,87 @@
+package hord
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestValidationPolicy(t *testing.T) {
+	policy := ValidationPolicy{
+		MaxKeyLength:     16,
+		KeyPattern:       regexp.MustCompile(`^[a-z0-9_:]+$`),
+		MaxValueSize:     8,
+		ReservedPrefixes: []string{"_hord:"},
+	}
+
+	t.Run("Keys", func(t *testing.T) {
+		unitTests := map[string]struct {
+			key           string
+			expectedError error
+		}{
+			"Valid Key":         {key: "user:1", expectedError: nil},
+			"Empty Key":         {key: "", expectedError: ErrInvalidKey},
+			"Key Too Long":      {key: strings.Repeat("a", 17), expectedError: ErrKeyTooLong},
+			"Invalid Character": {key: "user 1", expectedError: ErrKeyInvalidCharacters},
+			"Reserved Prefix":   {key: "_hord:chunk", expectedError: ErrKeyReserved},
+		}
+
+		for name, test := range unitTests {
+			t.Run(name, func(t *testing.T) {
+				err := policy.ValidKey(test.key)
+				if !errors.Is(err, test.expectedError) {
+					t.Errorf("ValidKey(%s) returned error: %s, expected %s", test.key, err, test.expectedError)
+				}
+				if test.expectedError != nil && !errors.Is(err, ErrInvalidKey) {
+					t.Errorf("ValidKey(%s) returned error: %s, expected error to wrap ErrInvalidKey", test.key, err)
+				}
+			})
+		}
+	})
+
+	t.Run("Data", func(t *testing.T) {
+		unitTests := map[string]struct {
+			data          []byte
+			expectedError error
+		}{
+			"Valid Data":     {data: []byte("data"), expectedError: nil},
+			"Empty Data":     {data: []byte{}, expectedError: ErrInvalidData},
+			"Data Too Large": {data: []byte("too much data"), expectedError: ErrDataTooLarge},
+		}
+
+		for name, test := range unitTests {
+			t.Run(name, func(t *testing.T) {
+				err := policy.ValidData(test.data)
+				if !errors.Is(err, test.expectedError) {
+					t.Errorf("ValidData(%v) returned error: %s, expected %s", test.data, err, test.expectedError)
+				}
+				if test.expectedError != nil && !errors.Is(err, ErrInvalidData) {
+					t.Errorf("ValidData(%v) returned error: %s, expected error to wrap ErrInvalidData", test.data, err)
+				}
+			})
+		}
+	})
+
+	t.Run("Zero Value", func(t *testing.T) {
+		var p ValidationPolicy
+		if err := p.ValidKey(strings.Repeat("a", 100000)); err != nil {
+			t.Errorf("ValidKey() returned error: %s, expected nil", err)
+		}
+		if err := p.ValidKey(""); err != ErrInvalidKey {
+			t.Errorf("ValidKey() returned error: %s, expected %s", err, ErrInvalidKey)
+		}
+		if err := p.ValidData(nil); err != ErrInvalidData {
+			t.Errorf("ValidData() returned error: %s, expected %s", err, ErrInvalidData)
+		}
+	})
+
+	t.Run("Portable Policy", func(t *testing.T) {
+		if err := PortableValidationPolicy.ValidKey("config/app.name"); err != nil {
+			t.Errorf("ValidKey() returned error: %s, expected nil", err)
+		}
+		if err := PortableValidationPolicy.ValidKey("Testing 1000 keys"); !errors.Is(err, ErrKeyInvalidCharacters) {
+			t.Errorf("ValidKey() returned error: %s, expected %s", err, ErrKeyInvalidCharacters)
+		}
+	})
+}
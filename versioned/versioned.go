@@ -0,0 +1,402 @@
+/*
+Package versioned provides a Hord database driver that keeps a history of values for each key. To use this driver, import it as follows:
+
+	import (
+	    "github.com/tarmac-project/hord"
+	    "github.com/tarmac-project/hord/versioned"
+	)
+
+Each Set and Delete records a new revision of the key, retaining up to the configured maximum number of versions.
+Revisions can be listed with History() and read with GetAt(), allowing changes to be audited and rolled back on any
+Hord database. Drivers with native history support, such as NATS, implement hord.Historian directly.
+
+Every revision is stored under its own history key, alongside a small index listing the retained revisions of the key,
+so any value that fits within the database on its own can also be recorded as a revision.
+
+# Connecting to the Database
+
+Use the Dial() function to create a new client for interacting with the versioned driver.
+
+	// Handle database connection
+	var database hord.Database
+	...
+
+	var db hord.Database
+	db, err := versioned.Dial(versioned.Config{
+		Database:    database,
+		MaxVersions: 10,
+	})
+	if err != nil {
+	    // Handle connection error
+	}
+
+# Initialize database
+
+Hord provides a Setup() function for preparing a database. This function is safe to execute after every Dial().
+
+	err := db.Setup()
+	if err != nil {
+	    // Handle setup error
+	}
+
+# Database Operations
+
+Hord provides a simple abstraction for working with the versioned driver, with easy-to-use methods such as Get() and Set() to read and write values.
+
+	// Set a value
+	err = db.Set("key", []byte("value"))
+	if err != nil {
+	    // Handle error
+	}
+
+	// List previous values
+	revisions, err := db.History("key")
+	if err != nil {
+	    // Handle error
+	}
+
+	// Roll back to the first revision
+	value, err := db.GetAt("key", revisions[0].Revision)
+	if err != nil {
+	    // Handle error
+	}
+
+	err = db.Set("key", value)
+	if err != nil {
+	    // Handle error
+	}
+*/
+package versioned
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tarmac-project/hord"
+)
+
+// Config provides the configuration options for the Versioned driver.
+type Config struct {
+	// Database is the underlying database used to store values and history.
+	Database hord.Database
+
+	// MaxVersions is the maximum number of revisions retained for each key. Default is 10.
+	MaxVersions int
+
+	// Prefix is the key prefix used for history keys. Keys using this prefix are hidden from Keys() and cannot be
+	// written by callers. Default is "_history.".
+	Prefix string
+}
+
+// Versioned is used to keep a history of values for each key. It satisfies the Hord database and hord.Historian
+// interfaces.
+type Versioned struct {
+	sync.Mutex
+
+	data        hord.Database
+	maxVersions int
+	prefix      string
+}
+
+const (
+	// DefaultMaxVersions is the default number of revisions retained for each key.
+	DefaultMaxVersions = 10
+
+	// DefaultPrefix is the default key prefix used for history keys.
+	DefaultPrefix = "_history."
+)
+
+// Dial will create a new Versioned driver using the provided Config. It will return an error if the Database is nil.
+func Dial(cfg Config) (*Versioned, error) {
+	if cfg.Database == nil {
+		return nil, hord.ErrInvalidDatabase
+	}
+
+	db := &Versioned{
+		data:        cfg.Database,
+		maxVersions: cfg.MaxVersions,
+		prefix:      cfg.Prefix,
+	}
+
+	if db.maxVersions <= 0 {
+		db.maxVersions = DefaultMaxVersions
+	}
+
+	if db.prefix == "" {
+		db.prefix = DefaultPrefix
+	}
+
+	return db, nil
+}
+
+// Setup will run the Setup function for the underlying database.
+func (db *Versioned) Setup() error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.Setup()
+}
+
+// HealthCheck will run the HealthCheck function for the underlying database.
+func (db *Versioned) HealthCheck() error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	return db.data.HealthCheck()
+}
+
+// Get will fetch the current value for the specified key.
+func (db *Versioned) Get(key string) ([]byte, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.validKey(key); err != nil {
+		return nil, err
+	}
+
+	return db.data.Get(key)
+}
+
+// Set will store the value for the specified key and record it as a new revision.
+func (db *Versioned) Set(key string, data []byte) error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.validKey(key); err != nil {
+		return err
+	}
+
+	if err := hord.ValidData(data); err != nil {
+		return err
+	}
+
+	db.Lock()
+	defer db.Unlock()
+
+	err := db.data.Set(key, data)
+	if err != nil {
+		return err
+	}
+
+	return db.record(key, hord.Revision{Value: data})
+}
+
+// Delete will delete the value for the specified key and record the deletion as a new revision. History is retained
+// after a key is deleted.
+func (db *Versioned) Delete(key string) error {
+	if db == nil || db.data == nil {
+		return hord.ErrNoDial
+	}
+
+	if err := db.validKey(key); err != nil {
+		return err
+	}
+
+	db.Lock()
+	defer db.Unlock()
+
+	err := db.data.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	return db.record(key, hord.Revision{Deleted: true})
+}
+
+// Keys will return the keys from the underlying database, excluding history keys.
+func (db *Versioned) Keys() ([]string, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	keys, err := db.data.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []string
+	for _, k := range keys {
+		if !strings.HasPrefix(k, db.prefix) {
+			filtered = append(filtered, k)
+		}
+	}
+
+	return filtered, nil
+}
+
+// Close will close the underlying database.
+func (db *Versioned) Close() {
+	if db != nil && db.data != nil {
+		db.data.Close()
+	}
+}
+
+// History returns the retained revisions of the specified key, ordered from oldest to newest.
+func (db *Versioned) History(key string) ([]hord.Revision, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.validKey(key); err != nil {
+		return nil, err
+	}
+
+	return db.history(key)
+}
+
+// GetAt returns the value of the specified key at the specified revision.
+func (db *Versioned) GetAt(key string, revision uint64) ([]byte, error) {
+	if db == nil || db.data == nil {
+		return nil, hord.ErrNoDial
+	}
+
+	if err := db.validKey(key); err != nil {
+		return nil, err
+	}
+
+	entries, err := db.index(key)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.Revision == revision && !e.Deleted {
+			return db.data.Get(db.revisionKey(key, revision))
+		}
+	}
+
+	return nil, hord.ErrNil
+}
+
+// GetDatabase will return the underlying database.
+func (db *Versioned) GetDatabase() hord.Database {
+	return db.data
+}
+
+// validKey will validate the key and reject keys using the history prefix.
+func (db *Versioned) validKey(key string) error {
+	if err := hord.ValidKey(key); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(key, db.prefix) {
+		return hord.ErrKeyReserved
+	}
+
+	return nil
+}
+
+// entry describes a revision within the index of a key. Values are stored separately under their revision key.
+type entry struct {
+	Revision  uint64    `json:"revision"`
+	Timestamp time.Time `json:"timestamp"`
+	Deleted   bool      `json:"deleted,omitempty"`
+}
+
+// indexKey returns the history key holding the index of the specified key.
+func (db *Versioned) indexKey(key string) string {
+	return db.prefix + "index." + key
+}
+
+// revisionKey returns the history key holding the value of the specified key at the specified revision.
+func (db *Versioned) revisionKey(key string, revision uint64) string {
+	return db.prefix + "rev." + strconv.FormatUint(revision, 10) + "." + key
+}
+
+// index will fetch and decode the index of the specified key.
+func (db *Versioned) index(key string) ([]entry, error) {
+	data, err := db.data.Get(db.indexKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode history: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, hord.ErrNil
+	}
+
+	return entries, nil
+}
+
+// history will fetch the retained revisions of the specified key along with their values.
+func (db *Versioned) history(key string) ([]hord.Revision, error) {
+	entries, err := db.index(key)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]hord.Revision, 0, len(entries))
+	for _, e := range entries {
+		r := hord.Revision{Revision: e.Revision, Timestamp: e.Timestamp, Deleted: e.Deleted}
+		if !e.Deleted {
+			r.Value, err = db.data.Get(db.revisionKey(key, e.Revision))
+			if err != nil {
+				return nil, fmt.Errorf("unable to fetch revision %d: %w", e.Revision, err)
+			}
+		}
+		revisions = append(revisions, r)
+	}
+
+	return revisions, nil
+}
+
+// record will store the value of a revision, append it to the index of the specified key and remove the oldest
+// revisions beyond the maximum number of versions. It must be called while holding the lock.
+func (db *Versioned) record(key string, r hord.Revision) error {
+	entries, err := db.index(key)
+	if err != nil && !errors.Is(err, hord.ErrNil) {
+		return fmt.Errorf("unable to record revision: %w", err)
+	}
+
+	e := entry{Revision: 1, Timestamp: time.Now(), Deleted: r.Deleted}
+	if len(entries) > 0 {
+		e.Revision = entries[len(entries)-1].Revision + 1
+	}
+
+	if !e.Deleted {
+		err = db.data.Set(db.revisionKey(key, e.Revision), r.Value)
+		if err != nil {
+			return fmt.Errorf("unable to record revision: %w", err)
+		}
+	}
+
+	entries = append(entries, e)
+	var trimmed []entry
+	if len(entries) > db.maxVersions {
+		trimmed = entries[:len(entries)-db.maxVersions]
+		entries = entries[len(entries)-db.maxVersions:]
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("unable to encode history: %w", err)
+	}
+
+	err = db.data.Set(db.indexKey(key), data)
+	if err != nil {
+		return fmt.Errorf("unable to record revision: %w", err)
+	}
+
+	// Trimmed revisions are no longer listed, so failing to remove them only leaves unused history keys behind
+	for _, t := range trimmed {
+		if !t.Deleted {
+			_ = db.data.Delete(db.revisionKey(key, t.Revision))
+		}
+	}
+
+	return nil
+}
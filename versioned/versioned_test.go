@@ -0,0 +1,277 @@
+package versioned
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/tarmac-project/hord"
+	"github.com/tarmac-project/hord/drivers/hashmap"
+	"github.com/tarmac-project/hord/drivers/mock"
+)
+
+// ErrDatabaseTest is used for testing purposes
+var ErrDatabaseTest = errors.New("database error")
+
+// setupVersioned is a helper function to create a new Versioned driver backed by a hashmap database.
+func setupVersioned(t *testing.T, maxVersions int) (*Versioned, *hashmap.Database) {
+	database, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	db, err := Dial(Config{
+		Database:    database,
+		MaxVersions: maxVersions,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create versioned driver - %s", err)
+	}
+
+	return db, database
+}
+
+func TestDial(t *testing.T) {
+	t.Run("No Database", func(t *testing.T) {
+		_, err := Dial(Config{})
+		if !errors.Is(err, hord.ErrInvalidDatabase) {
+			t.Errorf("Dial() returned error: %s, expected %s", err, hord.ErrInvalidDatabase)
+		}
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		db, err := Dial(Config{Database: &mock.Database{}})
+		if err != nil {
+			t.Fatalf("Dial() returned error: %s", err)
+		}
+		if db.maxVersions != DefaultMaxVersions || db.prefix != DefaultPrefix {
+			t.Errorf("Dial() did not set defaults - %+v", db)
+		}
+	})
+
+	t.Run("Historian", func(t *testing.T) {
+		var _ hord.Historian = &Versioned{}
+	})
+}
+
+func TestHistory(t *testing.T) {
+	db, database := setupVersioned(t, 3)
+
+	t.Run("No History", func(t *testing.T) {
+		_, err := db.History("key")
+		if !errors.Is(err, hord.ErrNil) {
+			t.Errorf("History() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+	})
+
+	t.Run("Record Revisions", func(t *testing.T) {
+		for _, v := range []string{"v1", "v2"} {
+			if err := db.Set("key", []byte(v)); err != nil {
+				t.Fatalf("Set() returned error: %s", err)
+			}
+		}
+
+		revisions, err := db.History("key")
+		if err != nil {
+			t.Fatalf("History() returned error: %s", err)
+		}
+		if len(revisions) != 2 {
+			t.Fatalf("Unexpected number of revisions - got %d, expected 2", len(revisions))
+		}
+		for i, r := range revisions {
+			if r.Revision != uint64(i+1) || r.Deleted || r.Timestamp.IsZero() {
+				t.Errorf("Unexpected revision %d - %+v", i, r)
+			}
+		}
+
+		data, err := db.Get("key")
+		if err != nil || string(data) != "v2" {
+			t.Errorf("Get() returned %s - %v, expected v2", data, err)
+		}
+	})
+
+	t.Run("Get At Revision", func(t *testing.T) {
+		data, err := db.GetAt("key", 1)
+		if err != nil {
+			t.Fatalf("GetAt() returned error: %s", err)
+		}
+		if string(data) != "v1" {
+			t.Errorf("GetAt() returned %s, expected v1", data)
+		}
+
+		_, err = db.GetAt("key", 10)
+		if !errors.Is(err, hord.ErrNil) {
+			t.Errorf("GetAt() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+	})
+
+	t.Run("Delete Retains History", func(t *testing.T) {
+		if err := db.Delete("key"); err != nil {
+			t.Fatalf("Delete() returned error: %s", err)
+		}
+
+		if _, err := db.Get("key"); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("Get() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+
+		revisions, err := db.History("key")
+		if err != nil {
+			t.Fatalf("History() returned error: %s", err)
+		}
+		last := revisions[len(revisions)-1]
+		if !last.Deleted || last.Revision != 3 {
+			t.Errorf("Expected deletion to be recorded as revision 3 - %+v", last)
+		}
+
+		if _, err := db.GetAt("key", 3); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("GetAt() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+	})
+
+	t.Run("Trim To Max Versions", func(t *testing.T) {
+		if err := db.Set("key", []byte("v4")); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+
+		revisions, err := db.History("key")
+		if err != nil {
+			t.Fatalf("History() returned error: %s", err)
+		}
+		if len(revisions) != 3 || revisions[0].Revision != 2 || revisions[2].Revision != 4 {
+			t.Errorf("Unexpected revisions after trimming - %+v", revisions)
+		}
+
+		if _, err := db.GetAt("key", 1); !errors.Is(err, hord.ErrNil) {
+			t.Errorf("GetAt() returned error: %v, expected %s", err, hord.ErrNil)
+		}
+	})
+
+	t.Run("Hide History Keys", func(t *testing.T) {
+		keys, err := db.Keys()
+		if err != nil {
+			t.Fatalf("Keys() returned error: %s", err)
+		}
+		if len(keys) != 1 || keys[0] != "key" {
+			t.Errorf("Unexpected keys returned - %v", keys)
+		}
+
+		all, err := database.Keys()
+		if err != nil {
+			t.Fatalf("Keys() returned error: %s", err)
+		}
+		// The index and the values of retained revisions are stored, while trimmed revisions are removed
+		sort.Strings(all)
+		expected := []string{"_history.index.key", "_history.rev.2.key", "_history.rev.4.key", "key"}
+		if fmt.Sprint(all) != fmt.Sprint(expected) {
+			t.Errorf("Unexpected keys within underlying database - %v, expected %v", all, expected)
+		}
+	})
+
+	t.Run("Reserved Prefix", func(t *testing.T) {
+		err := db.Set(DefaultPrefix+"key", []byte("data"))
+		if !errors.Is(err, hord.ErrKeyReserved) {
+			t.Errorf("Set() returned error: %v, expected %s", err, hord.ErrKeyReserved)
+		}
+
+		_, err = db.History(DefaultPrefix + "key")
+		if !errors.Is(err, hord.ErrKeyReserved) {
+			t.Errorf("History() returned error: %v, expected %s", err, hord.ErrKeyReserved)
+		}
+	})
+}
+
+func TestHistoryValueSize(t *testing.T) {
+	backing, err := hashmap.Dial(hashmap.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database - %s", err)
+	}
+
+	// Database rejecting values larger than 1KB
+	database, err := mock.Dial(mock.Config{
+		GetFunc:    backing.Get,
+		DeleteFunc: backing.Delete,
+		KeysFunc:   backing.Keys,
+		SetFunc: func(key string, data []byte) error {
+			if len(data) > 1024 {
+				return hord.ErrInvalidData
+			}
+			return backing.Set(key, data)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create mock database - %s", err)
+	}
+
+	db, err := Dial(Config{Database: database})
+	if err != nil {
+		t.Fatalf("Failed to create versioned driver - %s", err)
+	}
+
+	// Every value fitting within the database on its own can be recorded
+	for i := 0; i < DefaultMaxVersions; i++ {
+		if err := db.Set("key", bytes.Repeat([]byte{byte('a' + i)}, 1000)); err != nil {
+			t.Fatalf("Set() returned error: %s", err)
+		}
+	}
+
+	revisions, err := db.History("key")
+	if err != nil || len(revisions) != DefaultMaxVersions {
+		t.Fatalf("History() returned %d revisions - %v", len(revisions), err)
+	}
+
+	data, err := db.GetAt("key", 1)
+	if err != nil || !bytes.Equal(data, bytes.Repeat([]byte("a"), 1000)) {
+		t.Errorf("GetAt() returned %d bytes - %v", len(data), err)
+	}
+}
+
+func TestHistoryFailures(t *testing.T) {
+	tt := map[string]struct {
+		cfg mock.Config
+		err error
+	}{
+		"Set Value Fails": {
+			cfg: mock.Config{
+				SetFunc: func(_ string, _ []byte) error { return ErrDatabaseTest },
+			},
+			err: ErrDatabaseTest,
+		},
+		"History Fetch Fails": {
+			cfg: mock.Config{
+				SetFunc: func(_ string, _ []byte) error { return nil },
+				GetFunc: func(_ string) ([]byte, error) { return nil, ErrDatabaseTest },
+			},
+			err: ErrDatabaseTest,
+		},
+		"Invalid History": {
+			cfg: mock.Config{
+				SetFunc: func(_ string, _ []byte) error { return nil },
+				GetFunc: func(_ string) ([]byte, error) { return []byte("not json"), nil },
+			},
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			database, err := mock.Dial(tc.cfg)
+			if err != nil {
+				t.Fatalf("Failed to create mock database - %s", err)
+			}
+
+			db, err := Dial(Config{Database: database})
+			if err != nil {
+				t.Fatalf("Failed to create versioned driver - %s", err)
+			}
+
+			err = db.Set("key", []byte("data"))
+			if err == nil {
+				t.Fatalf("Set() returned nil error")
+			}
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Errorf("Set() returned error: %s, expected %s", err, tc.err)
+			}
+		})
+	}
+}